@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/serialexecutor"
+	backups "pb_launcher/internal/backups/domain"
+)
+
+func RegisterBackupRunner(
+	executor *serialexecutor.SequentialExecutor,
+	backupManager *backups.BackupManager,
+	config configs.Config) error {
+
+	backupTask := serialexecutor.NewTask(
+		func(ctx context.Context) {
+			if err := backupManager.Run(ctx); err != nil {
+				slog.Error("backup task failed", "error", err, "task", "backupRunner")
+			}
+		},
+		config.GetBackupCheckInterval(),
+		9000,
+	)
+
+	return executor.Add(backupTask.WithName("backupRunner"))
+}
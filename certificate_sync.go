@@ -70,7 +70,7 @@ func RegisterCertificateAutoRenewal(
 		math.MaxInt,
 	)
 
-	return executor.Add(certificateTask)
+	return executor.Add(certificateTask.WithName("certificateRenewal"))
 }
 
 func RegisterCertRequestPlanner(
@@ -97,7 +97,7 @@ func RegisterCertRequestPlanner(
 		cfg.GetCertRequestPlannerInterval(),
 		1,
 	)
-	return executor.Add(plannerTask)
+	return executor.Add(plannerTask.WithName("certRequestPlanner"))
 }
 
 func RegisterCertRequestExecutor(
@@ -115,5 +115,5 @@ func RegisterCertRequestExecutor(
 		0,
 	)
 
-	return executor.Add(certRequestTask)
+	return executor.Add(certRequestTask.WithName("certRequestExecutor"))
 }
@@ -7,3 +7,8 @@ const ServicesDomains = "services_domains"
 const ServicesComands = "comands"
 const CertRequests = "cert_requests"
 const ProxyEntries = "proxy_entries"
+const ServiceEnv = "service_env"
+const ServiceBackups = "service_backups"
+const ServiceTemplates = "service_templates"
+const ServiceSchedules = "service_schedules"
+const ServiceEvents = "service_events"
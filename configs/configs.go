@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"path"
 	"strconv"
@@ -21,12 +22,58 @@ type TlsConfig interface {
 
 type Config interface {
 	GetBindIPAddress() string
+	GetPortRange() (start int, end int)
 	GetReleaseSyncInterval() time.Duration
 	GetCommandCheckInterval() time.Duration
 	GetCertificateCheckInterval() time.Duration
+	GetHealthCheckInterval() time.Duration
+	GetHealthCheckTimeout() time.Duration
+	GetHealthCheckFailureThreshold() int
+	GetStartTimeout() time.Duration
+
+	GetMaxRestarts() int
+	GetRestartWindow() time.Duration
+	GetRestartBackoffBase() time.Duration
+	GetRestartBackoffMax() time.Duration
+
+	IsDetachOnShutdown() bool
+	GetRecoveryConcurrency() int
+	GetRecoveryReadyTimeout() time.Duration
+	GetCgroupParent() string
+	GetIsolationMode() string
+	GetIsolationUIDRange() (start int, end int)
+
+	IsZeroDowntimeRestart() bool
+	GetSwapHealthTimeout() time.Duration
+	GetDrainTimeout() time.Duration
+
+	GetBackupDir() string
+	GetBackupRetention() int
+	GetBackupCheckInterval() time.Duration
+	GetBackupTarget() string
+	GetBackupS3Config() BackupS3Config
+
+	GetTrashRetention() time.Duration
+	GetTrashPurgeInterval() time.Duration
+	IsTrashArchiveEnabled() bool
+
+	GetScheduleCheckInterval() time.Duration
+	GetScheduleCatchUpWindow() time.Duration
+
+	GetIdleCheckInterval() time.Duration
+	GetWakeTimeout() time.Duration
+
+	GetMetricsInterval() time.Duration
+	GetMetricsRetention() time.Duration
+	GetPrometheusListenAddress() string
+	GetPrometheusBearerToken() string
+
+	GetFilesMaxSize() int64
+	GetFilesMaxAreaSize() int64
 
 	GetDownloadDir() string
 	GetDataDir() string
+	GetMasterKeyFile() string
 
 	GetCertificatesDir() string
 	GetAccountsDir() string
@@ -74,12 +121,96 @@ func (c *tls_configs) GetProp(key string) (string, bool) {
 	return val, ok
 }
 
+// BackupS3Config points the backup archives to an S3 compatible storage.
+type BackupS3Config struct {
+	Endpoint       string
+	Region         string
+	Bucket         string
+	Prefix         string // prepended to every archive key
+	AccessKey      string
+	SecretKey      string
+	ForcePathStyle bool
+	PartSize       int // bytes per multipart upload part
+	// LauncherBackups also sends the launcher's own PocketBase backups to the bucket.
+	LauncherBackups bool
+}
+
+type backup_s3_configs struct {
+	Endpoint        string `mapstructure:"endpoint" yaml:"endpoint"`
+	Region          string `mapstructure:"region" yaml:"region"` // default: us-east-1
+	Bucket          string `mapstructure:"bucket" yaml:"bucket"`
+	Prefix          string `mapstructure:"prefix" yaml:"prefix"`
+	AccessKey       string `mapstructure:"access_key" yaml:"access_key"` // default: $AWS_ACCESS_KEY_ID
+	SecretKey       string `mapstructure:"secret_key" yaml:"secret_key"` // default: $AWS_SECRET_ACCESS_KEY
+	ForcePathStyle  bool   `mapstructure:"force_path_style" yaml:"force_path_style"`
+	PartSizeMB      int    `mapstructure:"part_size_mb" yaml:"part_size_mb"` // default: 8
+	LauncherBackups bool   `mapstructure:"launcher_backups" yaml:"launcher_backups"`
+}
+
+type backups_configs struct {
+	Target        string            `mapstructure:"target" yaml:"target"`                 // local or s3, default: local
+	Dir           string            `mapstructure:"dir" yaml:"dir"`                       // default: ./backups
+	Retention     int               `mapstructure:"retention" yaml:"retention"`           // default: 7
+	CheckInterval string            `mapstructure:"check_interval" yaml:"check_interval"` // default: 1m
+	S3            backup_s3_configs `mapstructure:"s3" yaml:"s3"`
+}
+
+type prometheus_configs struct {
+	ListenAddress string `mapstructure:"listen_address" yaml:"listen_address"` // e.g. 127.0.0.1:9100, empty disables the exporter
+	BearerToken   string `mapstructure:"bearer_token" yaml:"bearer_token"`     // default: $PB_LAUNCHER_METRICS_TOKEN
+}
+
+type isolation_configs struct {
+	Mode     string `mapstructure:"mode" yaml:"mode"`           // user or namespace, default: disabled
+	UIDRange string `mapstructure:"uid_range" yaml:"uid_range"` // default: 200000-299999
+}
+
 type configs struct {
 	BindAddress              string `mapstructure:"bind_address" yaml:"bind_address"`                             // default: 127.0.0.1
+	PortRange                string `mapstructure:"port_range" yaml:"port_range"`                                 // e.g. 20000-29999, default: any free port
 	ReleaseSyncInterval      string `mapstructure:"release_sync_interval" yaml:"release_sync_interval"`           // default: 10m
 	CommandCheckInterval     string `mapstructure:"command_check_interval" yaml:"command_check_interval"`         // default: 10ms
 	CertificateCheckInterval string `mapstructure:"certificate_check_interval" yaml:"certificate_check_interval"` // default: 1h
 
+	HealthCheckInterval         string `mapstructure:"health_check_interval" yaml:"health_check_interval"`                   // default: 30s
+	HealthCheckTimeout          string `mapstructure:"health_check_timeout" yaml:"health_check_timeout"`                     // default: 5s
+	HealthCheckFailureThreshold int    `mapstructure:"health_check_failure_threshold" yaml:"health_check_failure_threshold"` // default: 3
+	StartTimeout                string `mapstructure:"start_timeout" yaml:"start_timeout"`                                   // default: 30s
+
+	MaxRestarts        int    `mapstructure:"max_restarts" yaml:"max_restarts"`                 // default: 5
+	RestartWindow      string `mapstructure:"restart_window" yaml:"restart_window"`             // default: 10m
+	RestartBackoffBase string `mapstructure:"restart_backoff_base" yaml:"restart_backoff_base"` // default: 5s
+	RestartBackoffMax  string `mapstructure:"restart_backoff_max" yaml:"restart_backoff_max"`   // default: 5m
+
+	DetachOnShutdown bool `mapstructure:"detach_on_shutdown" yaml:"detach_on_shutdown"`
+
+	RecoveryConcurrency  int    `mapstructure:"recovery_concurrency" yaml:"recovery_concurrency"`     // default: 4
+	RecoveryReadyTimeout string `mapstructure:"recovery_ready_timeout" yaml:"recovery_ready_timeout"` // default: 30s
+
+	CgroupParent string `mapstructure:"cgroup_parent" yaml:"cgroup_parent"` // delegated cgroup v2 directory, e.g. /sys/fs/cgroup/pb_launcher
+
+	Isolation isolation_configs `mapstructure:"isolation" yaml:"isolation"`
+
+	ZeroDowntimeRestart bool   `mapstructure:"zero_downtime_restart" yaml:"zero_downtime_restart"`
+	SwapHealthTimeout   string `mapstructure:"swap_health_timeout" yaml:"swap_health_timeout"` // default: 30s
+	DrainTimeout        string `mapstructure:"drain_timeout" yaml:"drain_timeout"`             // default: 10s
+
+	TrashRetention     string `mapstructure:"trash_retention" yaml:"trash_retention"`           // default: 168h
+	TrashPurgeInterval string `mapstructure:"trash_purge_interval" yaml:"trash_purge_interval"` // default: 10m
+	TrashArchive       bool   `mapstructure:"trash_archive" yaml:"trash_archive"`
+
+	ScheduleCheckInterval string `mapstructure:"schedule_check_interval" yaml:"schedule_check_interval"`   // default: 30s
+	ScheduleCatchUpWindow string `mapstructure:"schedule_catch_up_window" yaml:"schedule_catch_up_window"` // default: 24h
+
+	IdleCheckInterval string `mapstructure:"idle_check_interval" yaml:"idle_check_interval"` // default: 30s
+	WakeTimeout       string `mapstructure:"wake_timeout" yaml:"wake_timeout"`               // default: 30s
+
+	MetricsInterval  string `mapstructure:"metrics_interval" yaml:"metrics_interval"`   // default: 30s
+	MetricsRetention string `mapstructure:"metrics_retention" yaml:"metrics_retention"` // default: 24h
+
+	FilesMaxSizeMB     int `mapstructure:"files_max_size_mb" yaml:"files_max_size_mb"`           // default: 10
+	FilesMaxAreaSizeMB int `mapstructure:"files_max_area_size_mb" yaml:"files_max_area_size_mb"` // default: 100
+
 	DownloadDir string `mapstructure:"download_dir" yaml:"download_dir"` // default: ./downloads
 
 	CertificatesDir string `mapstructure:"certificates_dir" yaml:"certificates_dir"` // default: ./.certificates
@@ -88,6 +219,8 @@ type configs struct {
 	DataDir string `mapstructure:"data_dir" yaml:"data_dir"` // default: ./data
 	Domain  string `mapstructure:"domain" yaml:"domain"`
 
+	MasterKeyFile string `mapstructure:"master_key_file" yaml:"master_key_file"` // default: <pb_data>/master.key
+
 	ListenAddress               string `mapstructure:"listen_address" yaml:"listen_address"` // default: 0.0.0.0
 	HttpPort                    string `mapstructure:"http_port" yaml:"http_port"`           // default: 8072
 	Https                       bool   `mapstructure:"https" yaml:"https"`
@@ -101,6 +234,10 @@ type configs struct {
 	AcmeEmail string `mapstructure:"acme_email" yaml:"acme_email"`
 
 	Tls tls_configs `mapstructure:"cert" yaml:"cert"`
+
+	Backups backups_configs `mapstructure:"backups" yaml:"backups"`
+
+	Prometheus prometheus_configs `mapstructure:"prometheus" yaml:"prometheus"`
 }
 
 var _ Config = (*configs)(nil)
@@ -112,12 +249,51 @@ func (c *configs) GetBindIPAddress() string {
 	return c.BindAddress
 }
 
+// GetPortRange returns the inclusive range instance ports are allocated from,
+// or (0, 0) when any free port may be used.
+func (c *configs) GetPortRange() (int, int) {
+	start, end, err := parsePortRange(c.PortRange)
+	if err != nil {
+		return 0, 0
+	}
+	return start, end
+}
+
 const min_sync_interval = 5 * time.Minute
 const min_command_check_interval = 10 * time.Second
 const min_certificate_check_interval = time.Minute
 const min_certificate_ttl = 30 * 24 * time.Hour
 const min_cert_request_planner_interval = 5 * time.Minute
 const min_cert_request_executor_interval = time.Minute
+const min_health_check_interval = 30 * time.Second
+const min_health_check_timeout = time.Second
+const default_health_check_timeout = 5 * time.Second
+const min_start_timeout = 5 * time.Second
+const default_start_timeout = 30 * time.Second
+const min_restart_window = 30 * time.Second
+const default_restart_window = 10 * time.Minute
+const min_restart_backoff_base = 5 * time.Second
+const min_restart_backoff_max = 10 * time.Second
+const default_restart_backoff_max = 5 * time.Minute
+const default_recovery_concurrency = 4
+const min_recovery_ready_timeout = 5 * time.Second
+const default_recovery_ready_timeout = 30 * time.Second
+const min_swap_health_timeout = 30 * time.Second
+const min_drain_timeout = 10 * time.Second
+const min_backup_check_interval = time.Minute
+const min_trash_retention = time.Hour
+const default_trash_retention = 7 * 24 * time.Hour
+const min_trash_purge_interval = 10 * time.Minute
+const min_schedule_check_interval = 30 * time.Second
+const min_schedule_catch_up_window = time.Minute
+const default_schedule_catch_up_window = 24 * time.Hour
+const min_idle_check_interval = 30 * time.Second
+const min_wake_timeout = 5 * time.Second
+const default_wake_timeout = 30 * time.Second
+const min_metrics_interval = 10 * time.Second
+const default_metrics_interval = 30 * time.Second
+const min_metrics_retention = time.Hour
+const default_metrics_retention = 24 * time.Hour
 
 func (c *configs) GetReleaseSyncInterval() time.Duration {
 	return parseDurationWithMin(
@@ -143,6 +319,354 @@ func (c *configs) GetCertificateCheckInterval() time.Duration {
 	)
 }
 
+func (c *configs) GetHealthCheckInterval() time.Duration {
+	return parseDurationWithMin(
+		c.HealthCheckInterval,
+		min_health_check_interval,
+		"health_check_interval",
+	)
+}
+
+func (c *configs) GetHealthCheckTimeout() time.Duration {
+	if c.HealthCheckTimeout == "" {
+		return default_health_check_timeout
+	}
+	return parseDurationWithMin(
+		c.HealthCheckTimeout,
+		min_health_check_timeout,
+		"health_check_timeout",
+	)
+}
+
+func (c *configs) GetHealthCheckFailureThreshold() int {
+	if c.HealthCheckFailureThreshold <= 0 {
+		return 3
+	}
+	return c.HealthCheckFailureThreshold
+}
+
+// GetStartTimeout is how long a started service has to answer /api/health
+// before it is stopped and marked as failure.
+func (c *configs) GetStartTimeout() time.Duration {
+	if c.StartTimeout == "" {
+		return default_start_timeout
+	}
+	return parseDurationWithMin(
+		c.StartTimeout,
+		min_start_timeout,
+		"start_timeout",
+	)
+}
+
+func (c *configs) GetMaxRestarts() int {
+	if c.MaxRestarts <= 0 {
+		return 5
+	}
+	return c.MaxRestarts
+}
+
+func (c *configs) GetRestartWindow() time.Duration {
+	if c.RestartWindow == "" {
+		return default_restart_window
+	}
+	return parseDurationWithMin(
+		c.RestartWindow,
+		min_restart_window,
+		"restart_window",
+	)
+}
+
+func (c *configs) GetRestartBackoffBase() time.Duration {
+	return parseDurationWithMin(
+		c.RestartBackoffBase,
+		min_restart_backoff_base,
+		"restart_backoff_base",
+	)
+}
+
+func (c *configs) GetRestartBackoffMax() time.Duration {
+	if c.RestartBackoffMax == "" {
+		return default_restart_backoff_max
+	}
+	return parseDurationWithMin(
+		c.RestartBackoffMax,
+		min_restart_backoff_max,
+		"restart_backoff_max",
+	)
+}
+
+// IsDetachOnShutdown reports whether instances keep running when the
+// launcher exits, so they can be re-adopted on the next boot.
+func (c *configs) IsDetachOnShutdown() bool { return c.DetachOnShutdown }
+
+// GetRecoveryConcurrency is how many services are started at the same time
+// when the launcher restores the previous state at boot.
+func (c *configs) GetRecoveryConcurrency() int {
+	if c.RecoveryConcurrency <= 0 {
+		return default_recovery_concurrency
+	}
+	return c.RecoveryConcurrency
+}
+
+// GetRecoveryReadyTimeout replaces start_timeout for the services started
+// by the boot recovery.
+func (c *configs) GetRecoveryReadyTimeout() time.Duration {
+	if c.RecoveryReadyTimeout == "" {
+		return default_recovery_ready_timeout
+	}
+	return parseDurationWithMin(
+		c.RecoveryReadyTimeout,
+		min_recovery_ready_timeout,
+		"recovery_ready_timeout",
+	)
+}
+
+const (
+	IsolationUser      = "user"      // a dedicated UID/GID per service
+	IsolationNamespace = "namespace" // the UID plus a mount namespace with only the service files
+)
+
+const min_isolation_uid = 1000
+const max_isolation_uid = 1<<31 - 1
+const default_isolation_uid_range = "200000-299999"
+
+// GetIsolationMode is how service processes are separated from the launcher
+// and from each other, empty when they run as the launcher user.
+func (c *configs) GetIsolationMode() string {
+	return strings.ToLower(strings.TrimSpace(c.Isolation.Mode))
+}
+
+// GetIsolationUIDRange is the inclusive pool service UIDs are taken from;
+// each service uses its UID as GID too.
+func (c *configs) GetIsolationUIDRange() (int, int) {
+	raw := strings.TrimSpace(c.Isolation.UIDRange)
+	if raw == "" {
+		raw = default_isolation_uid_range
+	}
+	start, end, err := parseRange(raw, min_isolation_uid, max_isolation_uid)
+	if err != nil {
+		slog.Warn("invalid isolation.uid_range, using the default", "error", err)
+		start, end, _ = parseRange(default_isolation_uid_range, min_isolation_uid, max_isolation_uid)
+	}
+	return start, end
+}
+
+// GetCgroupParent is the cgroup v2 directory holding one leaf per service
+// process for its resource limits. Without one only the open files limit
+// and, for isolated services, the pids limit are enforced.
+func (c *configs) GetCgroupParent() string { return strings.TrimSpace(c.CgroupParent) }
+
+// IsZeroDowntimeRestart reports whether restarts start the new process next
+// to the running one and switch traffic once it is healthy.
+func (c *configs) IsZeroDowntimeRestart() bool { return c.ZeroDowntimeRestart }
+
+func (c *configs) GetSwapHealthTimeout() time.Duration {
+	return parseDurationWithMin(
+		c.SwapHealthTimeout,
+		min_swap_health_timeout,
+		"swap_health_timeout",
+	)
+}
+
+func (c *configs) GetDrainTimeout() time.Duration {
+	return parseDurationWithMin(
+		c.DrainTimeout,
+		min_drain_timeout,
+		"drain_timeout",
+	)
+}
+
+func (c *configs) GetBackupDir() string {
+	if c.Backups.Dir == "" {
+		return "./backups"
+	}
+	return c.Backups.Dir
+}
+
+// GetBackupRetention is the number of archives kept per service when the
+// service does not set its own retention.
+func (c *configs) GetBackupRetention() int {
+	if c.Backups.Retention <= 0 {
+		return 7
+	}
+	return c.Backups.Retention
+}
+
+func (c *configs) GetBackupCheckInterval() time.Duration {
+	return parseDurationWithMin(
+		c.Backups.CheckInterval,
+		min_backup_check_interval,
+		"backups.check_interval",
+	)
+}
+
+const (
+	BackupTargetLocal = "local"
+	BackupTargetS3    = "s3"
+)
+
+func (c *configs) GetBackupTarget() string {
+	if c.Backups.Target == "" {
+		return BackupTargetLocal
+	}
+	return c.Backups.Target
+}
+
+const min_backup_part_size_mb = 5
+const default_backup_part_size_mb = 8
+
+func (c *configs) GetBackupS3Config() BackupS3Config {
+	s3 := c.Backups.S3
+	cfg := BackupS3Config{
+		Endpoint:        s3.Endpoint,
+		Region:          s3.Region,
+		Bucket:          s3.Bucket,
+		Prefix:          strings.Trim(s3.Prefix, "/"),
+		AccessKey:       s3.AccessKey,
+		SecretKey:       s3.SecretKey,
+		ForcePathStyle:  s3.ForcePathStyle,
+		PartSize:        default_backup_part_size_mb << 20,
+		LauncherBackups: s3.LauncherBackups,
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.AccessKey == "" {
+		cfg.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if cfg.SecretKey == "" {
+		cfg.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if s3.PartSizeMB != 0 {
+		if s3.PartSizeMB < min_backup_part_size_mb {
+			slog.Warn("backups.s3.part_size_mb below minimum, forcing to 5")
+		}
+		cfg.PartSize = max(s3.PartSizeMB, min_backup_part_size_mb) << 20
+	}
+	return cfg
+}
+
+// GetTrashRetention is how long a deleted service can be restored before
+// its data and record are purged.
+func (c *configs) GetTrashRetention() time.Duration {
+	if c.TrashRetention == "" {
+		return default_trash_retention
+	}
+	return parseDurationWithMin(
+		c.TrashRetention,
+		min_trash_retention,
+		"trash_retention",
+	)
+}
+
+func (c *configs) GetTrashPurgeInterval() time.Duration {
+	return parseDurationWithMin(
+		c.TrashPurgeInterval,
+		min_trash_purge_interval,
+		"trash_purge_interval",
+	)
+}
+
+func (c *configs) IsTrashArchiveEnabled() bool { return c.TrashArchive }
+
+func (c *configs) GetScheduleCheckInterval() time.Duration {
+	return parseDurationWithMin(
+		c.ScheduleCheckInterval,
+		min_schedule_check_interval,
+		"schedule_check_interval",
+	)
+}
+
+// GetScheduleCatchUpWindow bounds how far back runs missed while the
+// launcher was down are looked up.
+func (c *configs) GetScheduleCatchUpWindow() time.Duration {
+	if c.ScheduleCatchUpWindow == "" {
+		return default_schedule_catch_up_window
+	}
+	return parseDurationWithMin(
+		c.ScheduleCatchUpWindow,
+		min_schedule_catch_up_window,
+		"schedule_catch_up_window",
+	)
+}
+
+func (c *configs) GetIdleCheckInterval() time.Duration {
+	return parseDurationWithMin(
+		c.IdleCheckInterval,
+		min_idle_check_interval,
+		"idle_check_interval",
+	)
+}
+
+// GetWakeTimeout is how long the proxy holds a request while a sleeping
+// service starts.
+func (c *configs) GetWakeTimeout() time.Duration {
+	if c.WakeTimeout == "" {
+		return default_wake_timeout
+	}
+	return parseDurationWithMin(
+		c.WakeTimeout,
+		min_wake_timeout,
+		"wake_timeout",
+	)
+}
+
+func (c *configs) GetMetricsInterval() time.Duration {
+	if c.MetricsInterval == "" {
+		return default_metrics_interval
+	}
+	return parseDurationWithMin(
+		c.MetricsInterval,
+		min_metrics_interval,
+		"metrics_interval",
+	)
+}
+
+// GetMetricsRetention is how long the collected service metrics are kept.
+func (c *configs) GetMetricsRetention() time.Duration {
+	if c.MetricsRetention == "" {
+		return default_metrics_retention
+	}
+	return parseDurationWithMin(
+		c.MetricsRetention,
+		min_metrics_retention,
+		"metrics_retention",
+	)
+}
+
+// GetPrometheusListenAddress is where the Prometheus exporter listens,
+// empty when it is disabled.
+func (c *configs) GetPrometheusListenAddress() string {
+	return strings.TrimSpace(c.Prometheus.ListenAddress)
+}
+
+// GetPrometheusBearerToken is the token scrapers must send, empty to allow
+// any client that reaches the listen address.
+func (c *configs) GetPrometheusBearerToken() string {
+	if c.Prometheus.BearerToken == "" {
+		return os.Getenv("PB_LAUNCHER_METRICS_TOKEN")
+	}
+	return c.Prometheus.BearerToken
+}
+
+// GetFilesMaxSize limits a single upload through the service files API,
+// zip archives included, in bytes.
+func (c *configs) GetFilesMaxSize() int64 {
+	if c.FilesMaxSizeMB <= 0 {
+		return 10 << 20
+	}
+	return int64(c.FilesMaxSizeMB) << 20
+}
+
+// GetFilesMaxAreaSize limits the total size of each hooks, public and
+// migrations directory of a service, in bytes.
+func (c *configs) GetFilesMaxAreaSize() int64 {
+	if c.FilesMaxAreaSizeMB <= 0 {
+		return 100 << 20
+	}
+	return int64(c.FilesMaxAreaSizeMB) << 20
+}
+
 func (c *configs) GetDownloadDir() string {
 	if c.DownloadDir == "" {
 		return "./downloads"
@@ -157,6 +681,12 @@ func (c *configs) GetDataDir() string {
 	return c.DataDir
 }
 
+// GetMasterKeyFile returns the configured master key path, or an empty
+// string to keep the key next to the launcher database.
+func (c *configs) GetMasterKeyFile() string {
+	return strings.TrimSpace(c.MasterKeyFile)
+}
+
 func (c *configs) GetCertificatesDir() string {
 	if c.CertificatesDir == "" {
 		return "./.certificates"
@@ -281,12 +811,18 @@ func LoadConfigs(configPath string) (Config, error) {
 	c.DataDir = strings.TrimSpace(c.DataDir)
 	c.Domain = strings.TrimSpace(c.Domain)
 	c.BindAddress = strings.TrimSpace(c.BindAddress)
+	c.PortRange = strings.TrimSpace(c.PortRange)
 
 	c.ListenAddress = strings.TrimSpace(c.ListenAddress)
 	c.HttpPort = strings.TrimSpace(c.HttpPort)
 	c.HttpsPort = strings.TrimSpace(c.HttpsPort)
 	c.AcmeEmail = strings.TrimSpace(c.AcmeEmail)
 
+	c.Backups.Target = strings.ToLower(strings.TrimSpace(c.Backups.Target))
+	c.Backups.Dir = strings.TrimSpace(c.Backups.Dir)
+	c.Backups.S3.Endpoint = strings.TrimSpace(c.Backups.S3.Endpoint)
+	c.Backups.S3.Bucket = strings.TrimSpace(c.Backups.S3.Bucket)
+
 	if err := is.IPv4.Validate(c.GetBindIPAddress()); err != nil {
 		slog.Error(
 			"Invalid bind_address: not a valid IPv4 address",
@@ -295,6 +831,10 @@ func LoadConfigs(configPath string) (Config, error) {
 		return nil, fmt.Errorf("invalid bind_address: %w", err)
 	}
 
+	if _, _, err := parsePortRange(c.PortRange); err != nil {
+		return nil, fmt.Errorf("invalid port_range: %w", err)
+	}
+
 	if err := is.IPv4.Validate(c.GetListenIPAddress()); err != nil {
 		slog.Error(
 			"Invalid listen_address: not a valid IPv4 address",
@@ -308,6 +848,25 @@ func LoadConfigs(configPath string) (Config, error) {
 		return nil, errors.New("invalid http_port: must be an integer between 1 and 65535")
 	}
 
+	if err := validateBackupTarget(c); err != nil {
+		return nil, err
+	}
+
+	switch c.GetIsolationMode() {
+	case "", IsolationUser, IsolationNamespace:
+	default:
+		return nil, fmt.Errorf("invalid isolation.mode %q: must be user or namespace", c.Isolation.Mode)
+	}
+	if _, _, err := parseRange(strings.TrimSpace(c.Isolation.UIDRange), min_isolation_uid, max_isolation_uid); err != nil {
+		return nil, fmt.Errorf("invalid isolation.uid_range: %w", err)
+	}
+
+	if addr := c.GetPrometheusListenAddress(); addr != "" {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("invalid prometheus.listen_address: %w", err)
+		}
+	}
+
 	if c.IsHttpsEnabled() {
 		if err := is.EmailFormat.Validate(c.AcmeEmail); err != nil {
 			return nil, fmt.Errorf("invalid ACME email address: %w", err)
@@ -320,6 +879,51 @@ func LoadConfigs(configPath string) (Config, error) {
 	return c, nil
 }
 
+func validateBackupTarget(c *configs) error {
+	switch c.GetBackupTarget() {
+	case BackupTargetLocal:
+		return nil
+	case BackupTargetS3:
+		s3 := c.GetBackupS3Config()
+		if s3.Endpoint == "" || s3.Bucket == "" {
+			return errors.New("invalid backups.s3: endpoint and bucket are required")
+		}
+		if s3.AccessKey == "" || s3.SecretKey == "" {
+			return errors.New("invalid backups.s3: access_key and secret_key are required")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid backups.target %q: must be local or s3", c.Backups.Target)
+	}
+}
+
+func parsePortRange(raw string) (int, int, error) {
+	return parseRange(raw, 1024, 65535)
+}
+
+// parseRange reads an inclusive "start-end" range within [lower, upper].
+func parseRange(raw string, lower, upper int) (int, int, error) {
+	if raw == "" {
+		return 0, 0, nil
+	}
+	startRaw, endRaw, found := strings.Cut(raw, "-")
+	if !found {
+		return 0, 0, errors.New("expected format start-end")
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(startRaw))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range start: %w", err)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(endRaw))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range end: %w", err)
+	}
+	if start < lower || end > upper || start > end {
+		return 0, 0, fmt.Errorf("range must be within %d-%d and start <= end", lower, upper)
+	}
+	return start, end, nil
+}
+
 func parseDurationWithMin(raw string, min time.Duration, name string) time.Duration {
 	if raw == "" {
 		return min
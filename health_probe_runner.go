@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/serialexecutor"
+	launcher "pb_launcher/internal/launcher/domain"
+)
+
+func RegisterHealthProbe(
+	executor *serialexecutor.SequentialExecutor,
+	launcherManager *launcher.LauncherManager,
+	config configs.Config) error {
+
+	healthProbeTask := serialexecutor.NewTask(
+		func(ctx context.Context) {
+			if err := launcherManager.ProbeHealth(ctx); err != nil {
+				slog.Error("health probe task failed", "error", err, "task", "healthProbe")
+			}
+		},
+		config.GetHealthCheckInterval(),
+		9998,
+	)
+
+	return executor.Add(healthProbeTask.WithName("healthProbe"))
+}
@@ -136,6 +136,14 @@ func (s *ServiceLogDB) GetLogsByService(serviceID string, limit int) ([]ServiceL
 	return logs, err
 }
 
+// DeleteLogsByService removes every log row of a service.
+func (s *ServiceLogDB) DeleteLogsByService(serviceID string) error {
+	_, err := s.db.NewQuery("DELETE FROM service_logs WHERE service_id = {:service_id}").
+		Bind(dbx.Params{"service_id": serviceID}).
+		Execute()
+	return err
+}
+
 func (s *ServiceLogDB) Cleanup() error {
 	query := `
 		DELETE FROM service_logs
@@ -0,0 +1,168 @@
+package metricstore
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"go.uber.org/fx"
+)
+
+// ServiceMetrics is one sample of the resources used by a service.
+type ServiceMetrics struct {
+	ServiceID  string    `json:"service_id"`
+	Timestamp  time.Time `json:"timestamp"`
+	PID        int       `json:"pid"`
+	CPUPercent float64   `json:"cpu_percent"` // of one core, since the previous sample
+	CPUSeconds float64   `json:"cpu_seconds"` // total CPU time of the process
+	RSSBytes   int64     `json:"rss_bytes"`
+	OpenFDs    int       `json:"open_fds"`
+	DiskBytes  int64     `json:"disk_bytes"` // size of the service directory
+}
+
+type metricsRow struct {
+	ServiceID  string  `db:"service_id"`
+	Timestamp  int64   `db:"timestamp"`
+	PID        int     `db:"pid"`
+	CPUPercent float64 `db:"cpu_percent"`
+	CPUSeconds float64 `db:"cpu_seconds"`
+	RSSBytes   int64   `db:"rss_bytes"`
+	OpenFDs    int     `db:"open_fds"`
+	DiskBytes  int64   `db:"disk_bytes"`
+}
+
+func (r metricsRow) toMetrics() ServiceMetrics {
+	return ServiceMetrics{
+		ServiceID:  r.ServiceID,
+		Timestamp:  time.UnixMilli(r.Timestamp).UTC(),
+		PID:        r.PID,
+		CPUPercent: r.CPUPercent,
+		CPUSeconds: r.CPUSeconds,
+		RSSBytes:   r.RSSBytes,
+		OpenFDs:    r.OpenFDs,
+		DiskBytes:  r.DiskBytes,
+	}
+}
+
+// ServiceMetricsDB keeps a rolling time series of service metrics in its
+// own SQLite database, next to the service logs.
+type ServiceMetricsDB struct {
+	db *dbx.DB
+}
+
+func NewServiceMetricsDB(lc fx.Lifecycle, app *pocketbase.PocketBase) (*ServiceMetricsDB, error) {
+	dbPath := filepath.Join(app.DataDir(), "service_metrics.db")
+	dsn := fmt.Sprintf(
+		"%s?_pragma=busy_timeout(10000)&_pragma=synchronous(NORMAL)&_pragma=journal_mode(WAL)&_pragma=temp_store(MEMORY)",
+		dbPath,
+	)
+	db, err := dbx.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open service metrics database: %w", err)
+	}
+
+	// timestamps are unix milliseconds to keep range queries numeric
+	const schema = `
+	CREATE TABLE IF NOT EXISTS service_metrics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		service_id TEXT NOT NULL,
+		timestamp INTEGER NOT NULL,
+		pid INTEGER NOT NULL,
+		cpu_percent REAL NOT NULL,
+		cpu_seconds REAL NOT NULL,
+		rss_bytes INTEGER NOT NULL,
+		open_fds INTEGER NOT NULL,
+		disk_bytes INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_service_metrics_service_time ON service_metrics(service_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_service_metrics_time ON service_metrics(timestamp);
+	`
+
+	if _, err := db.NewQuery(schema).Execute(); err != nil {
+		return nil, fmt.Errorf("failed to initialize service_metrics schema: %w", err)
+	}
+	lc.Append(fx.StopHook(func() {
+		if err := db.Close(); err != nil {
+			slog.Error("failed to close service metrics database", slog.Any("error", err))
+		} else {
+			slog.Info("service metrics database closed successfully")
+		}
+	}))
+	return &ServiceMetricsDB{db: db}, nil
+}
+
+// InsertMetrics stores the samples of one collection run.
+func (s *ServiceMetricsDB) InsertMetrics(samples []ServiceMetrics) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	return s.db.Transactional(func(tx *dbx.Tx) error {
+		for _, m := range samples {
+			if _, err := tx.Insert("service_metrics", dbx.Params{
+				"service_id":  m.ServiceID,
+				"timestamp":   m.Timestamp.UnixMilli(),
+				"pid":         m.PID,
+				"cpu_percent": m.CPUPercent,
+				"cpu_seconds": m.CPUSeconds,
+				"rss_bytes":   m.RSSBytes,
+				"open_fds":    m.OpenFDs,
+				"disk_bytes":  m.DiskBytes,
+			}).Execute(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LatestMetrics returns the last sample of the service, or nil when there
+// is none.
+func (s *ServiceMetricsDB) LatestMetrics(serviceID string) (*ServiceMetrics, error) {
+	var rows []metricsRow
+	err := s.db.NewQuery(`
+		SELECT service_id, timestamp, pid, cpu_percent, cpu_seconds, rss_bytes, open_fds, disk_bytes
+		FROM service_metrics
+		WHERE service_id = {:service_id}
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`).Bind(dbx.Params{"service_id": serviceID}).All(&rows)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	latest := rows[0].toMetrics()
+	return &latest, nil
+}
+
+// MetricsHistory returns the samples of the service taken after since, the
+// oldest first.
+func (s *ServiceMetricsDB) MetricsHistory(serviceID string, since time.Time) ([]ServiceMetrics, error) {
+	var rows []metricsRow
+	err := s.db.NewQuery(`
+		SELECT service_id, timestamp, pid, cpu_percent, cpu_seconds, rss_bytes, open_fds, disk_bytes
+		FROM service_metrics
+		WHERE service_id = {:service_id} AND timestamp >= {:since}
+		ORDER BY timestamp ASC
+	`).Bind(dbx.Params{
+		"service_id": serviceID,
+		"since":      since.UnixMilli(),
+	}).All(&rows)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]ServiceMetrics, 0, len(rows))
+	for _, row := range rows {
+		history = append(history, row.toMetrics())
+	}
+	return history, nil
+}
+
+// PruneMetrics removes the samples taken before the given time.
+func (s *ServiceMetricsDB) PruneMetrics(before time.Time) error {
+	_, err := s.db.NewQuery("DELETE FROM service_metrics WHERE timestamp < {:before}").
+		Bind(dbx.Params{"before": before.UnixMilli()}).
+		Execute()
+	return err
+}
@@ -0,0 +1,74 @@
+package pbdata
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// authSystemTables reference auth records through collectionRef/recordRef.
+var authSystemTables = []string{"_externalAuths", "_mfas", "_otps", "_authOrigins"}
+
+// DropAuthRecords deletes the records of every auth collection except the
+// superusers, together with their external auths, MFAs, OTPs and origins.
+// The instance must not be running.
+func DropAuthRecords(ctx context.Context, pbData string) error {
+	return clearAuthCollections(ctx, pbData, "type = 'auth' AND name != {:superusers}")
+}
+
+// DeleteSuperusers deletes every superuser of the instance, the next
+// `superuser upsert` defines the only remaining one. The instance must not
+// be running.
+func DeleteSuperusers(ctx context.Context, pbData string) error {
+	return clearAuthCollections(ctx, pbData, "name = {:superusers}")
+}
+
+func clearAuthCollections(ctx context.Context, pbData, where string) error {
+	db, err := core.DefaultDBConnect(filepath.Join(pbData, "data.db"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.TransactionalContext(ctx, nil, func(tx *dbx.Tx) error {
+		var collections []struct {
+			ID   string `db:"id"`
+			Name string `db:"name"`
+		}
+		err := tx.NewQuery("SELECT id, name FROM _collections WHERE " + where).
+			Bind(dbx.Params{"superusers": core.CollectionNameSuperusers}).
+			All(&collections)
+		if err != nil {
+			return err
+		}
+		for _, collection := range collections {
+			if _, err := tx.Delete(collection.Name, nil).Execute(); err != nil {
+				return err
+			}
+			for _, table := range authSystemTables {
+				exists, err := tableExists(tx, table)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					continue
+				}
+				_, err = tx.Delete(table, dbx.HashExp{"collectionRef": collection.ID}).Execute()
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func tableExists(tx *dbx.Tx, name string) (bool, error) {
+	var count int
+	err := tx.NewQuery("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = {:name}").
+		Bind(dbx.Params{"name": name}).
+		Row(&count)
+	return count > 0, err
+}
@@ -0,0 +1,95 @@
+package pbdata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/stretchr/testify/require"
+)
+
+func mustExec(t *testing.T, db *dbx.DB, queries ...string) {
+	t.Helper()
+	for _, q := range queries {
+		_, err := db.NewQuery(q).Execute()
+		require.NoError(t, err)
+	}
+}
+
+func count(t *testing.T, db *dbx.DB, table string) int {
+	t.Helper()
+	var n int
+	require.NoError(t, db.NewQuery("SELECT count(*) FROM "+table).Row(&n))
+	return n
+}
+
+// newDataDir creates a pb_data with the tables of two auth collections.
+func newDataDir(t *testing.T) string {
+	pbData := t.TempDir()
+	db, err := core.DefaultDBConnect(filepath.Join(pbData, "data.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	mustExec(t, db,
+		"CREATE TABLE _collections (id TEXT, name TEXT, type TEXT)",
+		"INSERT INTO _collections VALUES ('c1', '_superusers', 'auth'), ('c2', 'users', 'auth'), ('c3', 'posts', 'base')",
+		"CREATE TABLE _superusers (id TEXT)",
+		"INSERT INTO _superusers VALUES ('s1')",
+		"CREATE TABLE users (id TEXT)",
+		"INSERT INTO users VALUES ('u1'), ('u2')",
+		"CREATE TABLE posts (id TEXT)",
+		"INSERT INTO posts VALUES ('p1')",
+		"CREATE TABLE _externalAuths (id TEXT, collectionRef TEXT)",
+		"INSERT INTO _externalAuths VALUES ('e1', 'c1'), ('e2', 'c2')",
+	)
+	return pbData
+}
+
+func TestSnapshot(t *testing.T) {
+	pbData := newDataDir(t)
+	uploads := filepath.Join(pbData, StorageDir, "posts", "p1")
+	require.NoError(t, os.MkdirAll(uploads, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(uploads, "a.txt"), []byte("data"), 0o644))
+
+	dst := filepath.Join(t.TempDir(), "pb_data")
+	require.NoError(t, Snapshot(context.Background(), pbData, dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, StorageDir, "posts", "p1", "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "data", string(content))
+
+	db, err := core.DefaultDBConnect(filepath.Join(dst, "data.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, 2, count(t, db, "users"))
+
+	require.Error(t, Snapshot(context.Background(), pbData, dst), "existing destination")
+}
+
+func TestDropAuthRecords(t *testing.T) {
+	pbData := newDataDir(t)
+	require.NoError(t, DropAuthRecords(context.Background(), pbData))
+
+	db, err := core.DefaultDBConnect(filepath.Join(pbData, "data.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, 0, count(t, db, "users"))
+	require.Equal(t, 1, count(t, db, "_superusers"))
+	require.Equal(t, 1, count(t, db, "posts"))
+	require.Equal(t, 1, count(t, db, "_externalAuths"))
+}
+
+func TestDeleteSuperusers(t *testing.T) {
+	pbData := newDataDir(t)
+	require.NoError(t, DeleteSuperusers(context.Background(), pbData))
+
+	db, err := core.DefaultDBConnect(filepath.Join(pbData, "data.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, 0, count(t, db, "_superusers"))
+	require.Equal(t, 2, count(t, db, "users"))
+	require.Equal(t, 1, count(t, db, "_externalAuths"))
+}
@@ -0,0 +1,75 @@
+// Package pbdata works on the pb_data directory of an instance while it may
+// still be running.
+package pbdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pb_launcher/utils/iouitls"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Databases are the SQLite files of a pb_data directory. They are copied
+// with VACUUM INTO, which reads a consistent state even while the instance
+// writes to them.
+var Databases = []string{"data.db", "auxiliary.db"}
+
+// StorageDir holds the uploaded files of the local filesystem storage.
+const StorageDir = "storage"
+
+// VacuumInto writes a consistent copy of the database src to dst.
+func VacuumInto(ctx context.Context, src, dst string) error {
+	db, err := core.DefaultDBConnect(src)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.NewQuery("VACUUM INTO {:dst}").
+		WithContext(ctx).
+		Bind(dbx.Params{"dst": dst}).
+		Execute()
+	return err
+}
+
+// Vacuum rebuilds the database file to give the space of deleted rows
+// back. Unlike VacuumInto it needs the database for itself, so the
+// instance must be stopped.
+func Vacuum(ctx context.Context, path string) error {
+	db, err := core.DefaultDBConnect(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.NewQuery("VACUUM").WithContext(ctx).Execute()
+	return err
+}
+
+// Snapshot copies the databases and the storage directory of pbData into
+// dst, which must not exist yet.
+func Snapshot(ctx context.Context, pbData, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("snapshot destination already exists: %s", dst)
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, name := range Databases {
+		src := filepath.Join(pbData, name)
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := VacuumInto(ctx, src, filepath.Join(dst, name)); err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", name, err)
+		}
+	}
+	storage := filepath.Join(pbData, StorageDir)
+	if _, err := os.Stat(storage); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return iouitls.CopyDir(storage, filepath.Join(dst, StorageDir))
+}
@@ -0,0 +1,97 @@
+package process
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const followInterval = 250 * time.Millisecond
+
+// fileFollower copies whatever is appended to a file into dst, like
+// `tail -f`. It lets a detached child write straight to a log file, which
+// survives a launcher restart, while its output still reaches the log store.
+type fileFollower struct {
+	path   string
+	dst    io.Writer
+	offset int64
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func followFile(path string, dst io.Writer, fromEnd bool) *fileFollower {
+	f := &fileFollower{
+		path: path,
+		dst:  dst,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if fromEnd {
+		if info, err := os.Stat(path); err == nil {
+			f.offset = info.Size()
+		}
+	}
+	go f.run()
+	return f
+}
+
+func (f *fileFollower) run() {
+	defer close(f.done)
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			f.drain()
+			return
+		case <-ticker.C:
+			f.drain()
+		}
+	}
+}
+
+func (f *fileFollower) drain() {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to open followed file", "path", f.path, "error", err)
+		}
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+	if info.Size() < f.offset {
+		f.offset = 0 // truncated
+	}
+	if info.Size() == f.offset {
+		return
+	}
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return
+	}
+	buf := make([]byte, info.Size()-f.offset)
+	n, err := io.ReadFull(file, buf)
+	if n > 0 {
+		f.offset += int64(n)
+		if _, werr := f.dst.Write(buf[:n]); werr != nil {
+			slog.Warn("failed to forward followed output", "path", f.path, "error", werr)
+		}
+	}
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		slog.Warn("failed to read followed file", "path", f.path, "error", err)
+	}
+}
+
+// Close flushes the remaining output and stops following.
+func (f *fileFollower) Close() {
+	f.once.Do(func() { close(f.stop) })
+	<-f.done
+}
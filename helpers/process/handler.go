@@ -1,6 +1,7 @@
 package process
 
 import (
+	"os"
 	"os/exec"
 	"sync"
 )
@@ -18,6 +19,7 @@ type handler struct {
 	sync.RWMutex
 	status ProcessState
 	cmd    *exec.Cmd
+	proc   *os.Process
 }
 
 func (_c *handler) updateStatus(status ProcessState) {
@@ -37,6 +39,22 @@ func (_c *handler) replaceCommand(c *exec.Cmd) {
 	defer _c.Unlock()
 	_c.cmd = nil
 	_c.cmd = c
+	_c.proc = c.Process
+}
+
+// replaceProcess tracks a process that was not spawned by this launcher
+// instance, so there is no exec.Cmd to wait on.
+func (_c *handler) replaceProcess(proc *os.Process) {
+	_c.Lock()
+	defer _c.Unlock()
+	_c.cmd = nil
+	_c.proc = proc
+}
+
+func (_c *handler) currentProcess() *os.Process {
+	_c.RLock()
+	defer _c.RUnlock()
+	return _c.proc
 }
 
 func (_c *handler) currentCommand() *exec.Cmd {
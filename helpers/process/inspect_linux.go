@@ -0,0 +1,76 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ProcessInfo describes a running process as reported by the OS.
+type ProcessInfo struct {
+	Executable string
+	Args       []string
+	// UID is the real user ID the process runs as
+	UID int
+}
+
+// Inspect reads the executable, command line and owner of pid from /proc.
+// A binary replaced after the process started is reported without the
+// " (deleted)" suffix.
+func Inspect(pid int) (ProcessInfo, error) {
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	uid, err := processUID(pid)
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	var args []string
+	for _, arg := range bytes.Split(bytes.TrimSuffix(raw, []byte{0}), []byte{0}) {
+		args = append(args, string(arg))
+	}
+	return ProcessInfo{
+		Executable: strings.TrimSuffix(exe, " (deleted)"),
+		Args:       args,
+		UID:        uid,
+	}, nil
+}
+
+// processUID reads the real UID from the "Uid:" line of /proc/<pid>/status.
+func processUID(pid int) (int, error) {
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "Uid:" {
+			return strconv.Atoi(fields[1])
+		}
+	}
+	return 0, fmt.Errorf("no Uid in /proc/%d/status", pid)
+}
+
+// processAlive treats zombies as gone: they are dead but not yet reaped.
+func processAlive(pid int) bool {
+	if pid <= 0 || syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	// the state follows the parenthesised command name
+	if i := bytes.LastIndexByte(stat, ')'); i >= 0 && i+2 < len(stat) {
+		return stat[i+2] != 'Z'
+	}
+	return true
+}
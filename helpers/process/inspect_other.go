@@ -0,0 +1,26 @@
+//go:build !linux
+
+package process
+
+import (
+	"errors"
+	"syscall"
+)
+
+// ProcessInfo describes a running process as reported by the OS.
+type ProcessInfo struct {
+	Executable string
+	Args       []string
+	// UID is the real user ID the process runs as
+	UID int
+}
+
+var ErrInspectUnsupported = errors.New("process inspection is only supported on linux")
+
+func Inspect(pid int) (ProcessInfo, error) {
+	return ProcessInfo{}, ErrInspectUnsupported
+}
+
+func processAlive(pid int) bool {
+	return pid > 0 && syscall.Kill(pid, 0) == nil
+}
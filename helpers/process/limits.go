@@ -0,0 +1,34 @@
+package process
+
+import "errors"
+
+// ErrOOMKilled wraps the exit error of a process killed by the kernel after
+// reaching the memory limit of its cgroup.
+var ErrOOMKilled = errors.New("killed by the OOM killer")
+
+// Limits caps the resources of a process. Zero values mean unlimited.
+type Limits struct {
+	MemoryMax int64   // bytes
+	CPUQuota  float64 // cores, 0.5 is half of one core
+	PidsMax   int64
+	NoFile    uint64 // open file descriptors
+	// OwnUser tells that the process runs as a user of its own, so
+	// RLIMIT_NPROC can stand in for PidsMax without a cgroup. The instances
+	// of a blue/green swap share the limit.
+	OwnUser bool
+}
+
+func (l Limits) IsZero() bool { return l == Limits{} }
+
+// WithLimits applies limits to the process. With a cgroupParent, a
+// delegated cgroup v2 directory, each process is spawned into its own leaf
+// below it; without one, or when the cgroup cannot be used, the memory and
+// CPU limits are not enforced and the pids limit only for an OwnUser
+// process, through RLIMIT_NPROC. The rlimits are set with prlimit right
+// after the spawn.
+func WithLimits(cgroupParent string, limits Limits) ProcessOption {
+	return func(options *ProcessOptions) {
+		options.cgroupParent = cgroupParent
+		options.limits = limits
+	}
+}
@@ -0,0 +1,194 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// cpuPeriod is the cpu.max period in microseconds, the kernel default.
+const cpuPeriod = 100000
+
+// openCgroup creates the leaf of a process about to be spawned below parent
+// and opens it for SysProcAttr.CgroupFD, so the process starts inside it and
+// never runs unlimited. It returns "" and nil when there is no usable
+// cgroup; failures are logged.
+func openCgroup(id, parent string, limits Limits) (string, *os.File) {
+	if parent == "" || (limits.MemoryMax == 0 && limits.CPUQuota == 0 && limits.PidsMax == 0) {
+		return "", nil
+	}
+	leaf, err := createCgroup(parent, id, limits)
+	if err != nil {
+		slog.Warn("failed to create cgroup", "process_id", id, "cgroup_parent", parent, "error", err)
+		return "", nil
+	}
+	dir, err := os.Open(leaf)
+	if err != nil {
+		slog.Warn("failed to open cgroup", "process_id", id, "cgroup", leaf, "error", err)
+		removeCgroup(leaf)
+		return "", nil
+	}
+	return leaf, dir
+}
+
+// useCgroup makes the child start inside the opened leaf.
+func useCgroup(attr *syscall.SysProcAttr, dir *os.File) {
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(dir.Fd())
+}
+
+// applyLimits sets the rlimits of a spawned process with prlimit: the open
+// files limit, and without a cgroup RLIMIT_NPROC for the pids limit of a
+// process running as its own user. Memory and CPU limits are only enforced
+// by a cgroup. RLIMIT_AS is not used as a fallback: it caps the address
+// space rather than the memory in use, which the Go runtime and memory
+// mapped databases exceed long before using it.
+func applyLimits(id string, pid int, inCgroup bool, limits Limits) {
+	if limits.NoFile > 0 {
+		nofile := unix.Rlimit{Cur: limits.NoFile, Max: limits.NoFile}
+		if err := unix.Prlimit(pid, unix.RLIMIT_NOFILE, &nofile, nil); err != nil {
+			slog.Warn("failed to set open files limit", "process_id", id, "pid", pid, "error", err)
+		}
+	}
+	if inCgroup {
+		return
+	}
+	if limits.PidsMax > 0 {
+		if limits.OwnUser {
+			nproc := unix.Rlimit{Cur: uint64(limits.PidsMax), Max: uint64(limits.PidsMax)}
+			if err := unix.Prlimit(pid, unix.RLIMIT_NPROC, &nproc, nil); err != nil {
+				slog.Warn("failed to set processes limit", "process_id", id, "pid", pid, "error", err)
+			}
+		} else {
+			slog.Warn("pids limit requires a cgroup v2 parent or a user of its own, not enforced", "process_id", id, "pid", pid)
+		}
+	}
+	if limits.MemoryMax > 0 || limits.CPUQuota > 0 {
+		slog.Warn("memory and cpu limits require a cgroup v2 parent, not enforced", "process_id", id, "pid", pid)
+	}
+}
+
+// createCgroup writes the limits into a fresh leaf named after the process
+// id and a random suffix.
+func createCgroup(parent, id string, limits Limits) (string, error) {
+	leaf, err := os.MkdirTemp(parent, id+"-")
+	if err != nil {
+		return "", err
+	}
+	files := map[string]string{}
+	if limits.MemoryMax > 0 {
+		files["memory.max"] = strconv.FormatInt(limits.MemoryMax, 10)
+		// without swap the limit is a hard one and ends in an OOM kill
+		files["memory.swap.max"] = "0"
+	}
+	if limits.CPUQuota > 0 {
+		files["cpu.max"] = formatCPUMax(limits.CPUQuota)
+	}
+	if limits.PidsMax > 0 {
+		files["pids.max"] = strconv.FormatInt(limits.PidsMax, 10)
+	}
+	for name, value := range files {
+		err := os.WriteFile(filepath.Join(leaf, name), []byte(value), 0o644)
+		// kernels without swap accounting have no memory.swap.max
+		if err != nil && !(name == "memory.swap.max" && errors.Is(err, os.ErrNotExist)) {
+			removeCgroup(leaf)
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return leaf, nil
+}
+
+func formatCPUMax(cores float64) string {
+	quota := max(int64(cores*cpuPeriod), 1000) // the kernel rejects quotas below 1ms
+	return fmt.Sprintf("%d %d", quota, cpuPeriod)
+}
+
+// findCgroup returns the leaf of an adopted process below parent, if it
+// has one.
+func findCgroup(parent, id string, pid int) string {
+	if parent == "" {
+		return ""
+	}
+	raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	return cgroupLeaf(parent, id, raw)
+}
+
+// cgroupLeaf resolves the cgroup v2 entry of a /proc/<pid>/cgroup file to a
+// leaf of the process id below parent.
+func cgroupLeaf(parent, id string, raw []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		path, ok := strings.CutPrefix(scanner.Text(), "0::")
+		if !ok {
+			continue
+		}
+		name := filepath.Base(path)
+		if !strings.HasPrefix(name, id+"-") {
+			return ""
+		}
+		leaf := filepath.Join(parent, name)
+		if info, err := os.Stat(leaf); err != nil || !info.IsDir() {
+			return ""
+		}
+		return leaf
+	}
+	return ""
+}
+
+func cgroupOOMKilled(leaf string) bool {
+	raw, err := os.ReadFile(filepath.Join(leaf, "memory.events"))
+	if err != nil {
+		return false
+	}
+	return parseOOMKills(raw) > 0
+}
+
+// parseOOMKills reads the oom_kill counter of a memory.events file.
+func parseOOMKills(raw []byte) int {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if ok && key == "oom_kill" {
+			n, _ := strconv.Atoi(strings.TrimSpace(value))
+			return n
+		}
+	}
+	return 0
+}
+
+// removeCgroup deletes a leaf once its processes are gone. Only the
+// directory is removed, the kernel owns the interface files.
+func removeCgroup(leaf string) {
+	if err := unix.Rmdir(leaf); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to remove cgroup", "cgroup", leaf, "error", err)
+	}
+}
+
+// EnableCgroupControllers turns on the memory, cpu and pids controllers for
+// the leaves below parent. It is enough for the parent to be delegated to
+// the launcher user; controllers already enabled are left as they are.
+func EnableCgroupControllers(parent string) error {
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return err
+	}
+	var errs []error
+	for _, controller := range []string{"memory", "cpu", "pids"} {
+		err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+"+controller), 0o644)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s controller: %w", controller, err))
+		}
+	}
+	return errors.Join(errs...)
+}
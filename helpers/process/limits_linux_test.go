@@ -0,0 +1,82 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseOOMKills(t *testing.T) {
+	raw := []byte("low 0\nhigh 0\nmax 12\noom 2\noom_kill 1\noom_group_kill 0\n")
+	if got := parseOOMKills(raw); got != 1 {
+		t.Errorf("parseOOMKills() = %d, want 1", got)
+	}
+	if got := parseOOMKills([]byte("low 0\noom_group_kill 3\n")); got != 0 {
+		t.Errorf("parseOOMKills() = %d, want 0 without oom_kill", got)
+	}
+}
+
+func TestFormatCPUMax(t *testing.T) {
+	tests := map[float64]string{
+		0.5:    "50000 100000",
+		2:      "200000 100000",
+		0.0001: "1000 100000",
+	}
+	for cores, want := range tests {
+		if got := formatCPUMax(cores); got != want {
+			t.Errorf("formatCPUMax(%v) = %q, want %q", cores, got, want)
+		}
+	}
+}
+
+// A plain directory stands in for the cgroup file system: the interface
+// files are created as regular files.
+func TestCreateCgroup(t *testing.T) {
+	parent := t.TempDir()
+	limits := Limits{MemoryMax: 256 << 20, CPUQuota: 0.5, PidsMax: 64}
+
+	leaf, err := createCgroup(parent, "svc", limits)
+	if err != nil {
+		t.Fatalf("createCgroup() error = %v", err)
+	}
+	if filepath.Dir(leaf) != parent || !strings.HasPrefix(filepath.Base(leaf), "svc-") {
+		t.Fatalf("createCgroup() = %q, want a svc- leaf of %q", leaf, parent)
+	}
+	for name, want := range map[string]string{
+		"memory.max":      "268435456",
+		"memory.swap.max": "0",
+		"cpu.max":         "50000 100000",
+		"pids.max":        "64",
+	} {
+		raw, err := os.ReadFile(filepath.Join(leaf, name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if string(raw) != want {
+			t.Errorf("%s = %q, want %q", name, raw, want)
+		}
+	}
+	// the process is spawned into the leaf, it is never moved there
+	if _, err := os.Stat(filepath.Join(leaf, "cgroup.procs")); !os.IsNotExist(err) {
+		t.Errorf("cgroup.procs written before the spawn, stat error = %v", err)
+	}
+
+	procCgroup := []byte("0::/launcher/" + filepath.Base(leaf) + "\n")
+	if got := cgroupLeaf(parent, "svc", procCgroup); got != leaf {
+		t.Errorf("cgroupLeaf() = %q, want %q", got, leaf)
+	}
+	if got := cgroupLeaf(parent, "other", procCgroup); got != "" {
+		t.Errorf("cgroupLeaf() = %q, want empty for another process id", got)
+	}
+	if got := cgroupLeaf(parent, "svc", []byte("0::/user.slice\n")); got != "" {
+		t.Errorf("cgroupLeaf() = %q, want empty outside the parent", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(leaf, "memory.events"), []byte("oom 1\noom_kill 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !cgroupOOMKilled(leaf) {
+		t.Error("cgroupOOMKilled() = false, want true after an oom_kill event")
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+package process
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"syscall"
+)
+
+func openCgroup(id, parent string, limits Limits) (string, *os.File) { return "", nil }
+
+func useCgroup(attr *syscall.SysProcAttr, dir *os.File) {}
+
+func applyLimits(id string, pid int, inCgroup bool, limits Limits) {
+	slog.Warn("resource limits are only supported on linux, not enforced", "process_id", id, "pid", pid)
+}
+
+func findCgroup(parent, id string, pid int) string { return "" }
+
+func cgroupOOMKilled(leaf string) bool { return false }
+
+func removeCgroup(leaf string) {}
+
+func EnableCgroupControllers(parent string) error {
+	return errors.New("cgroups are only supported on linux")
+}
@@ -0,0 +1,8 @@
+package process
+
+import "syscall"
+
+func setMountNamespace(attr *syscall.SysProcAttr) error {
+	attr.Cloneflags |= syscall.CLONE_NEWNS
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package process
+
+import (
+	"errors"
+	"syscall"
+)
+
+func setMountNamespace(attr *syscall.SysProcAttr) error {
+	return errors.New("mount namespaces are only supported on linux")
+}
@@ -2,16 +2,29 @@ package process
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
 
+// ErrProcessNotFound is returned by Adopt when the PID is no longer alive.
+var ErrProcessNotFound = errors.New("process not found")
+
+// adoptPollInterval is how often an adopted process is checked for exit,
+// since only its parent can wait on it.
+var adoptPollInterval = 500 * time.Millisecond
+
 type ProcessErrorMessage struct {
 	ID    string
+	PID   int
 	Error error
 }
 
@@ -19,6 +32,14 @@ type ProcessOptions struct {
 	errChan chan<- ProcessErrorMessage
 	stderr  io.Writer
 	stdout  io.Writer
+	env     []string
+	logDir  string
+	//
+	cgroupParent string
+	limits       Limits
+	//
+	credential *syscall.Credential
+	mountNS    bool
 }
 
 type ProcessOption = func(*ProcessOptions)
@@ -35,6 +56,35 @@ func WithStderr(w io.Writer) ProcessOption {
 	return func(options *ProcessOptions) { options.stderr = w }
 }
 
+// WithEnv sets the child environment as KEY=VALUE pairs. Without it the
+// process starts with an empty environment.
+func WithEnv(env []string) ProcessOption {
+	return func(options *ProcessOptions) { options.env = env }
+}
+
+// WithDetach runs the child in its own process group and sends its output to
+// stdout.log and stderr.log inside logDir instead of pipes, so the child
+// survives the launcher exiting. The files are followed and forwarded to the
+// WithStdout and WithStderr writers. logDir must not be writable by the
+// child's user.
+func WithDetach(logDir string) ProcessOption {
+	return func(options *ProcessOptions) { options.logDir = logDir }
+}
+
+// WithCredential runs the child as uid and gid without supplementary
+// groups. The launcher must run as root.
+func WithCredential(uid, gid uint32) ProcessOption {
+	return func(options *ProcessOptions) {
+		options.credential = &syscall.Credential{Uid: uid, Gid: gid}
+	}
+}
+
+// WithMountNamespace starts the child in a new mount namespace, see the
+// sandbox package. Only supported on linux.
+func WithMountNamespace() ProcessOption {
+	return func(options *ProcessOptions) { options.mountNS = true }
+}
+
 type Process struct {
 	id      string
 	options *ProcessOptions
@@ -45,6 +95,13 @@ type Process struct {
 	h *handler
 
 	closeChan chan struct{}
+
+	followersMtx sync.Mutex
+	followers    []*fileFollower
+	released     atomic.Bool
+
+	// cgroup is the leaf holding the process, empty when it has none
+	cgroup string
 }
 
 func New(ID string, command string, args []string, options ...ProcessOption) *Process {
@@ -63,9 +120,48 @@ func New(ID string, command string, args []string, options ...ProcessOption) *Pr
 	return p
 }
 
+// Adopt tracks an already running process, typically one started by a
+// previous launcher run with WithDetach. Exit is detected by polling the PID.
+func Adopt(ID string, pid int, options ...ProcessOption) (*Process, error) {
+	if !processAlive(pid) {
+		return nil, fmt.Errorf("%w: pid %d", ErrProcessNotFound, pid)
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	p := New(ID, "", nil, options...)
+	p.closeChan = make(chan struct{})
+	if p.options.logDir != "" {
+		p.followLogs(true)
+	}
+	p.h.replaceProcess(proc)
+	p.h.updateStatus(Running)
+	p.cgroup = findCgroup(p.options.cgroupParent, ID, pid)
+
+	go p.watchAdopted(pid, p.closeChan)
+	return p, nil
+}
+
 func (p *Process) Status() ProcessState { return p.h.currentState() }
 func (p *Process) IsRunning() bool      { return p.Status() == Running }
 
+// Pid returns the OS process ID, or 0 when the process was never started.
+func (p *Process) Pid() int {
+	if proc := p.h.currentProcess(); proc != nil {
+		return proc.Pid
+	}
+	return 0
+}
+
+// Release stops tracking the process without signalling it. Its exit is no
+// longer reported, so it can keep running after the launcher is gone.
+func (p *Process) Release() {
+	p.released.Store(true)
+	p.closeFollowers()
+}
+
 func (p *Process) Start() error {
 	currentState := p.Status()
 	if currentState != Stopped {
@@ -76,19 +172,67 @@ func (p *Process) Start() error {
 
 	cmd := exec.Command(p.command, p.args...)
 	cmd.Env = []string{}
-	if p.options.stdout != nil {
-		cmd.Stdout = p.options.stdout
+	if p.options.env != nil {
+		cmd.Env = p.options.env
 	}
-	if p.options.stderr != nil {
-		cmd.Stderr = p.options.stderr
+	if p.options.credential != nil {
+		sysProcAttr(cmd).Credential = p.options.credential
+	}
+	if p.options.mountNS {
+		if err := setMountNamespace(sysProcAttr(cmd)); err != nil {
+			return err
+		}
+	}
+	// descriptors handed to the child, closed once it is spawned
+	var childFiles []*os.File
+	if p.options.logDir != "" {
+		files, err := p.openLogFiles(cmd)
+		if err != nil {
+			slog.Error("failed to open process log files", "error", err, "process_id", p.id)
+			return err
+		}
+		childFiles = files
+	} else {
+		if p.options.stdout != nil {
+			cmd.Stdout = p.options.stdout
+		}
+		if p.options.stderr != nil {
+			cmd.Stderr = p.options.stderr
+		}
+	}
+
+	p.cgroup = ""
+	if !p.options.limits.IsZero() {
+		leaf, dir := openCgroup(p.id, p.options.cgroupParent, p.options.limits)
+		if dir != nil {
+			p.cgroup = leaf
+			useCgroup(sysProcAttr(cmd), dir)
+			childFiles = append(childFiles, dir)
+		}
 	}
 
 	p.h.updateStatus(Starting)
-	if err := cmd.Start(); err != nil {
+	err := cmd.Start()
+	// the child holds its own descriptors
+	for _, f := range childFiles {
+		f.Close()
+	}
+	if err != nil {
+		if p.cgroup != "" {
+			removeCgroup(p.cgroup)
+			p.cgroup = ""
+		}
 		p.h.updateStatus(Stopped)
 		slog.Error("failed to start process", "error", err, "process_id", p.id)
 		return err
 	}
+	p.released.Store(false)
+	if p.options.logDir != "" {
+		p.followLogs(false)
+	}
+	if !p.options.limits.IsZero() {
+		applyLimits(p.id, cmd.Process.Pid, p.cgroup != "", p.options.limits)
+	}
 
 	go p.waitForExit(cmd, p.closeChan)
 
@@ -97,12 +241,97 @@ func (p *Process) Start() error {
 	return nil
 }
 
+// openLogFiles never follows a symlink at the log file names, the directory
+// is expected to be writable by the launcher only.
+func (p *Process) openLogFiles(cmd *exec.Cmd) ([]*os.File, error) {
+	if err := os.MkdirAll(p.options.logDir, 0o700); err != nil {
+		return nil, err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC | syscall.O_NOFOLLOW
+	stdout, err := os.OpenFile(filepath.Join(p.options.logDir, "stdout.log"), flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := os.OpenFile(filepath.Join(p.options.logDir, "stderr.log"), flags, 0o644)
+	if err != nil {
+		stdout.Close()
+		return nil, err
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	sysProcAttr(cmd).Setpgid = true
+	return []*os.File{stdout, stderr}, nil
+}
+
+func sysProcAttr(cmd *exec.Cmd) *syscall.SysProcAttr {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	return cmd.SysProcAttr
+}
+
+// followLogs forwards the detached log files to the configured writers.
+// Adopted processes skip the output written while nobody was following.
+func (p *Process) followLogs(fromEnd bool) {
+	p.followersMtx.Lock()
+	defer p.followersMtx.Unlock()
+	if p.options.stdout != nil {
+		p.followers = append(p.followers,
+			followFile(filepath.Join(p.options.logDir, "stdout.log"), p.options.stdout, fromEnd))
+	}
+	if p.options.stderr != nil {
+		p.followers = append(p.followers,
+			followFile(filepath.Join(p.options.logDir, "stderr.log"), p.options.stderr, fromEnd))
+	}
+}
+
+func (p *Process) closeFollowers() {
+	p.followersMtx.Lock()
+	followers := p.followers
+	p.followers = nil
+	p.followersMtx.Unlock()
+	for _, f := range followers {
+		f.Close()
+	}
+}
+
+func (p *Process) watchAdopted(pid int, doneChan chan struct{}) {
+	ticker := time.NewTicker(adoptPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !processAlive(pid) {
+			break
+		}
+	}
+	p.closeFollowers()
+	oomKilled := p.releaseCgroup()
+	if p.Status() != Stopping && !p.released.Load() {
+		err := fmt.Errorf("adopted process %d exited", pid)
+		if oomKilled {
+			err = fmt.Errorf("%w: %w", ErrOOMKilled, err)
+		}
+		if p.options.errChan != nil {
+			p.options.errChan <- ProcessErrorMessage{ID: p.id, PID: pid, Error: err}
+		}
+		slog.Error("process exited with error", "error", err, "process_id", p.id)
+	}
+	p.h.updateStatus(Stopped)
+	close(doneChan)
+}
+
 func (p *Process) waitForExit(cmd *exec.Cmd, doneChan chan struct{}) {
-	if err := cmd.Wait(); err != nil {
+	err := cmd.Wait()
+	p.closeFollowers()
+	oomKilled := p.releaseCgroup()
+	if err != nil && !p.released.Load() {
 		if err.Error() != "signal: terminated" {
+			if oomKilled {
+				err = fmt.Errorf("%w: %w", ErrOOMKilled, err)
+			}
 			if p.options.errChan != nil {
 				p.options.errChan <- ProcessErrorMessage{
 					ID:    p.id,
+					PID:   cmd.Process.Pid,
 					Error: fmt.Errorf("process exited with error: %w", err),
 				}
 			}
@@ -115,20 +344,31 @@ func (p *Process) waitForExit(cmd *exec.Cmd, doneChan chan struct{}) {
 	}
 }
 
+// releaseCgroup reports whether the OOM killer ended the process and
+// removes its leaf. A released process keeps its cgroup, it is still running.
+func (p *Process) releaseCgroup() bool {
+	if p.cgroup == "" || p.released.Load() {
+		return false
+	}
+	oomKilled := cgroupOOMKilled(p.cgroup)
+	removeCgroup(p.cgroup)
+	return oomKilled
+}
+
 func (p *Process) Stop() error {
 	currentState := p.Status()
 	if currentState != Running {
 		return nil
 	}
 
-	cmd := p.h.currentCommand()
-	if cmd == nil {
+	proc := p.h.currentProcess()
+	if proc == nil {
 		slog.Warn("stop ignored: no active command found", "process_id", p.id)
 		return nil
 	}
 
 	p.h.updateStatus(Stopping)
-	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+	if err := proc.Signal(syscall.SIGTERM); err != nil {
 		p.h.updateStatus(Running)
 		slog.Error("failed to stop process", "error", err, "process_id", p.id)
 		return err
@@ -142,7 +382,7 @@ func (p *Process) Stop() error {
 		case <-p.closeChan:
 		case <-ctx.Done():
 			slog.Warn("process did not exit gracefully, sending SIGKILL", "process_id", p.id)
-			_ = cmd.Process.Kill()
+			_ = proc.Kill()
 		}
 	}
 	return nil
@@ -2,7 +2,12 @@ package process_test
 
 import (
 	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"pb_launcher/helpers/process"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -169,3 +174,91 @@ func TestProcess_StdoutAndStderr(t *testing.T) {
 		t.Fatalf("unexpected stderr output, got: %q", stderr.String())
 	}
 }
+
+func TestProcess_Env(t *testing.T) {
+	var stdout bytes.Buffer
+	service := process.New("test-service", "printenv", []string{"PB_TEST_VAR"},
+		process.WithStdout(&stdout),
+		process.WithEnv([]string{"PB_TEST_VAR=hello"}))
+
+	if err := service.Start(); err != nil {
+		t.Fatalf("failed to start service: %v", err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	if got := stdout.String(); got != "hello\n" {
+		t.Fatalf("unexpected stdout, got: %q", got)
+	}
+}
+
+func TestProcess_DetachWritesLogFiles(t *testing.T) {
+	logDir := t.TempDir()
+	var stdout bytes.Buffer
+	service := process.New("test-service", "echo", []string{"hello"},
+		process.WithDetach(logDir),
+		process.WithStdout(&stdout))
+
+	if err := service.Start(); err != nil {
+		t.Fatalf("failed to start service: %v", err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	if service.IsRunning() {
+		t.Fatalf("service should have exited")
+	}
+	data, err := os.ReadFile(filepath.Join(logDir, "stdout.log"))
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	if string(data) != "hello\n" {
+		t.Fatalf("unexpected log file content, got: %q", data)
+	}
+	if got := stdout.String(); got != "hello\n" {
+		t.Fatalf("unexpected forwarded stdout, got: %q", got)
+	}
+}
+
+func TestProcess_Adopt(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	go cmd.Wait()
+
+	if runtime.GOOS == "linux" {
+		info, err := process.Inspect(cmd.Process.Pid)
+		if err != nil {
+			t.Fatalf("failed to inspect process: %v", err)
+		}
+		if filepath.Base(info.Executable) != "sleep" || len(info.Args) != 2 || info.Args[1] != "30" || info.UID != os.Getuid() {
+			t.Fatalf("unexpected process info: %+v", info)
+		}
+	}
+
+	errChan := make(chan process.ProcessErrorMessage, 1)
+	service, err := process.Adopt("test-service", cmd.Process.Pid, process.WithErrorChan(errChan))
+	if err != nil {
+		t.Fatalf("failed to adopt process: %v", err)
+	}
+	if !service.IsRunning() || service.Pid() != cmd.Process.Pid {
+		t.Fatalf("adopted process should be running with pid %d", cmd.Process.Pid)
+	}
+
+	if err := service.Stop(); err != nil {
+		t.Fatalf("failed to stop service: %v", err)
+	}
+	if service.IsRunning() {
+		t.Fatalf("service should not be running")
+	}
+	select {
+	case msg := <-errChan:
+		t.Fatalf("unexpected error after stop: %v", msg.Error)
+	default:
+	}
+
+	if _, err := process.Adopt("test-service", cmd.Process.Pid); !errors.Is(err, process.ErrProcessNotFound) {
+		t.Fatalf("expected ErrProcessNotFound, got: %v", err)
+	}
+}
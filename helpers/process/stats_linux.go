@@ -0,0 +1,88 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc/<pid>/stat. It
+// is 100 on every architecture Linux supports.
+const clockTicks = 100
+
+// Stats is the resource usage of a process read from /proc.
+type Stats struct {
+	CPUTime time.Duration // user and system time since the process started
+	RSS     int64         // resident set size, in bytes
+	OpenFDs int
+}
+
+// ReadStats samples /proc/<pid>/stat, status and fd.
+func ReadStats(pid int) (Stats, error) {
+	raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return Stats{}, err
+	}
+	cpu, err := parseStatCPUTime(raw)
+	if err != nil {
+		return Stats{}, err
+	}
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return Stats{}, err
+	}
+	fds, err := os.ReadDir(fmt.Sprintf("/proc/%d/fd", pid))
+	if err != nil {
+		return Stats{}, err
+	}
+	return Stats{
+		CPUTime: cpu,
+		RSS:     parseStatusRSS(status),
+		OpenFDs: len(fds),
+	}, nil
+}
+
+// parseStatCPUTime adds utime and stime, fields 14 and 15 of
+// /proc/<pid>/stat. Fields are counted after the parenthesised command
+// name, which may contain spaces.
+func parseStatCPUTime(raw []byte) (time.Duration, error) {
+	i := bytes.LastIndexByte(raw, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("malformed stat: %q", raw)
+	}
+	fields := bytes.Fields(raw[i+1:])
+	// fields[0] is the state, field 3 of the file
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("malformed stat: %q", raw)
+	}
+	utime, err := strconv.ParseInt(string(fields[11]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed utime: %w", err)
+	}
+	stime, err := strconv.ParseInt(string(fields[12]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed stime: %w", err)
+	}
+	return time.Duration(utime+stime) * time.Second / clockTicks, nil
+}
+
+// parseStatusRSS returns VmRSS from /proc/<pid>/status in bytes, 0 when the
+// line is missing (kernel threads, zombies).
+func parseStatusRSS(raw []byte) int64 {
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) < 2 || string(fields[0]) != "VmRSS:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(string(fields[1]), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb << 10
+	}
+	return 0
+}
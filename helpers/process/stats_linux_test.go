@@ -0,0 +1,42 @@
+package process
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseStatCPUTime(t *testing.T) {
+	raw := []byte("1234 (pocket base) S 1 1234 1234 0 -1 4194560 1500 0 0 0 250 130 0 0 20 0 12 0 100 0 0")
+	got, err := parseStatCPUTime(raw)
+	if err != nil {
+		t.Fatalf("parseStatCPUTime() error = %v", err)
+	}
+	if want := 3800 * time.Millisecond; got != want {
+		t.Errorf("parseStatCPUTime() = %v, want %v", got, want)
+	}
+
+	if _, err := parseStatCPUTime([]byte("1234 (broken")); err == nil {
+		t.Error("parseStatCPUTime() expected an error for a malformed stat")
+	}
+}
+
+func TestParseStatusRSS(t *testing.T) {
+	raw := []byte("Name:\tpocketbase\nVmPeak:\t  900000 kB\nVmRSS:\t   20480 kB\nThreads:\t12\n")
+	if got, want := parseStatusRSS(raw), int64(20480<<10); got != want {
+		t.Errorf("parseStatusRSS() = %d, want %d", got, want)
+	}
+	if got := parseStatusRSS([]byte("Name:\tkthreadd\n")); got != 0 {
+		t.Errorf("parseStatusRSS() = %d, want 0 without VmRSS", got)
+	}
+}
+
+func TestReadStats_Self(t *testing.T) {
+	stats, err := ReadStats(os.Getpid())
+	if err != nil {
+		t.Fatalf("ReadStats() error = %v", err)
+	}
+	if stats.RSS <= 0 || stats.OpenFDs <= 0 {
+		t.Errorf("ReadStats() = %+v, want a positive RSS and open fds", stats)
+	}
+}
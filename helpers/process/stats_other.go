@@ -0,0 +1,16 @@
+//go:build !linux
+
+package process
+
+import "time"
+
+// Stats is the resource usage of a process read from /proc.
+type Stats struct {
+	CPUTime time.Duration // user and system time since the process started
+	RSS     int64         // resident set size, in bytes
+	OpenFDs int
+}
+
+func ReadStats(pid int) (Stats, error) {
+	return Stats{}, ErrInspectUnsupported
+}
@@ -0,0 +1,319 @@
+package promexport
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default latency buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics of the launcher and writes them in the
+// Prometheus text exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]family
+}
+
+type family interface {
+	write(ctx context.Context, w *bufio.Writer) error
+}
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]family)}
+}
+
+func (r *Registry) register(name string, f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.families[name]; exists {
+		panic(fmt.Sprintf("promexport: metric %q registered twice", name))
+	}
+	r.families[name] = f
+}
+
+// Write renders every metric, sorted by name. A failing gauge function is
+// logged and left out so the rest of the scrape still succeeds.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]family, 0, len(names))
+	slices.Sort(names)
+	for _, name := range names {
+		families = append(families, r.families[name])
+	}
+	r.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for idx, f := range families {
+		if err := f.write(ctx, buf); err != nil {
+			slog.Warn("failed to collect metric", "metric", names[idx], "error", err)
+		}
+	}
+	return buf.Flush()
+}
+
+// Handler serves the metrics. With a token, requests must send it as
+// "Authorization: Bearer <token>".
+func (r *Registry) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.Write(req.Context(), w); err != nil {
+			slog.Warn("failed to write metrics", "error", err)
+		}
+	})
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, help, d.name, d.kind)
+}
+
+func (d desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("promexport: metric %q expects %d label values, got %d",
+			d.name, len(d.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeSample writes one line; extra is an already rendered label pair.
+func (d desc) writeSample(w *bufio.Writer, suffix string, labelValues []string, extra string, value float64) {
+	w.WriteString(d.name)
+	w.WriteString(suffix)
+	if len(labelValues) > 0 || extra != "" {
+		w.WriteByte('{')
+		for idx, label := range d.labels {
+			if idx > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, labelValueEscaper.Replace(labelValues[idx]))
+		}
+		if extra != "" {
+			if len(labelValues) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+// valueVec is the storage shared by counters and gauges.
+type valueVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func (v *valueVec) update(labelValues []string, fn func(current float64) float64) {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		v.series[key] = s
+	}
+	s.value = fn(s.value)
+}
+
+func (v *valueVec) write(ctx context.Context, w *bufio.Writer) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.writeHeader(w)
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		v.writeSample(w, "", s.labelValues, "", s.value)
+	}
+	return nil
+}
+
+// CounterVec is a monotonically increasing value per label set.
+type CounterVec struct{ valueVec }
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{valueVec{
+		desc:   desc{name: name, help: help, kind: "counter", labels: labels},
+		series: make(map[string]*series),
+	}}
+	r.register(name, c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("promexport: counter %q cannot decrease", c.name))
+	}
+	c.update(labelValues, func(current float64) float64 { return current + delta })
+}
+
+// GaugeVec is a value per label set that can go up and down.
+type GaugeVec struct{ valueVec }
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{valueVec{
+		desc:   desc{name: name, help: help, kind: "gauge", labels: labels},
+		series: make(map[string]*series),
+	}}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.update(labelValues, func(float64) float64 { return value })
+}
+
+// GaugeCollectFunc reports the current values of a gauge at scrape time.
+type GaugeCollectFunc func(ctx context.Context, set func(value float64, labelValues ...string)) error
+
+type gaugeFunc struct {
+	desc
+	collect GaugeCollectFunc
+}
+
+// NewGaugeFunc registers a gauge read from its source on every scrape,
+// for values owned by the database or the file system.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect GaugeCollectFunc) {
+	r.register(name, &gaugeFunc{
+		desc:    desc{name: name, help: help, kind: "gauge", labels: labels},
+		collect: collect,
+	})
+}
+
+func (g *gaugeFunc) write(ctx context.Context, w *bufio.Writer) error {
+	values := make(map[string]*series)
+	err := g.collect(ctx, func(value float64, labelValues ...string) {
+		values[g.key(labelValues)] = &series{labelValues: slices.Clone(labelValues), value: value}
+	})
+	if err != nil {
+		return err
+	}
+	g.writeHeader(w)
+	for _, key := range sortedKeys(values) {
+		s := values[key]
+		g.writeSample(w, "", s.labelValues, "", s.value)
+	}
+	return nil
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	sum         float64
+	count       uint64
+}
+
+// HistogramVec counts observations in buckets per label set.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: slices.Sorted(slices.Values(buckets)),
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(name, h)
+	return h
+}
+
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: slices.Clone(labelValues),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	if idx, _ := slices.BinarySearch(h.buckets, value); idx < len(h.buckets) {
+		s.counts[idx]++
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) write(ctx context.Context, w *bufio.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for idx, bound := range h.buckets {
+			cumulative += s.counts[idx]
+			h.writeSample(w, "_bucket", s.labelValues, fmt.Sprintf(`le="%s"`, formatFloat(bound)), float64(cumulative))
+		}
+		h.writeSample(w, "_bucket", s.labelValues, `le="+Inf"`, float64(s.count))
+		h.writeSample(w, "_sum", s.labelValues, "", s.sum)
+		h.writeSample(w, "_count", s.labelValues, "", float64(s.count))
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
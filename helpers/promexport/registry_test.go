@@ -0,0 +1,100 @@
+package promexport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.NewCounterVec("test_requests_total", "Requests handled.", "host")
+	requests.Inc("a.example.com")
+	requests.Add(2, "a.example.com")
+	requests.Inc(`b"\`)
+
+	up := r.NewGaugeVec("test_up", "Whether the target is up.")
+	up.Set(1)
+
+	latency := r.NewHistogramVec("test_latency_seconds", "Request latency.", []float64{0.1, 1}, "host")
+	latency.Observe(0.05, "a")
+	latency.Observe(0.5, "a")
+	latency.Observe(3, "a")
+
+	r.NewGaugeFunc("test_ttl_seconds", "Certificate TTL.\nIn seconds.", []string{"domain"},
+		func(ctx context.Context, set func(float64, ...string)) error {
+			set(3600, "x.example.com")
+			return nil
+		})
+	r.NewGaugeFunc("test_broken", "Fails to collect.", nil,
+		func(ctx context.Context, set func(float64, ...string)) error {
+			return errors.New("database is locked")
+		})
+
+	var out bytes.Buffer
+	require.NoError(t, r.Write(context.Background(), &out))
+	require.Equal(t, `# HELP test_latency_seconds Request latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{host="a",le="0.1"} 1
+test_latency_seconds_bucket{host="a",le="1"} 2
+test_latency_seconds_bucket{host="a",le="+Inf"} 3
+test_latency_seconds_sum{host="a"} 3.55
+test_latency_seconds_count{host="a"} 3
+# HELP test_requests_total Requests handled.
+# TYPE test_requests_total counter
+test_requests_total{host="a.example.com"} 3
+test_requests_total{host="b\"\\"} 1
+# HELP test_ttl_seconds Certificate TTL.\nIn seconds.
+# TYPE test_ttl_seconds gauge
+test_ttl_seconds{domain="x.example.com"} 3600
+# HELP test_up Whether the target is up.
+# TYPE test_up gauge
+test_up 1
+`, out.String())
+}
+
+func TestRegistry_PanicsOnMisuse(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounterVec("test_total", "Test.", "a", "b")
+
+	require.Panics(t, func() { r.NewGaugeVec("test_total", "Duplicate.") })
+	require.Panics(t, func() { counter.Inc("only-one") })
+	require.Panics(t, func() { counter.Add(-1, "a", "b") })
+}
+
+func TestRegistry_HandlerToken(t *testing.T) {
+	r := NewRegistry()
+	r.NewGaugeVec("test_up", "Up.").Set(1)
+	handler := r.Handler("s3cret")
+
+	tests := []struct {
+		authorization string
+		expected      int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"s3cret", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, tt.expected, rec.Code, "authorization %q", tt.authorization)
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "test_up 1\n")
+	require.Contains(t, rec.Header().Get("Content-Type"), "version=0.0.4")
+}
@@ -0,0 +1,193 @@
+package s3client
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPartSize = 8 << 20
+	abortTimeout    = 30 * time.Second
+)
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// Upload stores the content of reader under key and returns its size.
+// Content larger than one part is sent as a multipart upload; every
+// request carries the MD5 and SHA-256 of its payload and the returned
+// ETags are checked against the local digests.
+func (c *Client) Upload(ctx context.Context, key string, reader io.Reader) (int64, error) {
+	partSize := c.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+	buf := make([]byte, partSize)
+
+	n, readErr := io.ReadFull(reader, buf)
+	if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+		if _, err := c.putPart(ctx, key, nil, buf[:n]); err != nil {
+			return 0, err
+		}
+		return int64(n), nil
+	}
+	if readErr != nil {
+		return 0, readErr
+	}
+
+	uploadID, err := c.createMultipartUpload(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	var parts []completedPart
+	var digests []byte
+	var size int64
+	for number := 1; n > 0; number++ {
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		sum, err := c.putPart(ctx, key, query, buf[:n])
+		if err != nil {
+			return 0, c.abortMultipartUpload(key, uploadID, err)
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: `"` + hex.EncodeToString(sum) + `"`})
+		digests = append(digests, sum...)
+		size += int64(n)
+
+		if readErr != nil {
+			break // the last part was short
+		}
+		n, readErr = io.ReadFull(reader, buf)
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return 0, c.abortMultipartUpload(key, uploadID, readErr)
+		}
+	}
+
+	etag, err := c.completeMultipartUpload(ctx, key, uploadID, parts)
+	if err != nil {
+		return 0, c.abortMultipartUpload(key, uploadID, err)
+	}
+	sum := md5.Sum(digests)
+	expected := fmt.Sprintf("%s-%d", hex.EncodeToString(sum[:]), len(parts))
+	if !etagMatches(etag, expected) {
+		return 0, fmt.Errorf("s3: checksum mismatch for %s: got etag %s, want %s", key, etag, expected)
+	}
+	return size, nil
+}
+
+// putPart uploads a whole object (nil query) or a multipart part and
+// returns the MD5 of the payload once the storage confirmed it.
+func (c *Client) putPart(ctx context.Context, key string, query url.Values, payload []byte) ([]byte, error) {
+	sum := md5.Sum(payload)
+	header := http.Header{}
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+
+	resp, err := c.do(ctx, http.MethodPut, key, query, header, payload)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if etag := resp.Header.Get("ETag"); !etagMatches(etag, hex.EncodeToString(sum[:])) {
+		return nil, fmt.Errorf("s3: checksum mismatch for %s: got etag %s", key, etag)
+	}
+	return sum[:], nil
+}
+
+// etagMatches compares an ETag with the expected digest. ETags that are
+// not plain digests (e.g. objects encrypted with SSE-KMS) are trusted,
+// the Content-MD5 header was already verified by the storage.
+func etagMatches(etag, expected string) bool {
+	etag = strings.Trim(etag, `"`)
+	digest, _, _ := strings.Cut(etag, "-")
+	if len(digest) != 32 {
+		return true
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return true
+	}
+	return strings.EqualFold(etag, expected)
+}
+
+func (c *Client) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.UploadID == "" {
+		return "", errors.New("s3: missing upload id")
+	}
+	return result.UploadID, nil
+}
+
+func (c *Client) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) (string, error) {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return "", err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/xml")
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, header, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// the storage may answer 200 and still report an error in the body
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		XMLName xml.Name
+		ETag    string `xml:"ETag"`
+	}
+	if err := xml.Unmarshal(raw, &result); err != nil {
+		return "", err
+	}
+	if result.XMLName.Local == "Error" {
+		respErr := &ResponseError{Status: resp.StatusCode}
+		_ = xml.NewDecoder(bytes.NewReader(raw)).Decode(respErr)
+		return "", respErr
+	}
+	return result.ETag, nil
+}
+
+// abortMultipartUpload discards the uploaded parts so they do not keep
+// using storage, it runs detached from ctx which may be already canceled.
+func (c *Client) abortMultipartUpload(key, uploadID string, cause error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return errors.Join(cause, fmt.Errorf("failed to abort multipart upload: %w", err))
+	}
+	resp.Body.Close()
+	return cause
+}
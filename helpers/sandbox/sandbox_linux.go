@@ -0,0 +1,147 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+var devices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// Main runs the sandbox helper and only returns on failure, before the
+// service binary was executed.
+func Main(args []string) error {
+	spec, err := ParseArgs(args)
+	if err != nil {
+		return err
+	}
+	if err := buildRoot(spec); err != nil {
+		return fmt.Errorf("failed to build sandbox root: %w", err)
+	}
+	if err := dropPrivileges(spec.UID, spec.GID); err != nil {
+		return fmt.Errorf("failed to drop privileges: %w", err)
+	}
+	argv := append([]string{spec.Executable}, spec.Args...)
+	return syscall.Exec(spec.Executable, argv, os.Environ())
+}
+
+// buildRoot mounts a tmpfs on spec.Root, populates it and makes it the
+// root of the mount namespace. It must run in a new mount namespace.
+func buildRoot(spec Spec) error {
+	// keep every mount below private to this namespace
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := os.MkdirAll(spec.Root, 0o700); err != nil {
+		return err
+	}
+	root := spec.Root
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+
+	procDir := filepath.Join(root, "proc")
+	if err := os.MkdirAll(procDir, 0o555); err != nil {
+		return err
+	}
+	if err := unix.Mount("proc", procDir, "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mount /proc: %w", err)
+	}
+	tmpDir := filepath.Join(root, "tmp")
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return err
+	}
+	if err := unix.Mount("tmpfs", tmpDir, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+
+	// after /tmp, the service files may live below it
+	for _, path := range spec.Binds {
+		if err := bindMount(root, path, false); err != nil {
+			return err
+		}
+	}
+	for _, path := range append(spec.ReadOnly, spec.Executable) {
+		if err := bindMount(root, path, true); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	for _, device := range devices {
+		if err := bindMount(root, device, false); err != nil {
+			return err
+		}
+	}
+
+	oldRoot := filepath.Join(root, ".old_root")
+	if err := os.Mkdir(oldRoot, 0o700); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return err
+	}
+	if err := unix.Unmount("/.old_root", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("unmount old root: %w", err)
+	}
+	if err := os.Remove("/.old_root"); err != nil {
+		return err
+	}
+	if spec.Dir != "" {
+		// relative paths in the arguments resolve as they did outside
+		_ = os.Chdir(spec.Dir)
+	}
+	return nil
+}
+
+// bindMount mounts path at the same location below root.
+func bindMount(root, path string, readOnly bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(root, path)
+	if info.IsDir() {
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		f.Close()
+	}
+	if err := unix.Mount(path, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", path, err)
+	}
+	if readOnly {
+		flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY | unix.MS_REC | unix.MS_NOSUID)
+		if err := unix.Mount("", target, "", flags, ""); err != nil {
+			return fmt.Errorf("remount %s read-only: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func dropPrivileges(uid, gid int) error {
+	if err := syscall.Setgroups(nil); err != nil {
+		return err
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return err
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return err
+	}
+	// setuid binaries inside the sandbox cannot regain privileges
+	return unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0)
+}
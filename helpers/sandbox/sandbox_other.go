@@ -0,0 +1,9 @@
+//go:build !linux
+
+package sandbox
+
+import "errors"
+
+func Main(args []string) error {
+	return errors.New("the sandbox is only supported on linux")
+}
@@ -0,0 +1,91 @@
+// Package sandbox starts a service binary with a private view of the file
+// system. The launcher re-executes itself with Command as first argument in
+// a new mount namespace; Main then builds the sandbox root, drops to the
+// service user and replaces itself with the binary, keeping the PID.
+package sandbox
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Command is the first argument that makes the launcher binary run Main.
+const Command = "sandbox-exec"
+
+// SystemFiles are mounted read-only when present so the service can resolve
+// names, verify TLS certificates and load time zones.
+var SystemFiles = []string{
+	"/etc/resolv.conf",
+	"/etc/hosts",
+	"/etc/nsswitch.conf",
+	"/etc/localtime",
+	"/etc/ssl/certs",
+	"/etc/pki/tls/certs",
+	"/usr/share/zoneinfo",
+}
+
+// Spec describes what the sandboxed process sees and runs as.
+type Spec struct {
+	Root       string // directory the private root is mounted on, created if missing
+	UID        int
+	GID        int
+	Binds      []string // absolute paths mounted read-write at the same location
+	ReadOnly   []string // absolute paths mounted read-only, skipped when missing
+	Dir        string   // working directory inside the sandbox
+	Executable string
+	Args       []string
+}
+
+// CommandArgs encodes the spec as the arguments following Command.
+func (s Spec) CommandArgs() []string {
+	args := []string{
+		"--root", s.Root,
+		"--uid", strconv.Itoa(s.UID),
+		"--gid", strconv.Itoa(s.GID),
+	}
+	if s.Dir != "" {
+		args = append(args, "--dir", s.Dir)
+	}
+	for _, path := range s.Binds {
+		args = append(args, "--bind", path)
+	}
+	for _, path := range s.ReadOnly {
+		args = append(args, "--ro", path)
+	}
+	args = append(args, "--", s.Executable)
+	return append(args, s.Args...)
+}
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+
+// ParseArgs decodes the arguments written by CommandArgs.
+func ParseArgs(args []string) (Spec, error) {
+	var spec Spec
+	fs := flag.NewFlagSet(Command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&spec.Root, "root", "", "")
+	fs.IntVar(&spec.UID, "uid", -1, "")
+	fs.IntVar(&spec.GID, "gid", -1, "")
+	fs.StringVar(&spec.Dir, "dir", "", "")
+	fs.Var((*listFlag)(&spec.Binds), "bind", "")
+	fs.Var((*listFlag)(&spec.ReadOnly), "ro", "")
+	if err := fs.Parse(args); err != nil {
+		return Spec{}, err
+	}
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return Spec{}, errors.New("missing executable")
+	}
+	spec.Executable, spec.Args = rest[0], rest[1:]
+	// running a tenant as root would defeat the sandbox
+	if spec.Root == "" || spec.UID <= 0 || spec.GID <= 0 {
+		return Spec{}, errors.New("root, uid and gid are required and uid/gid cannot be root")
+	}
+	return spec, nil
+}
@@ -0,0 +1,40 @@
+package sandbox
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSpecArgsRoundTrip(t *testing.T) {
+	spec := Spec{
+		Root:       "/tmp/pb_launcher-sandbox",
+		UID:        200001,
+		GID:        200001,
+		Binds:      []string{"/srv/data/abc"},
+		ReadOnly:   []string{"/etc/resolv.conf", "/etc/ssl/certs"},
+		Dir:        "/srv",
+		Executable: "/srv/downloads/pocketbase",
+		Args:       []string{"serve", "--dir", "./data/abc/pb_data", "--http", "127.0.0.1:9000"},
+	}
+	got, err := ParseArgs(spec.CommandArgs())
+	if err != nil {
+		t.Fatalf("ParseArgs() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, spec) {
+		t.Errorf("ParseArgs() = %+v, want %+v", got, spec)
+	}
+}
+
+func TestParseArgsRejectsRoot(t *testing.T) {
+	tests := [][]string{
+		{"--root", "/r", "--uid", "0", "--gid", "200001", "--", "/bin/true"},
+		{"--root", "/r", "--uid", "200001", "--", "/bin/true"},
+		{"--root", "/r", "--uid", "200001", "--gid", "200001"},
+		{"--uid", "200001", "--gid", "200001", "--", "/bin/true"},
+	}
+	for _, args := range tests {
+		if _, err := ParseArgs(args); err == nil {
+			t.Errorf("ParseArgs(%q) expected an error", args)
+		}
+	}
+}
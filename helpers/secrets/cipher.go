@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+const keyLength = 32
+
+var ErrInvalidKey = errors.New("master key must be exactly 32 characters")
+
+// Cipher encrypts values at rest with the launcher master key (AES-256-GCM).
+type Cipher struct {
+	key string
+}
+
+func NewCipher(key string) (*Cipher, error) {
+	if len(key) != keyLength {
+		return nil, ErrInvalidKey
+	}
+	return &Cipher{key: key}, nil
+}
+
+// LoadOrCreateKey reads the master key stored at keyPath, generating and
+// persisting a new random key (mode 0600) when the file does not exist yet.
+func LoadOrCreateKey(keyPath string) (string, error) {
+	data, err := os.ReadFile(keyPath)
+	if err == nil {
+		key := strings.TrimSpace(string(data))
+		if len(key) != keyLength {
+			return "", fmt.Errorf("%w: %s", ErrInvalidKey, keyPath)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read master key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return "", fmt.Errorf("failed to create master key directory: %w", err)
+	}
+	key := security.RandomString(keyLength)
+	if err := os.WriteFile(keyPath, []byte(key), 0600); err != nil {
+		return "", fmt.Errorf("failed to write master key: %w", err)
+	}
+	slog.Info("generated new master key", "path", keyPath)
+	return key, nil
+}
+
+func (c *Cipher) Encrypt(plain string) (string, error) {
+	return security.Encrypt([]byte(plain), c.key)
+}
+
+func (c *Cipher) Decrypt(encrypted string) (string, error) {
+	plain, err := security.Decrypt(encrypted, c.key)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
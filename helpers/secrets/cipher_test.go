@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c, err := NewCipher("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+
+	encrypted, err := c.Encrypt("s3cr3t")
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if encrypted == "s3cr3t" {
+		t.Fatal("encrypted value must differ from plain text")
+	}
+
+	plain, err := c.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	if plain != "s3cr3t" {
+		t.Fatalf("expected %q, got %q", "s3cr3t", plain)
+	}
+}
+
+func TestNewCipher_InvalidKey(t *testing.T) {
+	if _, err := NewCipher("short"); err == nil {
+		t.Fatal("expected error for short key")
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "nested", "master.key")
+
+	key, err := LoadOrCreateKey(keyPath)
+	if err != nil {
+		t.Fatalf("failed to create key: %v", err)
+	}
+	if len(key) != keyLength {
+		t.Fatalf("expected key of %d chars, got %d", keyLength, len(key))
+	}
+
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatalf("key file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	again, err := LoadOrCreateKey(keyPath)
+	if err != nil {
+		t.Fatalf("failed to load key: %v", err)
+	}
+	if again != key {
+		t.Fatal("expected the persisted key to be reused")
+	}
+}
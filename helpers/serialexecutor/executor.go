@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"slices"
 	"sync"
+	"time"
 )
 
 type SequentialExecutor struct {
@@ -15,6 +16,8 @@ type SequentialExecutor struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 	mu      sync.Mutex
+	// observer is told how long each run of a task took
+	observer func(task string, duration time.Duration)
 }
 
 func NewSequentialExecutor() *SequentialExecutor {
@@ -44,6 +47,18 @@ func (s *SequentialExecutor) Add(task *Task) error {
 	return nil
 }
 
+// SetObserver registers fn to be called after every task run. Like Add it
+// must be called before Start.
+func (s *SequentialExecutor) SetObserver(fn func(task string, duration time.Duration)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return errors.New("cannot set the observer while running")
+	}
+	s.observer = fn
+	return nil
+}
+
 func (s *SequentialExecutor) Start() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -61,6 +76,7 @@ func (s *SequentialExecutor) Start() error {
 
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	s.running = true
+	observer := s.observer
 
 	go func() {
 		for {
@@ -71,7 +87,11 @@ func (s *SequentialExecutor) Start() error {
 				if !ok {
 					return
 				}
+				start := time.Now()
 				task.Exec(s.ctx, s.queue)
+				if observer != nil {
+					observer(task.Name(), time.Since(start))
+				}
 			}
 		}
 	}()
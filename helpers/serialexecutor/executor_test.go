@@ -278,3 +278,39 @@ func TestSequentialExecutorCheckIntervalsT1_T2(t *testing.T) {
 		}
 	}
 }
+
+func TestSequentialExecutorObserver(t *testing.T) {
+	exec := NewSequentialExecutor()
+	observed := make(chan string, 2)
+	named := NewTask(func(ctx context.Context) { time.Sleep(10 * time.Millisecond) }, time.Hour, 2).WithName("named")
+	unnamed := NewTask(func(ctx context.Context) {}, time.Hour, 1)
+	exec.Add(named)
+	exec.Add(unnamed)
+
+	var durations []time.Duration
+	if err := exec.SetObserver(func(task string, d time.Duration) {
+		durations = append(durations, d)
+		observed <- task
+	}); err != nil {
+		t.Fatalf("failed to set observer: %v", err)
+	}
+	exec.Start()
+	defer exec.Stop()
+
+	for _, expected := range []string{"named", "priority_1"} {
+		select {
+		case task := <-observed:
+			if task != expected {
+				t.Fatalf("observed task %q, want %q", task, expected)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Fatalf("task %q was not observed", expected)
+		}
+	}
+	if durations[0] < 10*time.Millisecond {
+		t.Errorf("observed duration %v, want at least 10ms", durations[0])
+	}
+	if err := exec.SetObserver(nil); err == nil {
+		t.Error("SetObserver() expected an error while running")
+	}
+}
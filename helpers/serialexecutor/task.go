@@ -2,6 +2,7 @@ package serialexecutor
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -11,10 +12,34 @@ type Task struct {
 	action   TaskFunc
 	interval time.Duration
 	priority int
+	name     string
+	wakeup   <-chan struct{}
 }
 
 func NewTask(task TaskFunc, interval time.Duration, priority int) *Task {
-	return &Task{task, interval, priority}
+	return &Task{action: task, interval: interval, priority: priority}
+}
+
+// WithName sets the name the task is reported with to the executor observer.
+func (t *Task) WithName(name string) *Task {
+	t.name = name
+	return t
+}
+
+// WithWakeup requeues the task as soon as a value is received from ch
+// instead of waiting for the rest of its interval. A value sent while the
+// task runs requeues it right after that run.
+func (t *Task) WithWakeup(ch <-chan struct{}) *Task {
+	t.wakeup = ch
+	return t
+}
+
+// Name returns the task name, or its priority for unnamed tasks.
+func (t *Task) Name() string {
+	if t.name == "" {
+		return fmt.Sprintf("priority_%d", t.priority)
+	}
+	return t.name
 }
 
 func (t *Task) Exec(ctx context.Context, queue chan<- *Task) {
@@ -28,10 +53,11 @@ func (t *Task) Exec(ctx context.Context, queue chan<- *Task) {
 		case <-ctx.Done():
 			return
 		case <-timer.C:
-			select {
-			case queue <- t:
-			case <-ctx.Done():
-			}
+		case <-t.wakeup: // a nil channel never wakes the task
+		}
+		select {
+		case queue <- t:
+		case <-ctx.Done():
 		}
 	}()
 }
@@ -91,3 +91,25 @@ func TestTaskExecWithInterval(t *testing.T) {
 		t.Fatal("task should have been queued again after interval")
 	}
 }
+
+func TestTaskExecWakeup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := make(chan *Task, 1)
+	wakeup := make(chan struct{}, 1)
+	task := (&Task{
+		action:   func(ctx context.Context) {},
+		interval: time.Hour,
+	}).WithWakeup(wakeup)
+
+	task.Exec(ctx, queue)
+	wakeup <- struct{}{}
+
+	select {
+	case <-queue:
+		// ok
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("task was not re-queued on wakeup")
+	}
+}
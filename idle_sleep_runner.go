@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/serialexecutor"
+	launcher "pb_launcher/internal/launcher/domain"
+)
+
+func RegisterIdleSleeper(
+	executor *serialexecutor.SequentialExecutor,
+	launcherManager *launcher.LauncherManager,
+	config configs.Config) error {
+
+	idleSleeperTask := serialexecutor.NewTask(
+		func(ctx context.Context) {
+			if err := launcherManager.SleepIdleServices(ctx); err != nil {
+				slog.Error("idle sleeper task failed", "error", err, "task", "idleSleeper")
+			}
+		},
+		config.GetIdleCheckInterval(),
+		9997,
+	)
+
+	return executor.Add(idleSleeperTask.WithName("idleSleeper"))
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"pb_launcher/helpers/pbdata"
+)
+
+// writeArchive zips consistent copies of the pb_data databases together
+// with the uploaded files of the storage directory.
+func writeArchive(ctx context.Context, pbData string, w io.Writer) error {
+	tmpDir, err := os.MkdirTemp("", "pb-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	zw := zip.NewWriter(w)
+	for _, name := range pbdata.Databases {
+		src := filepath.Join(pbData, name)
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		dst := filepath.Join(tmpDir, name)
+		if err := pbdata.VacuumInto(ctx, src, dst); err != nil {
+			return fmt.Errorf("failed to snapshot %s: %w", name, err)
+		}
+		if err := addFile(zw, dst, name); err != nil {
+			return err
+		}
+	}
+
+	storage := filepath.Join(pbData, pbdata.StorageDir)
+	err = filepath.WalkDir(storage, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path == storage {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(pbData, path)
+		if err != nil {
+			return err
+		}
+		return addFile(zw, path, filepath.ToSlash(rel))
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func addFile(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	entry, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
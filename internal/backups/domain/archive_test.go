@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteArchive(t *testing.T) {
+	pbData := t.TempDir()
+
+	db, err := core.DefaultDBConnect(filepath.Join(pbData, "data.db"))
+	require.NoError(t, err)
+	_, err = db.NewQuery("CREATE TABLE items (name TEXT)").Execute()
+	require.NoError(t, err)
+	_, err = db.NewQuery("INSERT INTO items (name) VALUES ('one')").Execute()
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	uploads := filepath.Join(pbData, "storage", "col", "rec")
+	require.NoError(t, os.MkdirAll(uploads, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(uploads, "file.txt"), []byte("data"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(pbData, "types.d.ts"), []byte("ignored"), 0644))
+
+	var buf bytes.Buffer
+	require.NoError(t, writeArchive(context.Background(), pbData, &buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	require.ElementsMatch(t, []string{"data.db", "storage/col/rec/file.txt"}, names)
+}
+
+func TestDueBetween(t *testing.T) {
+	schedule, err := cron.NewSchedule("30 2 * * *")
+	require.NoError(t, err)
+
+	base := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+	require.True(t, dueBetween(schedule, base, base.Add(31*time.Minute)))
+	require.False(t, dueBetween(schedule, base, base.Add(29*time.Minute)))
+	// the lower bound is exclusive
+	require.False(t, dueBetween(schedule, base.Add(30*time.Minute), base.Add(40*time.Minute)))
+	// catching up is capped, a week-long gap only looks at the last day
+	require.True(t, dueBetween(schedule, base.Add(-7*24*time.Hour), base.Add(31*time.Minute)))
+}
@@ -0,0 +1,321 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/unzip"
+	"pb_launcher/internal/backups/domain/models"
+	"pb_launcher/internal/backups/domain/repositories"
+	"pb_launcher/internal/backups/domain/services"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// maxScheduleLookback bounds the minutes checked for due schedules between
+// two runs.
+const maxScheduleLookback = 24 * time.Hour
+
+type BackupManager struct {
+	repo             repositories.BackupRepository
+	storage          services.BackupStorage
+	controller       services.ServiceController
+	unzip            *unzip.Unzip
+	dataDir          string
+	defaultRetention int
+	// lastScheduleCheck is only touched from the SequentialExecutor
+	lastScheduleCheck time.Time
+}
+
+func NewBackupManager(
+	repo repositories.BackupRepository,
+	storage services.BackupStorage,
+	controller services.ServiceController,
+	unzip *unzip.Unzip,
+	c configs.Config,
+) *BackupManager {
+	return &BackupManager{
+		repo:             repo,
+		storage:          storage,
+		controller:       controller,
+		unzip:            unzip,
+		dataDir:          c.GetDataDir(),
+		defaultRetention: c.GetBackupRetention(),
+	}
+}
+
+// Run queues the scheduled backups that became due, then creates the
+// pending backups and applies the pending restores.
+func (bm *BackupManager) Run(ctx context.Context) error {
+	bm.enqueueScheduled(ctx, time.Now())
+
+	backups, err := bm.repo.PendingBackups(ctx)
+	if err != nil {
+		slog.Error("failed to get pending backups", "error", err)
+		return err
+	}
+	for _, backup := range backups {
+		if err := bm.createBackup(ctx, backup); err != nil {
+			slog.Error("backup failed", "backupID", backup.ID, "serviceID", backup.ServiceID, "error", err)
+			if markErr := bm.repo.MarkBackupError(ctx, backup.ID, err.Error()); markErr != nil {
+				slog.Error("failed to mark backup as error", "backupID", backup.ID, "error", markErr)
+			}
+			continue
+		}
+		bm.applyRetention(ctx, backup.ServiceID)
+	}
+
+	restores, err := bm.repo.PendingRestores(ctx)
+	if err != nil {
+		slog.Error("failed to get pending restores", "error", err)
+		return err
+	}
+	for _, backup := range restores {
+		if err := bm.restoreBackup(ctx, backup); err != nil {
+			slog.Error("restore failed", "backupID", backup.ID, "serviceID", backup.ServiceID, "error", err)
+			if markErr := bm.repo.MarkRestoreError(ctx, backup.ID, err.Error()); markErr != nil {
+				slog.Error("failed to mark restore as error", "backupID", backup.ID, "error", markErr)
+			}
+			continue
+		}
+		if err := bm.repo.MarkRestoreSuccess(ctx, backup.ID); err != nil {
+			slog.Error("failed to mark restore as success", "backupID", backup.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+func (bm *BackupManager) enqueueScheduled(ctx context.Context, now time.Time) {
+	from := bm.lastScheduleCheck
+	bm.lastScheduleCheck = now
+	if from.IsZero() {
+		return
+	}
+
+	schedules, err := bm.repo.Schedules(ctx)
+	if err != nil {
+		slog.Error("failed to get backup schedules", "error", err)
+		return
+	}
+	for _, s := range schedules {
+		schedule, err := cron.NewSchedule(s.Schedule)
+		if err != nil {
+			slog.Warn("invalid backup schedule", "serviceID", s.ServiceID, "schedule", s.Schedule, "error", err)
+			continue
+		}
+		if !dueBetween(schedule, from, now) {
+			continue
+		}
+		if _, err := bm.repo.CreateBackup(ctx, s.ServiceID, models.TriggerScheduled); err != nil {
+			slog.Error("failed to queue scheduled backup", "serviceID", s.ServiceID, "error", err)
+		}
+	}
+}
+
+// dueBetween reports whether the schedule matches a minute in (from, to].
+func dueBetween(schedule *cron.Schedule, from, to time.Time) bool {
+	if to.Sub(from) > maxScheduleLookback {
+		from = to.Add(-maxScheduleLookback)
+	}
+	for t := from.Truncate(time.Minute).Add(time.Minute); !t.After(to); t = t.Add(time.Minute) {
+		if schedule.IsDue(cron.NewMoment(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bm *BackupManager) dataDirs(serviceID string) (data, staging string) {
+	args := launcherdomain.NewLaunchArgs(bm.dataDir, serviceID, "")
+	return args.DataDir, path.Join(args.BaseDir, "pb_data.restore")
+}
+
+func archiveKey(backup models.Backup, now time.Time) string {
+	return fmt.Sprintf("%s/%s-%s.zip", backup.ServiceID, now.UTC().Format("20060102-150405"), backup.ID)
+}
+
+func (bm *BackupManager) createBackup(ctx context.Context, backup models.Backup) error {
+	if err := bm.repo.MarkBackupRunning(ctx, backup.ID); err != nil {
+		return err
+	}
+	return bm.archiveBackup(ctx, backup)
+}
+
+// archiveBackup stores the archive of a running backup.
+func (bm *BackupManager) archiveBackup(ctx context.Context, backup models.Backup) error {
+	pbData, _ := bm.dataDirs(backup.ServiceID)
+	if _, err := os.Stat(pbData); err != nil {
+		return fmt.Errorf("service has no data to back up: %w", err)
+	}
+
+	key := archiveKey(backup, time.Now())
+	size, err := bm.storeArchive(ctx, pbData, key)
+	if err != nil {
+		return err
+	}
+	return bm.repo.MarkBackupSuccess(ctx, backup.ID, key, size)
+}
+
+// storeArchive zips pbData into a temporary file and saves it under key.
+func (bm *BackupManager) storeArchive(ctx context.Context, pbData, key string) (int64, error) {
+	tmp, err := os.CreateTemp("", "pb-backup-*.zip")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := writeArchive(ctx, pbData, tmp); err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	size, err := bm.storage.Save(ctx, key, tmp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store archive: %w", err)
+	}
+	return size, nil
+}
+
+// ArchiveServiceData stores a last archive of the pb_data of a service
+// before it is purged from the trash and returns its key. It reports an
+// empty key when the service has no data. Archives under trash/ are not
+// subject to the backup retention.
+func (bm *BackupManager) ArchiveServiceData(ctx context.Context, serviceID string) (string, error) {
+	pbData, _ := bm.dataDirs(serviceID)
+	if _, err := os.Stat(pbData); errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	key := fmt.Sprintf("trash/%s-%s.zip", serviceID, time.Now().UTC().Format("20060102-150405"))
+	if _, err := bm.storeArchive(ctx, pbData, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (bm *BackupManager) applyRetention(ctx context.Context, serviceID string) {
+	keep, err := bm.repo.ServiceRetention(ctx, serviceID)
+	if err != nil {
+		slog.Error("failed to get backup retention", "serviceID", serviceID, "error", err)
+		return
+	}
+	if keep <= 0 {
+		keep = bm.defaultRetention
+	}
+	expired, err := bm.repo.ExpiredBackups(ctx, serviceID, keep)
+	if err != nil {
+		slog.Error("failed to get expired backups", "serviceID", serviceID, "error", err)
+		return
+	}
+	for _, backup := range expired {
+		if err := bm.repo.DeleteBackup(ctx, backup.ID); err != nil {
+			slog.Error("failed to delete expired backup", "backupID", backup.ID, "error", err)
+		}
+	}
+	bm.pruneArchives(ctx, serviceID)
+}
+
+// pruneArchives enforces the retention on the storage itself, removing the
+// archives of a service no backup record points to anymore (failed deletes,
+// records removed while the storage was unreachable).
+func (bm *BackupManager) pruneArchives(ctx context.Context, serviceID string) {
+	stored, err := bm.storage.List(ctx, serviceID+"/")
+	if err != nil {
+		slog.Error("failed to list stored archives", "serviceID", serviceID, "error", err)
+		return
+	}
+	keys, err := bm.repo.ArchiveKeys(ctx, serviceID)
+	if err != nil {
+		slog.Error("failed to get archive keys", "serviceID", serviceID, "error", err)
+		return
+	}
+	referenced := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		referenced[key] = true
+	}
+	for _, key := range stored {
+		if referenced[key] {
+			continue
+		}
+		if err := bm.storage.Delete(ctx, key); err != nil {
+			slog.Error("failed to delete unreferenced archive", "key", key, "error", err)
+		}
+	}
+}
+
+// restoreBackup extracts the archive next to pb_data and swaps the
+// directories while the instance is stopped. The replaced data is kept as
+// pb_data.before-restore until the next restore.
+func (bm *BackupManager) restoreBackup(ctx context.Context, backup models.Backup) error {
+	if err := bm.repo.MarkRestoreRunning(ctx, backup.ID); err != nil {
+		return err
+	}
+	if backup.Status != models.BackupSuccess || backup.Key == "" {
+		return errors.New("backup has no archive to restore")
+	}
+
+	pbData, staging := bm.dataDirs(backup.ServiceID)
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	archive, err := bm.fetchArchive(ctx, backup.Key)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive)
+
+	if _, err := bm.unzip.Extract(archive, staging); err != nil {
+		return err
+	}
+
+	return bm.controller.WithServiceStopped(ctx, backup.ServiceID, func() error {
+		return swapDataDir(pbData, staging)
+	})
+}
+
+// fetchArchive copies the archive to a local temp file, unzip needs random access.
+func (bm *BackupManager) fetchArchive(ctx context.Context, key string) (string, error) {
+	reader, err := bm.storage.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	tmp, err := os.CreateTemp("", "pb-restore-*.zip")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+	if _, err := io.Copy(tmp, reader); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func swapDataDir(pbData, staging string) error {
+	previous := pbData + ".before-restore"
+	if err := os.RemoveAll(previous); err != nil {
+		return err
+	}
+	if err := os.Rename(pbData, previous); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(staging, pbData); err != nil {
+		if restoreErr := os.Rename(previous, pbData); restoreErr != nil {
+			return errors.Join(err, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/internal/backups/domain/models"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+	launchermodels "pb_launcher/internal/launcher/domain/models"
+)
+
+// ActionBackup backs a service up right away from the command queue.
+const ActionBackup launchermodels.CommandAction = "backup"
+
+// BackupResult is the result of a backup command.
+type BackupResult struct {
+	Backup string `json:"backup"`
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+}
+
+// RegisterCommands adds the backup actions to the command queue.
+func (bm *BackupManager) RegisterCommands(commands *launcherdomain.CommandRegistry) {
+	commands.Register(ActionBackup, bm.backupCommand)
+}
+
+// backupCommand creates a manual backup without waiting for the backup
+// task. The record is created as running, so the task leaves it alone.
+func (bm *BackupManager) backupCommand(ctx context.Context, service launchermodels.Service, _ launchermodels.ServiceCommand) (any, error) {
+	id, err := bm.repo.StartBackup(ctx, service.ID, models.TriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := bm.repo.FindBackup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := bm.archiveBackup(ctx, *backup); err != nil {
+		if markErr := bm.repo.MarkBackupError(ctx, id, err.Error()); markErr != nil {
+			slog.Error("failed to mark backup as error", "backupID", id, "error", markErr)
+		}
+		return nil, err
+	}
+	bm.applyRetention(ctx, service.ID)
+
+	backup, err = bm.repo.FindBackup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return BackupResult{Backup: backup.ID, Key: backup.Key, Size: backup.Size}, nil
+}
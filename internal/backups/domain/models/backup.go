@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+type BackupStatus string
+type BackupTrigger string
+
+const (
+	BackupPending BackupStatus = "pending" // Waiting for the backup task
+	BackupRunning BackupStatus = "running"
+	BackupSuccess BackupStatus = "success"
+	BackupError   BackupStatus = "error"
+)
+
+const (
+	TriggerManual    BackupTrigger = "manual"
+	TriggerScheduled BackupTrigger = "scheduled"
+)
+
+type Backup struct {
+	ID        string
+	ServiceID string
+	Status    BackupStatus
+	Trigger   BackupTrigger
+	Key       string // archive location inside the backup storage
+	Size      int64
+	Created   time.Time
+}
+
+type BackupSchedule struct {
+	ServiceID string
+	Schedule  string // cron expression
+	Retention int    // 0 means the launcher default
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"context"
+	"pb_launcher/internal/backups/domain/models"
+)
+
+type BackupRepository interface {
+	// CreateBackup queues a backup of the service and returns its id.
+	CreateBackup(ctx context.Context, serviceID string, trigger models.BackupTrigger) (string, error)
+	// StartBackup records a backup that is already running, out of reach of
+	// the backup task, and returns its id.
+	StartBackup(ctx context.Context, serviceID string, trigger models.BackupTrigger) (string, error)
+	PendingBackups(ctx context.Context) ([]models.Backup, error)
+	PendingRestores(ctx context.Context) ([]models.Backup, error)
+	FindBackup(ctx context.Context, id string) (*models.Backup, error)
+
+	MarkBackupRunning(ctx context.Context, id string) error
+	MarkBackupSuccess(ctx context.Context, id, key string, size int64) error
+	MarkBackupError(ctx context.Context, id string, errorMessage string) error
+
+	MarkRestoreRunning(ctx context.Context, id string) error
+	MarkRestoreSuccess(ctx context.Context, id string) error
+	MarkRestoreError(ctx context.Context, id string, errorMessage string) error
+
+	// Schedules returns the backup settings of every live service with a schedule.
+	Schedules(ctx context.Context) ([]models.BackupSchedule, error)
+	ServiceRetention(ctx context.Context, serviceID string) (int, error)
+	// ExpiredBackups returns the successful backups of a service beyond the newest keep ones.
+	ExpiredBackups(ctx context.Context, serviceID string, keep int) ([]models.Backup, error)
+	// ArchiveKeys returns the storage keys referenced by the backups of a service.
+	ArchiveKeys(ctx context.Context, serviceID string) ([]string, error)
+	// DeleteBackup removes the record; the archive is removed by the record hooks.
+	DeleteBackup(ctx context.Context, id string) error
+}
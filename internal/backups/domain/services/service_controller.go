@@ -0,0 +1,10 @@
+package services
+
+import "context"
+
+// ServiceController lets backups touch the data of a stopped instance.
+type ServiceController interface {
+	// WithServiceStopped stops the service process when it runs, calls fn
+	// and starts the service again.
+	WithServiceStopped(ctx context.Context, serviceID string, fn func() error) error
+}
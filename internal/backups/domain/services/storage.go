@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrArchiveNotFound = errors.New("backup archive not found")
+
+// BackupStorage keeps backup archives addressed by a slash separated key.
+type BackupStorage interface {
+	// Save stores the archive read from reader under key and returns its size.
+	Save(ctx context.Context, key string, reader io.Reader) (int64, error)
+
+	// Open returns the archive stored under key or ErrArchiveNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the archive; missing archives are not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys of the stored archives starting with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
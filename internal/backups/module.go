@@ -0,0 +1,27 @@
+package backups
+
+import (
+	"pb_launcher/internal/backups/domain"
+	"pb_launcher/internal/backups/domain/repositories"
+	"pb_launcher/internal/backups/domain/services"
+	"pb_launcher/internal/backups/repos"
+	infra_services "pb_launcher/internal/backups/services"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("backups",
+	fx.Provide(
+		fx.Annotate(
+			repos.NewBackupRepository,
+			fx.As(new(repositories.BackupRepository)),
+		),
+	),
+	fx.Provide(
+		infra_services.NewBackupStorage,
+		func(lm *launcherdomain.LauncherManager) services.ServiceController { return lm },
+	),
+	fx.Provide(domain.NewBackupManager),
+	fx.Invoke((*domain.BackupManager).RegisterCommands),
+)
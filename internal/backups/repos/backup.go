@@ -0,0 +1,223 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"pb_launcher/collections"
+	"pb_launcher/internal/backups/domain/models"
+	"pb_launcher/internal/backups/domain/repositories"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type BackupRepository struct {
+	app *pocketbase.PocketBase
+}
+
+var _ repositories.BackupRepository = (*BackupRepository)(nil)
+
+func NewBackupRepository(app *pocketbase.PocketBase) *BackupRepository {
+	return &BackupRepository{app: app}
+}
+
+func toBackup(r *core.Record) models.Backup {
+	return models.Backup{
+		ID:        r.Id,
+		ServiceID: r.GetString("service"),
+		Status:    models.BackupStatus(r.GetString("status")),
+		Trigger:   models.BackupTrigger(r.GetString("trigger")),
+		Key:       r.GetString("key"),
+		Size:      int64(r.GetInt("size")),
+		Created:   r.GetDateTime("created").Time(),
+	}
+}
+
+// CreateBackup implements repositories.BackupRepository.
+func (b *BackupRepository) CreateBackup(ctx context.Context, serviceID string, trigger models.BackupTrigger) (string, error) {
+	return b.insertBackup(serviceID, trigger, models.BackupPending)
+}
+
+// StartBackup implements repositories.BackupRepository.
+func (b *BackupRepository) StartBackup(ctx context.Context, serviceID string, trigger models.BackupTrigger) (string, error) {
+	return b.insertBackup(serviceID, trigger, models.BackupRunning)
+}
+
+func (b *BackupRepository) insertBackup(serviceID string, trigger models.BackupTrigger, status models.BackupStatus) (string, error) {
+	collection, err := b.app.FindCachedCollectionByNameOrId(collections.ServiceBackups)
+	if err != nil {
+		return "", err
+	}
+	record := core.NewRecord(collection)
+	record.Set("service", serviceID)
+	record.Set("status", string(status))
+	record.Set("trigger", string(trigger))
+	if err := b.app.Save(record); err != nil {
+		return "", err
+	}
+	return record.Id, nil
+}
+
+func (b *BackupRepository) findBackups(where dbx.Expression) ([]models.Backup, error) {
+	var records []*core.Record
+	err := b.app.RecordQuery(collections.ServiceBackups).
+		AndWhere(where).
+		OrderBy("created").
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+	return toBackups(records), nil
+}
+
+func toBackups(records []*core.Record) []models.Backup {
+	backups := make([]models.Backup, 0, len(records))
+	for _, r := range records {
+		backups = append(backups, toBackup(r))
+	}
+	return backups
+}
+
+// PendingBackups implements repositories.BackupRepository.
+func (b *BackupRepository) PendingBackups(ctx context.Context) ([]models.Backup, error) {
+	return b.findBackups(dbx.HashExp{"status": string(models.BackupPending)})
+}
+
+// PendingRestores implements repositories.BackupRepository.
+func (b *BackupRepository) PendingRestores(ctx context.Context) ([]models.Backup, error) {
+	return b.findBackups(dbx.HashExp{"restore_status": "pending"})
+}
+
+// FindBackup implements repositories.BackupRepository.
+func (b *BackupRepository) FindBackup(ctx context.Context, id string) (*models.Backup, error) {
+	record, err := b.app.FindRecordById(collections.ServiceBackups, id)
+	if err != nil {
+		return nil, err
+	}
+	backup := toBackup(record)
+	return &backup, nil
+}
+
+func (b *BackupRepository) update(id string, fields map[string]any) error {
+	record, err := b.app.FindRecordById(collections.ServiceBackups, id)
+	if err != nil {
+		return err
+	}
+	for k, v := range fields {
+		record.Set(k, v)
+	}
+	return b.app.Save(record)
+}
+
+// MarkBackupRunning implements repositories.BackupRepository.
+func (b *BackupRepository) MarkBackupRunning(ctx context.Context, id string) error {
+	return b.update(id, map[string]any{"status": string(models.BackupRunning)})
+}
+
+// MarkBackupSuccess implements repositories.BackupRepository.
+func (b *BackupRepository) MarkBackupSuccess(ctx context.Context, id, key string, size int64) error {
+	return b.update(id, map[string]any{
+		"status":        string(models.BackupSuccess),
+		"key":           key,
+		"size":          size,
+		"error_message": "",
+		"completed":     time.Now(),
+	})
+}
+
+// MarkBackupError implements repositories.BackupRepository.
+func (b *BackupRepository) MarkBackupError(ctx context.Context, id string, errorMessage string) error {
+	return b.update(id, map[string]any{
+		"status":        string(models.BackupError),
+		"error_message": errorMessage,
+		"completed":     time.Now(),
+	})
+}
+
+// MarkRestoreRunning implements repositories.BackupRepository.
+func (b *BackupRepository) MarkRestoreRunning(ctx context.Context, id string) error {
+	return b.update(id, map[string]any{"restore_status": "running", "restore_error": ""})
+}
+
+// MarkRestoreSuccess implements repositories.BackupRepository.
+func (b *BackupRepository) MarkRestoreSuccess(ctx context.Context, id string) error {
+	return b.update(id, map[string]any{"restore_status": "success"})
+}
+
+// MarkRestoreError implements repositories.BackupRepository.
+func (b *BackupRepository) MarkRestoreError(ctx context.Context, id string, errorMessage string) error {
+	return b.update(id, map[string]any{"restore_status": "error", "restore_error": errorMessage})
+}
+
+// Schedules implements repositories.BackupRepository.
+func (b *BackupRepository) Schedules(ctx context.Context) ([]models.BackupSchedule, error) {
+	records, err := b.app.FindAllRecords(collections.Services,
+		dbx.NewExp("backup_schedule != ''"),
+		dbx.NewExp("(deleted IS NULL OR deleted = '')"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	schedules := make([]models.BackupSchedule, 0, len(records))
+	for _, r := range records {
+		schedules = append(schedules, models.BackupSchedule{
+			ServiceID: r.Id,
+			Schedule:  r.GetString("backup_schedule"),
+			Retention: r.GetInt("backup_retention"),
+		})
+	}
+	return schedules, nil
+}
+
+// ServiceRetention implements repositories.BackupRepository.
+func (b *BackupRepository) ServiceRetention(ctx context.Context, serviceID string) (int, error) {
+	record, err := b.app.FindRecordById(collections.Services, serviceID)
+	if err != nil {
+		return 0, err
+	}
+	return record.GetInt("backup_retention"), nil
+}
+
+// ExpiredBackups implements repositories.BackupRepository.
+func (b *BackupRepository) ExpiredBackups(ctx context.Context, serviceID string, keep int) ([]models.Backup, error) {
+	if keep < 0 {
+		return nil, errors.New("retention must not be negative")
+	}
+	var records []*core.Record
+	err := b.app.RecordQuery(collections.ServiceBackups).
+		AndWhere(dbx.HashExp{"service": serviceID, "status": string(models.BackupSuccess)}).
+		OrderBy("created DESC").
+		Offset(int64(keep)).
+		Limit(-1).
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+	return toBackups(records), nil
+}
+
+// ArchiveKeys implements repositories.BackupRepository.
+func (b *BackupRepository) ArchiveKeys(ctx context.Context, serviceID string) ([]string, error) {
+	var keys []string
+	err := b.app.DB().
+		Select("key").
+		From(collections.ServiceBackups).
+		Where(dbx.HashExp{"service": serviceID}).
+		AndWhere(dbx.NewExp("key != ''")).
+		Column(&keys)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// DeleteBackup implements repositories.BackupRepository.
+func (b *BackupRepository) DeleteBackup(ctx context.Context, id string) error {
+	record, err := b.app.FindRecordById(collections.ServiceBackups, id)
+	if err != nil {
+		return err
+	}
+	return b.app.Delete(record)
+}
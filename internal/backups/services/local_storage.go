@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"pb_launcher/configs"
+	"pb_launcher/internal/backups/domain/services"
+	"strings"
+)
+
+// LocalBackupStorage keeps archives as files under the backup directory.
+type LocalBackupStorage struct {
+	baseDir string
+}
+
+var _ services.BackupStorage = (*LocalBackupStorage)(nil)
+
+func NewLocalBackupStorage(c configs.Config) *LocalBackupStorage {
+	return &LocalBackupStorage{baseDir: c.GetBackupDir()}
+}
+
+func (s *LocalBackupStorage) resolve(key string) (string, error) {
+	target := filepath.Join(s.baseDir, filepath.FromSlash(key))
+	rel, err := filepath.Rel(s.baseDir, target)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("invalid backup key: %s", key)
+	}
+	return target, nil
+}
+
+// Save writes to a temporary file first so a failed copy never leaves a
+// truncated archive behind.
+func (s *LocalBackupStorage) Save(ctx context.Context, key string, reader io.Reader) (int64, error) {
+	target, err := s.resolve(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, reader)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *LocalBackupStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	target, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, services.ErrArchiveNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *LocalBackupStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// drop the service directory once its last archive is gone
+	os.Remove(filepath.Dir(target))
+	return nil
+}
+
+func (s *LocalBackupStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(s.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path == s.baseDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.baseDir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
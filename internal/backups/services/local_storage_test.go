@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"pb_launcher/internal/backups/domain/services"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBackupStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := &LocalBackupStorage{baseDir: t.TempDir()}
+
+	size, err := storage.Save(ctx, "svc/backup.zip", strings.NewReader("archive"))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), size)
+
+	reader, err := storage.Open(ctx, "svc/backup.zip")
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, "archive", string(content))
+
+	require.NoError(t, storage.Delete(ctx, "svc/backup.zip"))
+	_, err = storage.Open(ctx, "svc/backup.zip")
+	require.True(t, errors.Is(err, services.ErrArchiveNotFound))
+}
+
+func TestLocalBackupStorage_RejectsEscapingKeys(t *testing.T) {
+	storage := &LocalBackupStorage{baseDir: t.TempDir()}
+	_, err := storage.Save(context.Background(), "../outside.zip", strings.NewReader("x"))
+	require.Error(t, err)
+}
+
+func TestLocalBackupStorage_List(t *testing.T) {
+	ctx := context.Background()
+	storage := &LocalBackupStorage{baseDir: t.TempDir()}
+
+	keys, err := storage.List(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	for _, key := range []string{"a/1.zip", "a/2.zip", "b/1.zip"} {
+		_, err := storage.Save(ctx, key, strings.NewReader(key))
+		require.NoError(t, err)
+	}
+
+	keys, err = storage.List(ctx, "a/")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"a/1.zip", "a/2.zip"}, keys)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/s3client"
+	"pb_launcher/internal/backups/domain/services"
+	"strings"
+)
+
+// S3BackupStorage keeps archives in an S3 compatible bucket, under the
+// configured prefix.
+type S3BackupStorage struct {
+	client *s3client.Client
+	prefix string
+}
+
+var _ services.BackupStorage = (*S3BackupStorage)(nil)
+
+func NewS3BackupStorage(c configs.Config) *S3BackupStorage {
+	cfg := c.GetBackupS3Config()
+	return &S3BackupStorage{
+		client: &s3client.Client{
+			Endpoint:  cfg.Endpoint,
+			Region:    cfg.Region,
+			Bucket:    cfg.Bucket,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			PathStyle: cfg.ForcePathStyle,
+			PartSize:  cfg.PartSize,
+		},
+		prefix: cfg.Prefix,
+	}
+}
+
+// NewBackupStorage returns the storage selected by backups.target.
+func NewBackupStorage(c configs.Config) services.BackupStorage {
+	if c.GetBackupTarget() == configs.BackupTargetS3 {
+		return NewS3BackupStorage(c)
+	}
+	return NewLocalBackupStorage(c)
+}
+
+func (s *S3BackupStorage) objectKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+func (s *S3BackupStorage) Save(ctx context.Context, key string, reader io.Reader) (int64, error) {
+	return s.client.Upload(ctx, s.objectKey(key), reader)
+}
+
+func (s *S3BackupStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := s.client.GetObject(ctx, s.objectKey(key))
+	if errors.Is(err, s3client.ErrNotFound) {
+		return nil, services.ErrArchiveNotFound
+	}
+	return reader, err
+}
+
+func (s *S3BackupStorage) Delete(ctx context.Context, key string) error {
+	err := s.client.DeleteObject(ctx, s.objectKey(key))
+	if errors.Is(err, s3client.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *S3BackupStorage) List(ctx context.Context, prefix string) ([]string, error) {
+	objects, err := s.client.ListObjects(ctx, s.objectKey(prefix))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if s.prefix == "" {
+			keys = append(keys, object.Key)
+			continue
+		}
+		keys = append(keys, strings.TrimPrefix(object.Key, s.prefix+"/"))
+	}
+	return keys, nil
+}
@@ -6,10 +6,12 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"pb_launcher/helpers/promexport"
 	"pb_launcher/helpers/unzip"
 	"pb_launcher/internal/download/domain/dtos"
 	"pb_launcher/internal/download/domain/repositories"
 	"pb_launcher/internal/download/domain/services"
+	"time"
 )
 
 type DownloadUsecase struct {
@@ -17,6 +19,9 @@ type DownloadUsecase struct {
 	repository      repositories.ReleaseRepository
 	artifactStorage services.RepositoryArtifactStorage
 	unzip           *unzip.Unzip
+	//
+	syncResults     *promexport.CounterVec
+	lastSyncSuccess *promexport.GaugeVec
 }
 
 func NewDownloadUsecase(
@@ -24,12 +29,17 @@ func NewDownloadUsecase(
 	repository repositories.ReleaseRepository,
 	artifactStorage services.RepositoryArtifactStorage,
 	unzip *unzip.Unzip,
+	registry *promexport.Registry,
 ) *DownloadUsecase {
 	return &DownloadUsecase{
 		service:         service,
 		repository:      repository,
 		artifactStorage: artifactStorage,
 		unzip:           unzip,
+		syncResults: registry.NewCounterVec("pb_launcher_release_sync_total",
+			"Release syncs per repository and result (success or failure).", "repository", "result"),
+		lastSyncSuccess: registry.NewGaugeVec("pb_launcher_release_sync_last_success_timestamp_seconds",
+			"Unix time of the last successful release sync per repository.", "repository"),
 	}
 }
 
@@ -143,35 +153,42 @@ func (uc *DownloadUsecase) Run(ctx context.Context) error {
 	}
 	var combinedErr error
 	for _, repo := range repositories {
-
-		availableReleases, err := uc.service.FetchReleases(ctx, repo)
+		err := uc.syncRepository(ctx, repo)
 		if err != nil {
-			slog.Error("failed to fetch releases", "repository_id", repo.ID, "error", err)
+			uc.syncResults.Inc(repo.Repo, "failure")
 			combinedErr = errors.Join(combinedErr, err)
 			continue
 		}
+		uc.syncResults.Inc(repo.Repo, "success")
+		uc.lastSyncSuccess.Set(float64(time.Now().Unix()), repo.Repo)
+	}
+	return combinedErr
+}
 
-		existingReleases, err := uc.repository.ListReleases(ctx, repo.ID)
-		if err != nil {
-			slog.Error("failed to list existing releases", "repository_id", repo.ID, "error", err)
-			combinedErr = errors.Join(combinedErr, err)
-			continue
-		}
+func (uc *DownloadUsecase) syncRepository(ctx context.Context, repo dtos.Repository) error {
+	availableReleases, err := uc.service.FetchReleases(ctx, repo)
+	if err != nil {
+		slog.Error("failed to fetch releases", "repository_id", repo.ID, "error", err)
+		return err
+	}
 
-		diff := uc.DiffReleases(availableReleases, existingReleases)
-		if len(diff) > 0 {
-			if err := uc.repository.SaveReleases(ctx, diff); err != nil {
-				slog.Error("failed to save new releases", "repository_id", repo.ID, "error", err)
-				combinedErr = errors.Join(combinedErr, err)
-				continue
-			}
-		}
+	existingReleases, err := uc.repository.ListReleases(ctx, repo.ID)
+	if err != nil {
+		slog.Error("failed to list existing releases", "repository_id", repo.ID, "error", err)
+		return err
+	}
 
-		if err := uc.resolveMissingReleases(ctx, repo); err != nil {
-			slog.Error("failed to resolve missing releases", "repository_id", repo.ID, "error", err)
-			combinedErr = errors.Join(combinedErr, err)
-			continue
+	diff := uc.DiffReleases(availableReleases, existingReleases)
+	if len(diff) > 0 {
+		if err := uc.repository.SaveReleases(ctx, diff); err != nil {
+			slog.Error("failed to save new releases", "repository_id", repo.ID, "error", err)
+			return err
 		}
 	}
-	return combinedErr
+
+	if err := uc.resolveMissingReleases(ctx, repo); err != nil {
+		slog.Error("failed to resolve missing releases", "repository_id", repo.ID, "error", err)
+		return err
+	}
+	return nil
 }
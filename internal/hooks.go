@@ -10,8 +10,21 @@ var Module = fx.Module("hooks",
 	fx.Invoke(hooks.RegisterAdminExistsRoute),
 	fx.Invoke(hooks.RegisterServiceLogsRoute),
 	fx.Invoke(hooks.RegisterUpsertServiceSuperuserRoute),
+	fx.Invoke(hooks.RegisterResetSuperuserPasswordRoute),
 	fx.Invoke(hooks.AddServiceHooks),
 	fx.Invoke(hooks.AddProxyEntriesHooks),
 	fx.Invoke(hooks.AddServiceDomainsHooks),
 	fx.Invoke(hooks.AddComandHooks),
+	fx.Invoke(hooks.AddServiceEnvHooks),
+	fx.Invoke(hooks.AddArgsTemplateHooks),
+	fx.Invoke(hooks.AddServiceBackupsHooks),
+	fx.Invoke(hooks.AddLauncherBackupsHooks),
+	fx.Invoke(hooks.RegisterServiceCloneRoute),
+	fx.Invoke(hooks.RegisterServiceTrashRoutes),
+	fx.Invoke(hooks.RegisterRecoveryProgressRoute),
+	fx.Invoke(hooks.RegisterServiceFilesRoutes),
+	fx.Invoke(hooks.AddServiceSchedulesHooks),
+	fx.Invoke(hooks.RegisterServiceMetricsRoute),
+	fx.Invoke(hooks.AddServiceEventHooks),
+	fx.Invoke(hooks.RegisterServiceUptimeRoutes),
 )
@@ -0,0 +1,22 @@
+package hooks
+
+import (
+	"pb_launcher/collections"
+	launcher "pb_launcher/internal/launcher/domain"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func AddArgsTemplateHooks(app *pocketbase.PocketBase) {
+	app.OnRecordValidate(collections.Services, collections.Repositories).
+		BindFunc(func(e *core.RecordEvent) error {
+			if err := launcher.ValidateArgsTemplate(e.Record.GetString("args_template")); err != nil {
+				return validation.Errors{
+					"args_template": validation.NewError("validation_invalid_args_template", err.Error()),
+				}
+			}
+			return e.Next()
+		})
+}
@@ -1,18 +1,242 @@
 package hooks
 
 import (
+	"encoding/json"
 	"pb_launcher/collections"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+	"pb_launcher/internal/launcher/domain/models"
+	"reflect"
+	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
 )
 
-func AddComandHooks(app *pocketbase.PocketBase) {
+func AddComandHooks(app *pocketbase.PocketBase, registry *launcherdomain.CommandRegistry, signal *launcherdomain.CommandSignal) {
 	app.OnRecordCreateRequest(collections.ServicesComands).
 		BindFunc(func(e *core.RecordRequestEvent) error {
 			e.Record.Set("status", "pending")
 			e.Record.Set("error_message", nil)
 			e.Record.Set("executed", nil)
+			e.Record.Set("attempts", 0)
+			e.Record.Set("result", nil)
+			e.Record.Set("cause", string(models.CauseUser))
+
+			action := models.CommandAction(e.Record.GetString("action"))
+			if _, ok := registry.Handler(action); !ok {
+				return validation.Errors{
+					"action": validation.NewError("validation_unknown_action", "unknown action"),
+				}
+			}
+			if action != models.ActionUpgrade {
+				e.Record.Set("release", nil)
+			} else if err := validateUpgradeRelease(e.App, e.Record); err != nil {
+				return err
+			}
+			if action != models.ActionClone {
+				e.Record.Set("source", nil)
+			}
+			switch action {
+			case models.ActionClone:
+				if err := validateClone(e.App, e.Record, e.Auth); err != nil {
+					return err
+				}
+			case models.ActionResetSuperuser:
+				if err := validateResetSuperuser(e.Record, e.Auth); err != nil {
+					return err
+				}
+			case models.ActionStop, models.ActionStart, models.ActionRestart,
+				models.ActionReset, models.ActionUpgrade, models.ActionVacuum:
+				e.Record.Set("options", nil)
+			}
+			return e.Next()
+		})
+
+	// identical pending commands collapse into the queued one, whatever
+	// created them; the caller gets the queued record back
+	app.OnRecordCreate(collections.ServicesComands).
+		BindFunc(func(e *core.RecordEvent) error {
+			if e.Record.GetString("status") != "pending" {
+				return e.Next()
+			}
+			queued, err := findQueuedDuplicate(e.App, e.Record)
+			if err != nil {
+				return err
+			}
+			if queued == nil {
+				return e.Next()
+			}
+			// SetRaw, Set ignores the autodate fields
+			for name, value := range queued.FieldsData() {
+				e.Record.SetRaw(name, value)
+			}
+			e.Record.MarkAsNotNew()
+			return nil
+		})
+
+	// wakes the launcher runner instead of leaving the command to the next
+	// command_check_interval, whoever queued it
+	app.OnRecordAfterCreateSuccess(collections.ServicesComands).
+		BindFunc(func(e *core.RecordEvent) error {
+			signal.Notify()
 			return e.Next()
 		})
+
+	// users may only cancel commands that did not run yet
+	app.OnRecordUpdateRequest(collections.ServicesComands).
+		BindFunc(func(e *core.RecordRequestEvent) error {
+			// the launcher may have claimed it since the request loaded it
+			current, err := e.App.FindRecordById(collections.ServicesComands, e.Record.Id)
+			if err != nil {
+				return err
+			}
+			if e.Record.GetString("status") != "cancelled" || current.GetString("status") != "pending" {
+				return validation.Errors{
+					"status": validation.NewError("validation_not_cancellable",
+						"only pending commands can be cancelled"),
+				}
+			}
+			e.Record.Load(current.FieldsData())
+			e.Record.Set("status", "cancelled")
+			e.Record.Set("executed", time.Now())
+			return e.Next()
+		})
+}
+
+// findQueuedDuplicate returns the pending command of the same service that
+// does the same as comand, if any.
+func findQueuedDuplicate(app core.App, comand *core.Record) (*core.Record, error) {
+	pending, err := app.FindAllRecords(collections.ServicesComands, dbx.HashExp{
+		"service": comand.GetString("service"),
+		"action":  comand.GetString("action"),
+		"status":  "pending",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, queued := range pending {
+		if queued.GetString("release") == comand.GetString("release") &&
+			queued.GetString("source") == comand.GetString("source") &&
+			queued.GetDateTime("not_before").Equal(comand.GetDateTime("not_before")) &&
+			sameJSON(queued.GetString("options"), comand.GetString("options")) {
+			return queued, nil
+		}
+	}
+	return nil, nil
+}
+
+// sameJSON compares two JSON documents regardless of formatting; empty and
+// null documents are equal.
+func sameJSON(a, b string) bool {
+	var va, vb any
+	if a != "" {
+		if err := json.Unmarshal([]byte(a), &va); err != nil {
+			return a == b
+		}
+	}
+	if b != "" {
+		if err := json.Unmarshal([]byte(b), &vb); err != nil {
+			return a == b
+		}
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// validateUpgradeRelease only allows upgrades to another release of the
+// repository the service already runs.
+func validateUpgradeRelease(app core.App, comand *core.Record) error {
+	releaseID := comand.GetString("release")
+	if releaseID == "" {
+		return validation.Errors{
+			"release": validation.NewError("validation_required", "upgrade requires a target release"),
+		}
+	}
+	service, err := app.FindRecordById(collections.Services, comand.GetString("service"))
+	if err != nil {
+		return err
+	}
+	current, err := app.FindRecordById(collections.Releases, service.GetString("release"))
+	if err != nil {
+		return err
+	}
+	target, err := app.FindRecordById(collections.Releases, releaseID)
+	if err != nil {
+		return validation.Errors{
+			"release": validation.NewError("validation_invalid_release", "release not found"),
+		}
+	}
+	if target.Id == current.Id {
+		return validation.Errors{
+			"release": validation.NewError("validation_same_release", "service already runs this release"),
+		}
+	}
+	if target.GetString("repository") != current.GetString("repository") {
+		return validation.Errors{
+			"release": validation.NewError("validation_other_repository",
+				"release belongs to another repository"),
+		}
+	}
+	return nil
+}
+
+// validateClone only allows cloning a live service into a service that was
+// never started. The rotated superuser gets the email of the requester.
+func validateClone(app core.App, comand *core.Record, auth *core.Record) error {
+	target, err := app.FindRecordById(collections.Services, comand.GetString("service"))
+	if err != nil {
+		return err
+	}
+	if target.GetString("status") != "idle" {
+		return validation.Errors{
+			"service": validation.NewError("validation_not_idle", "only new services can be cloned into"),
+		}
+	}
+	source, err := app.FindRecordById(collections.Services, comand.GetString("source"))
+	if err != nil || source.Id == target.Id || !source.GetDateTime("deleted").IsZero() {
+		return validation.Errors{
+			"source": validation.NewError("validation_invalid_source", "source service not found"),
+		}
+	}
+
+	var options struct {
+		DropAuthRecords bool `json:"drop_auth_records"`
+		RotateSuperuser bool `json:"rotate_superuser"`
+	}
+	if comand.GetString("options") != "" {
+		if err := comand.UnmarshalJSONField("options", &options); err != nil {
+			return validation.Errors{
+				"options": validation.NewError("validation_invalid_options", err.Error()),
+			}
+		}
+	}
+	normalized := map[string]any{
+		"drop_auth_records": options.DropAuthRecords,
+		"rotate_superuser":  options.RotateSuperuser,
+	}
+	if options.RotateSuperuser {
+		if auth == nil || auth.GetString("email") == "" {
+			return validation.Errors{
+				"options": validation.NewError("validation_missing_email",
+					"rotating the superuser requires an email on the auth record"),
+			}
+		}
+		normalized["superuser_email"] = auth.GetString("email")
+	}
+	comand.Set("options", normalized)
+	return nil
+}
+
+// validateResetSuperuser resets the superuser of the requester, the only
+// one whose new password is meant for whoever reads the result.
+func validateResetSuperuser(comand *core.Record, auth *core.Record) error {
+	if auth == nil || auth.GetString("email") == "" {
+		return validation.Errors{
+			"options": validation.NewError("validation_missing_email",
+				"resetting the superuser requires an email on the auth record"),
+		}
+	}
+	comand.Set("options", map[string]any{"superuser_email": auth.GetString("email")})
+	return nil
 }
@@ -0,0 +1,41 @@
+package hooks
+
+import (
+	"log/slog"
+	"pb_launcher/configs"
+	"strings"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// AddLauncherBackupsHooks points the launcher's own PocketBase backups to
+// the S3 backup target when backups.s3.launcher_backups is enabled. The
+// settings are applied on every start so the config file stays the source
+// of truth.
+func AddLauncherBackupsHooks(app *pocketbase.PocketBase, c configs.Config) {
+	cfg := c.GetBackupS3Config()
+	if c.GetBackupTarget() != configs.BackupTargetS3 || !cfg.LauncherBackups {
+		return
+	}
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		endpoint := cfg.Endpoint
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+		settings := se.App.Settings()
+		settings.Backups.S3 = core.S3Config{
+			Enabled:        true,
+			Bucket:         cfg.Bucket,
+			Region:         cfg.Region,
+			Endpoint:       endpoint,
+			AccessKey:      cfg.AccessKey,
+			Secret:         cfg.SecretKey,
+			ForcePathStyle: cfg.ForcePathStyle,
+		}
+		if err := se.App.Save(settings); err != nil {
+			slog.Error("failed to configure launcher backups storage", "error", err)
+		}
+		return se.Next()
+	})
+}
@@ -0,0 +1,59 @@
+package hooks
+
+import (
+	"net/http"
+	"time"
+
+	launcher "pb_launcher/internal/launcher/domain"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type recoveryFailureResponse struct {
+	ServiceID string `json:"service_id"`
+	Error     string `json:"error"`
+}
+
+type recoveryProgressResponse struct {
+	Running  bool                      `json:"running"`
+	Total    int                       `json:"total"`
+	Done     int                       `json:"done"`
+	Failures []recoveryFailureResponse `json:"failures"`
+	Started  *time.Time                `json:"started"`
+	Finished *time.Time                `json:"finished"`
+}
+
+// RegisterRecoveryProgressRoute reports how far the launcher got restoring
+// the services that were running before it stopped.
+func RegisterRecoveryProgressRoute(app *pocketbase.PocketBase, launcherManager *launcher.LauncherManager) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/x-api/services/recovery", func(re *core.RequestEvent) error {
+			progress := launcherManager.RecoveryProgress()
+			response := recoveryProgressResponse{
+				Running:  progress.Running,
+				Total:    progress.Total,
+				Done:     progress.Done,
+				Failures: make([]recoveryFailureResponse, 0, len(progress.Failures)),
+				Started:  optionalTime(progress.Started),
+				Finished: optionalTime(progress.Finished),
+			}
+			for _, failure := range progress.Failures {
+				response.Failures = append(response.Failures, recoveryFailureResponse{
+					ServiceID: failure.ServiceID,
+					Error:     failure.Error,
+				})
+			}
+			return re.JSON(http.StatusOK, response)
+		}).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
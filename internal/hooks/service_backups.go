@@ -0,0 +1,121 @@
+package hooks
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"pb_launcher/collections"
+	"pb_launcher/internal/backups/domain/services"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// AddServiceBackupsHooks queues on-demand backups created through the
+// collection API and removes archives together with their records. The
+// backups themselves run on the SequentialExecutor.
+func AddServiceBackupsHooks(app *pocketbase.PocketBase, storage services.BackupStorage) {
+	app.OnRecordValidate(collections.Services).BindFunc(func(e *core.RecordEvent) error {
+		schedule := e.Record.GetString("backup_schedule")
+		if schedule == "" {
+			return e.Next()
+		}
+		if _, err := cron.NewSchedule(schedule); err != nil {
+			return validation.Errors{
+				"backup_schedule": validation.NewError("validation_invalid_cron", err.Error()),
+			}
+		}
+		return e.Next()
+	})
+
+	app.OnRecordCreateRequest(collections.ServiceBackups).
+		BindFunc(func(e *core.RecordRequestEvent) error {
+			service, err := e.App.FindRecordById(collections.Services, e.Record.GetString("service"))
+			if err != nil || !service.GetDateTime("deleted").IsZero() {
+				return validation.Errors{
+					"service": validation.NewError("validation_invalid_service", "service not found"),
+				}
+			}
+			e.Record.Set("status", "pending")
+			e.Record.Set("trigger", "manual")
+			e.Record.Set("key", "")
+			e.Record.Set("size", 0)
+			e.Record.Set("error_message", "")
+			e.Record.Set("restore_status", "")
+			e.Record.Set("restore_error", "")
+			e.Record.Set("completed", nil)
+			return e.Next()
+		})
+
+	app.OnRecordAfterDeleteSuccess(collections.ServiceBackups).
+		BindFunc(func(e *core.RecordEvent) error {
+			if key := e.Record.GetString("key"); key != "" {
+				if err := storage.Delete(e.Context, key); err != nil {
+					slog.Error("failed to delete backup archive", "backupID", e.Record.Id, "key", key, "error", err)
+				}
+			}
+			return e.Next()
+		})
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/x-api/service/backups/{backup_id}/restore", handleRestoreBackup).
+			Bind(apis.RequireAuth())
+		se.Router.GET("/x-api/service/backups/{backup_id}/download", handleDownloadBackup(storage)).
+			Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
+
+func handleRestoreBackup(re *core.RequestEvent) error {
+	record, err := re.App.FindRecordById(collections.ServiceBackups, re.Request.PathValue("backup_id"))
+	if err != nil {
+		return re.NotFoundError("backup not found", err)
+	}
+	if record.GetString("status") != "success" {
+		return re.BadRequestError("only successful backups can be restored", nil)
+	}
+	switch record.GetString("restore_status") {
+	case "pending", "running":
+		return re.BadRequestError("a restore of this backup is already in progress", nil)
+	}
+
+	record.Set("restore_status", "pending")
+	record.Set("restore_error", "")
+	if err := re.App.Save(record); err != nil {
+		return re.InternalServerError("failed to queue restore", err)
+	}
+	return re.JSON(http.StatusAccepted, map[string]string{"restore_status": "pending"})
+}
+
+func handleDownloadBackup(storage services.BackupStorage) func(re *core.RequestEvent) error {
+	return func(re *core.RequestEvent) error {
+		record, err := re.App.FindRecordById(collections.ServiceBackups, re.Request.PathValue("backup_id"))
+		if err != nil {
+			return re.NotFoundError("backup not found", err)
+		}
+		key := record.GetString("key")
+		if record.GetString("status") != "success" || key == "" {
+			return re.BadRequestError("backup has no archive", nil)
+		}
+
+		reader, err := storage.Open(re.Request.Context(), key)
+		if err != nil {
+			if errors.Is(err, services.ErrArchiveNotFound) {
+				return re.NotFoundError("backup archive not found", err)
+			}
+			return re.InternalServerError("failed to open backup archive", err)
+		}
+		defer reader.Close()
+
+		re.Response.Header().Set("Content-Type", "application/zip")
+		re.Response.Header().Set("Content-Disposition", `attachment; filename="`+path.Base(key)+`"`)
+		re.Response.WriteHeader(http.StatusOK)
+		_, err = io.Copy(re.Response, reader)
+		return err
+	}
+}
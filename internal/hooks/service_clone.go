@@ -0,0 +1,162 @@
+package hooks
+
+import (
+	"context"
+	"net/http"
+	"pb_launcher/collections"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type cloneServiceRequest struct {
+	Name            string `json:"name"`
+	Release         string `json:"release"` // defaults to the release of the source
+	DropAuthRecords bool   `json:"drop_auth_records"`
+	RotateSuperuser bool   `json:"rotate_superuser"`
+}
+
+// RegisterServiceCloneRoute creates a new service from an existing one and
+// queues the clone command that copies its data and starts it.
+func RegisterServiceCloneRoute(app *pocketbase.PocketBase) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/x-api/service/{service_id}/clone", func(re *core.RequestEvent) error {
+			var body cloneServiceRequest
+			if err := re.BindBody(&body); err != nil {
+				return re.BadRequestError("invalid request body", err)
+			}
+
+			source, err := re.App.FindRecordById(collections.Services, re.Request.PathValue("service_id"))
+			if err != nil || !source.GetDateTime("deleted").IsZero() {
+				return re.NotFoundError("service not found", err)
+			}
+			if err := validateCloneRelease(re.App, source, body.Release); err != nil {
+				return re.BadRequestError("invalid clone release", err)
+			}
+			email := re.Auth.GetString("email")
+			if body.RotateSuperuser && email == "" {
+				return re.BadRequestError("rotating the superuser requires an email on the auth record", nil)
+			}
+
+			clone, comand, err := queueClone(re.App, source, body, email)
+			if err != nil {
+				return re.BadRequestError("failed to clone service", err)
+			}
+			return re.JSON(http.StatusAccepted, map[string]string{
+				"service": clone.Id,
+				"comand":  comand.Id,
+			})
+		}).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
+
+// queueClone creates the clone of source and its clone command in one
+// transaction. The clone command starts the service once its data is
+// copied, so the clone is not started on creation.
+func queueClone(app core.App, source *core.Record, body cloneServiceRequest, email string) (clone, comand *core.Record, err error) {
+	err = app.RunInTransaction(func(txApp core.App) error {
+		clone, err = createClonedService(txApp, source, body)
+		if err != nil {
+			return err
+		}
+		comands, err := txApp.FindCachedCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		options := map[string]any{
+			"drop_auth_records": body.DropAuthRecords,
+			"rotate_superuser":  body.RotateSuperuser,
+		}
+		if body.RotateSuperuser {
+			options["superuser_email"] = email
+		}
+		comand = core.NewRecord(comands)
+		comand.Set("service", clone.Id)
+		comand.Set("action", "clone")
+		comand.Set("source", source.Id)
+		comand.Set("options", options)
+		comand.Set("cause", "user")
+		comand.Set("status", "pending")
+		return txApp.Save(comand)
+	})
+	return clone, comand, err
+}
+
+// validateCloneRelease accepts any release of the repository the source
+// service runs.
+func validateCloneRelease(app core.App, source *core.Record, releaseID string) error {
+	if releaseID == "" || releaseID == source.GetString("release") {
+		return nil
+	}
+	current, err := app.FindRecordById(collections.Releases, source.GetString("release"))
+	if err != nil {
+		return err
+	}
+	target, err := app.FindRecordById(collections.Releases, releaseID)
+	if err != nil {
+		return validation.Errors{
+			"release": validation.NewError("validation_invalid_release", "release not found"),
+		}
+	}
+	if target.GetString("repository") != current.GetString("repository") {
+		return validation.Errors{
+			"release": validation.NewError("validation_other_repository",
+				"release belongs to another repository"),
+		}
+	}
+	return nil
+}
+
+// createClonedService copies the settings and environment of source into a
+// new idle service. Ports, domains and backup schedules are not copied;
+// secret env values are copied sealed, both services share the master key.
+func createClonedService(app core.App, source *core.Record, body cloneServiceRequest) (*core.Record, error) {
+	clone := core.NewRecord(source.Collection())
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		name = source.GetString("name") + " (clone)"
+	}
+	release := body.Release
+	if release == "" {
+		release = source.GetString("release")
+	}
+	clone.Set("name", name)
+	clone.Set("release", release)
+	clone.Set("restart_policy", source.GetString("restart_policy"))
+	clone.Set("args_template", source.GetString("args_template"))
+	clone.Set("idle_timeout", source.GetInt("idle_timeout"))
+	clone.Set("boot_priority", source.GetInt("boot_priority"))
+	for _, field := range resourceLimitFields {
+		clone.Set(field, source.GetFloat(field))
+	}
+	clone.Set("status", "idle")
+	if !body.RotateSuperuser {
+		clone.Set("boot_user_email", source.GetString("boot_user_email"))
+		clone.Set("boot_user_password", source.GetString("boot_user_password"))
+	}
+	if err := app.SaveWithContext(withoutAutoStart(context.Background()), clone); err != nil {
+		return nil, err
+	}
+
+	envs, err := app.FindAllRecords(collections.ServiceEnv, dbx.HashExp{"service": source.Id})
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		copied := core.NewRecord(env.Collection())
+		copied.Set("service", clone.Id)
+		copied.Set("key", env.GetString("key"))
+		copied.Set("value", env.GetString("value"))
+		copied.Set("secret", env.GetBool("secret"))
+		if err := app.Save(copied); err != nil {
+			return nil, err
+		}
+	}
+	return clone, nil
+}
@@ -0,0 +1,81 @@
+package hooks
+
+import (
+	"pb_launcher/collections"
+	_ "pb_launcher/migrations"
+	"testing"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// saveUnvalidated stores a record with only the given fields set.
+func saveUnvalidated(t *testing.T, app core.App, collection string, fields map[string]any) *core.Record {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(c)
+	for key, value := range fields {
+		record.Set(key, value)
+	}
+	if err := app.SaveNoValidate(record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestQueueCloneOnlyQueuesTheCloneCommand(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Cleanup()
+	app.OnRecordAfterCreateSuccess(collections.Services).BindFunc(queueInitialStart)
+
+	release := saveUnvalidated(t, app, collections.Releases, map[string]any{"version": "0.29.1"})
+	source := saveUnvalidated(t, app, collections.Services, map[string]any{
+		"name":    "source",
+		"release": release.Id,
+		"status":  "running",
+	})
+
+	clone, _, err := queueClone(app, source, cloneServiceRequest{}, "")
+	if err != nil {
+		t.Fatalf("queueClone() error = %v", err)
+	}
+
+	comands, err := app.FindAllRecords(collections.ServicesComands, dbx.HashExp{"service": clone.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comands) != 1 || comands[0].GetString("action") != "clone" {
+		var actions []string
+		for _, comand := range comands {
+			actions = append(actions, comand.GetString("action"))
+		}
+		t.Fatalf("queued actions = %v, want only clone", actions)
+	}
+
+	// services created any other way are still started
+	created, err := app.FindCollectionByNameOrId(collections.Services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := core.NewRecord(created)
+	other.Set("name", "other")
+	other.Set("release", release.Id)
+	if err := app.Save(other); err != nil {
+		t.Fatal(err)
+	}
+	starts, err := app.CountRecords(collections.ServicesComands,
+		dbx.HashExp{"service": other.Id, "action": "start"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if starts != 1 {
+		t.Errorf("queued %d start commands for a new service, want 1", starts)
+	}
+}
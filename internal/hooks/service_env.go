@@ -0,0 +1,84 @@
+package hooks
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/helpers/secrets"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const secretMask = "********"
+
+func AddServiceEnvHooks(app *pocketbase.PocketBase, cipher *secrets.Cipher) {
+	app.OnRecordCreateRequest(collections.ServiceEnv).
+		BindFunc(func(e *core.RecordRequestEvent) error {
+			if err := sealServiceEnvValue(cipher, e.Record, e.Record.GetString("value")); err != nil {
+				return e.InternalServerError("failed to encrypt secret value", err)
+			}
+			return e.Next()
+		})
+
+	app.OnRecordUpdateRequest(collections.ServiceEnv).
+		BindFunc(func(e *core.RecordRequestEvent) error {
+			original := e.Record.Original()
+			e.Record.Set("service", original.GetString("service"))
+
+			plain := e.Record.GetString("value")
+			if plain == secretMask {
+				// the client sent back the masked value, keep the stored one
+				plain = original.GetString("value")
+				if original.GetBool("secret") {
+					decrypted, err := cipher.Decrypt(plain)
+					if err != nil {
+						return e.InternalServerError("failed to decrypt secret value", err)
+					}
+					plain = decrypted
+				}
+			}
+			if err := sealServiceEnvValue(cipher, e.Record, plain); err != nil {
+				return e.InternalServerError("failed to encrypt secret value", err)
+			}
+			return e.Next()
+		})
+
+	app.OnRecordEnrich(collections.ServiceEnv).
+		BindFunc(func(e *core.RecordEnrichEvent) error {
+			if e.Record.GetBool("secret") {
+				e.Record.Set("value", secretMask)
+			}
+			return e.Next()
+		})
+
+	markRestartRequired := func(e *core.RecordEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+		service, err := e.App.FindRecordById(collections.Services, e.Record.GetString("service"))
+		if err != nil {
+			return nil
+		}
+		if !hasProcess(service.GetString("status")) || service.GetBool("restart_required") {
+			return nil
+		}
+		service.Set("restart_required", true)
+		return e.App.Save(service)
+	}
+
+	app.OnRecordAfterCreateSuccess(collections.ServiceEnv).BindFunc(markRestartRequired)
+	app.OnRecordAfterUpdateSuccess(collections.ServiceEnv).BindFunc(markRestartRequired)
+	app.OnRecordAfterDeleteSuccess(collections.ServiceEnv).BindFunc(markRestartRequired)
+}
+
+func sealServiceEnvValue(cipher *secrets.Cipher, record *core.Record, plain string) error {
+	if !record.GetBool("secret") {
+		record.Set("value", plain)
+		return nil
+	}
+	encrypted, err := cipher.Encrypt(plain)
+	if err != nil {
+		return err
+	}
+	record.Set("value", encrypted)
+	return nil
+}
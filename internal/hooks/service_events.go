@@ -0,0 +1,49 @@
+package hooks
+
+import (
+	"log/slog"
+	"pb_launcher/collections"
+	"pb_launcher/internal/launcher/domain/models"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// AddServiceEventHooks records every status change of a service in
+// service_events. The cause is taken from the context of the save, see
+// models.WithCause; saves without one are recorded as system changes.
+func AddServiceEventHooks(app *pocketbase.PocketBase) {
+	app.OnRecordUpdate(collections.Services).BindFunc(func(e *core.RecordEvent) error {
+		from := e.Record.Original().GetString("status")
+		if err := e.Next(); err != nil {
+			return err
+		}
+		to := e.Record.GetString("status")
+		if from == to {
+			return nil
+		}
+
+		events, err := e.App.FindCachedCollectionByNameOrId(collections.ServiceEvents)
+		if err != nil {
+			return err
+		}
+		event := core.NewRecord(events)
+		event.Set("service", e.Record.Id)
+		event.Set("from", from)
+		event.Set("to", to)
+		event.Set("cause", string(models.CauseFrom(e.Context)))
+		if to == string(models.Failure) || to == string(models.CrashLoop) {
+			event.Set("error_message", e.Record.GetString("error_message"))
+		}
+		// the status is saved already, a lost event must not fail it
+		if err := e.App.Save(event); err != nil {
+			slog.Error("failed to record service event",
+				"serviceID", e.Record.Id,
+				"from", from,
+				"to", to,
+				"error", err,
+			)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,202 @@
+package hooks
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"pb_launcher/collections"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+	"strconv"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type renameFileRequest struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Restart bool   `json:"restart"`
+}
+
+// RegisterServiceFilesRoutes exposes the hooks, public and migrations
+// directories of a service:
+//
+//	GET    /x-api/service/{service_id}/files/{area}/{path...}  list a directory (recursive) or download a file
+//	PUT    /x-api/service/{service_id}/files/{area}/{path...}  upload the request body as a file
+//	POST   /x-api/service/{service_id}/files/{area}/extract    extract the zip body into ?dir=
+//	POST   /x-api/service/{service_id}/files/{area}/rename     {from, to}
+//	DELETE /x-api/service/{service_id}/files/{area}/{path...}  delete a file or directory
+//
+// Changes are picked up on the next restart; ?restart=true (or "restart"
+// in the rename body) queues it right away, otherwise a running service is
+// flagged with restart_required.
+func RegisterServiceFilesRoutes(app *pocketbase.PocketBase, files *launcherdomain.ServiceFiles) {
+	// replaces the default body limit of PocketBase on the upload routes
+	bodyLimit := files.MaxFileSize() + 1
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		// the area itself is listed with a trailing slash, a bare
+		// /files/{area} pattern would collide with the logs route
+		se.Router.GET("/x-api/service/{service_id}/files/{area}/{path...}", handleGetServiceFile(files)).
+			Bind(apis.RequireAuth())
+
+		se.Router.PUT("/x-api/service/{service_id}/files/{area}/{path...}", func(re *core.RequestEvent) error {
+			service, err := findFilesService(re)
+			if err != nil {
+				return err
+			}
+			entry, err := files.Write(service.Id, re.Request.PathValue("area"), re.Request.PathValue("path"), re.Request.Body)
+			if err != nil {
+				return filesError(re, err)
+			}
+			restart, err := afterFilesChange(re.App, service, queryRestart(re))
+			if err != nil {
+				return re.InternalServerError("files changed, but the restart could not be scheduled", err)
+			}
+			return re.JSON(http.StatusOK, map[string]any{"file": entry, "restart": restart})
+		}).Bind(apis.RequireAuth(), apis.BodyLimit(bodyLimit))
+
+		se.Router.POST("/x-api/service/{service_id}/files/{area}/extract", func(re *core.RequestEvent) error {
+			service, err := findFilesService(re)
+			if err != nil {
+				return err
+			}
+			extracted, err := files.Extract(service.Id, re.Request.PathValue("area"), re.Request.URL.Query().Get("dir"), re.Request.Body)
+			if err != nil {
+				return filesError(re, err)
+			}
+			restart, err := afterFilesChange(re.App, service, queryRestart(re))
+			if err != nil {
+				return re.InternalServerError("files changed, but the restart could not be scheduled", err)
+			}
+			return re.JSON(http.StatusOK, map[string]any{"files": extracted, "restart": restart})
+		}).Bind(apis.RequireAuth(), apis.BodyLimit(bodyLimit))
+
+		se.Router.POST("/x-api/service/{service_id}/files/{area}/rename", func(re *core.RequestEvent) error {
+			var body renameFileRequest
+			if err := re.BindBody(&body); err != nil {
+				return re.BadRequestError("invalid request body", err)
+			}
+			service, err := findFilesService(re)
+			if err != nil {
+				return err
+			}
+			if err := files.Rename(service.Id, re.Request.PathValue("area"), body.From, body.To); err != nil {
+				return filesError(re, err)
+			}
+			restart, err := afterFilesChange(re.App, service, body.Restart || queryRestart(re))
+			if err != nil {
+				return re.InternalServerError("files changed, but the restart could not be scheduled", err)
+			}
+			return re.JSON(http.StatusOK, map[string]any{"restart": restart})
+		}).Bind(apis.RequireAuth())
+
+		se.Router.DELETE("/x-api/service/{service_id}/files/{area}/{path...}", func(re *core.RequestEvent) error {
+			service, err := findFilesService(re)
+			if err != nil {
+				return err
+			}
+			if err := files.Delete(service.Id, re.Request.PathValue("area"), re.Request.PathValue("path")); err != nil {
+				return filesError(re, err)
+			}
+			restart, err := afterFilesChange(re.App, service, queryRestart(re))
+			if err != nil {
+				return re.InternalServerError("files changed, but the restart could not be scheduled", err)
+			}
+			return re.JSON(http.StatusOK, map[string]any{"restart": restart})
+		}).Bind(apis.RequireAuth())
+
+		return se.Next()
+	})
+}
+
+func handleGetServiceFile(files *launcherdomain.ServiceFiles) func(re *core.RequestEvent) error {
+	return func(re *core.RequestEvent) error {
+		service, err := findFilesService(re)
+		if err != nil {
+			return err
+		}
+		area, name := re.Request.PathValue("area"), re.Request.PathValue("path")
+		entry, err := files.Stat(service.Id, area, name)
+		if err != nil {
+			return filesError(re, err)
+		}
+		if entry.Dir {
+			entries, err := files.List(service.Id, area, name)
+			if err != nil {
+				return filesError(re, err)
+			}
+			return re.JSON(http.StatusOK, entries)
+		}
+
+		f, entry, err := files.Open(service.Id, area, name)
+		if err != nil {
+			return filesError(re, err)
+		}
+		defer f.Close()
+		re.Response.Header().Set("Content-Disposition",
+			"attachment; filename="+strconv.Quote(path.Base(entry.Path)))
+		http.ServeContent(re.Response, re.Request, entry.Path, entry.Modified, f)
+		return nil
+	}
+}
+
+func findFilesService(re *core.RequestEvent) (*core.Record, error) {
+	service, err := re.App.FindRecordById(collections.Services, re.Request.PathValue("service_id"))
+	if err != nil || !service.GetDateTime("deleted").IsZero() {
+		return nil, re.NotFoundError("service not found", err)
+	}
+	return service, nil
+}
+
+func filesError(re *core.RequestEvent, err error) error {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return re.NotFoundError("file not found", nil)
+	case errors.Is(err, launcherdomain.ErrFileTooLarge), errors.Is(err, launcherdomain.ErrFileAreaFull):
+		return re.Error(http.StatusRequestEntityTooLarge, err.Error(), nil)
+	case errors.Is(err, launcherdomain.ErrInvalidFileArea),
+		errors.Is(err, launcherdomain.ErrInvalidFilePath),
+		errors.Is(err, launcherdomain.ErrFileExists):
+		return re.BadRequestError(err.Error(), nil)
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return re.Error(http.StatusRequestEntityTooLarge, launcherdomain.ErrFileTooLarge.Error(), nil)
+	}
+	return re.BadRequestError("file operation failed", err)
+}
+
+func queryRestart(re *core.RequestEvent) bool {
+	restart, _ := strconv.ParseBool(re.Request.URL.Query().Get("restart"))
+	return restart
+}
+
+// afterFilesChange queues a restart of a running service when asked to,
+// otherwise it only flags the service. Reports whether a restart was queued.
+func afterFilesChange(app core.App, service *core.Record, restart bool) (bool, error) {
+	if !hasProcess(service.GetString("status")) {
+		return false, nil
+	}
+	if !restart {
+		if service.GetBool("restart_required") {
+			return false, nil
+		}
+		service.Set("restart_required", true)
+		return false, app.Save(service)
+	}
+	comandCollection, err := app.FindCachedCollectionByNameOrId(collections.ServicesComands)
+	if err != nil {
+		return false, err
+	}
+	record := core.NewRecord(comandCollection)
+	record.Set("service", service.Id)
+	record.Set("action", "restart")
+	record.Set("status", "pending")
+	record.Set("error_message", "")
+	record.Set("executed", nil)
+	record.Set("cause", "user")
+	return true, app.Save(record)
+}
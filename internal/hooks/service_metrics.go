@@ -0,0 +1,66 @@
+package hooks
+
+import (
+	"net/http"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/metricstore"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const defaultMetricsWindow = time.Hour
+
+type serviceMetricsResponse struct {
+	// Current is the last sample, nil when the service is not running
+	Current *metricstore.ServiceMetrics  `json:"current"`
+	History []metricstore.ServiceMetrics `json:"history"`
+}
+
+// RegisterServiceMetricsRoute exposes the collected metrics of a service:
+// GET /x-api/service/{service_id}/metrics?since=6h
+func RegisterServiceMetricsRoute(app *pocketbase.PocketBase, store *metricstore.ServiceMetricsDB, c configs.Config) {
+	interval := c.GetMetricsInterval()
+	retention := c.GetMetricsRetention()
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		// "/x-api/service/{service_id}/metrics" would conflict with
+		// "/x-api/service/logs/{service_id}" in the router, which is more
+		// specific than this pattern
+		se.Router.GET("/x-api/service/{service_id}/{resource}", func(re *core.RequestEvent) error {
+			if re.Request.PathValue("resource") != "metrics" {
+				return re.NotFoundError("", nil)
+			}
+			serviceID := re.Request.PathValue("service_id")
+
+			window := defaultMetricsWindow
+			if raw := re.Request.URL.Query().Get("since"); raw != "" {
+				parsed, err := time.ParseDuration(raw)
+				if err != nil || parsed <= 0 {
+					return re.BadRequestError("invalid since duration", err)
+				}
+				window = min(parsed, retention)
+			}
+
+			history, err := store.MetricsHistory(serviceID, time.Now().Add(-window))
+			if err != nil {
+				return re.InternalServerError("failed to read service metrics", err)
+			}
+			current, err := store.LatestMetrics(serviceID)
+			if err != nil {
+				return re.InternalServerError("failed to read service metrics", err)
+			}
+			// a sample older than two collection runs belongs to a stopped process
+			if current != nil && time.Since(current.Timestamp) > 2*interval {
+				current = nil
+			}
+			return re.JSON(http.StatusOK, serviceMetricsResponse{
+				Current: current,
+				History: history,
+			})
+		}).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
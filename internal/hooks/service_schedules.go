@@ -0,0 +1,64 @@
+package hooks
+
+import (
+	"pb_launcher/collections"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// AddServiceSchedulesHooks validates the service schedules and keeps their
+// bookkeeping fields to the scheduler. Enabling a schedule or changing when
+// it fires restarts its evaluation from now, so runs that fell in between
+// are not caught up.
+func AddServiceSchedulesHooks(app *pocketbase.PocketBase) {
+	app.OnRecordValidate(collections.ServiceSchedules).BindFunc(func(e *core.RecordEvent) error {
+		if _, err := cron.NewSchedule(e.Record.GetString("cron")); err != nil {
+			return validation.Errors{
+				"cron": validation.NewError("validation_invalid_cron", err.Error()),
+			}
+		}
+		if _, err := time.LoadLocation(e.Record.GetString("timezone")); err != nil {
+			return validation.Errors{
+				"timezone": validation.NewError("validation_invalid_timezone", err.Error()),
+			}
+		}
+		return e.Next()
+	})
+
+	app.OnRecordCreateRequest(collections.ServiceSchedules).BindFunc(func(e *core.RecordRequestEvent) error {
+		service, err := e.App.FindRecordById(collections.Services, e.Record.GetString("service"))
+		if err != nil || !service.GetDateTime("deleted").IsZero() {
+			return validation.Errors{
+				"service": validation.NewError("validation_invalid_service", "service not found"),
+			}
+		}
+		if e.Record.GetString("catch_up") == "" {
+			e.Record.Set("catch_up", "skip")
+		}
+		e.Record.Set("last_checked", time.Now())
+		e.Record.Set("last_run", nil)
+		e.Record.Set("last_comand", nil)
+		return e.Next()
+	})
+
+	app.OnRecordUpdateRequest(collections.ServiceSchedules).BindFunc(func(e *core.RecordRequestEvent) error {
+		original := e.Record.Original()
+		e.Record.Set("service", original.GetString("service"))
+		e.Record.Set("last_run", original.Get("last_run"))
+		e.Record.Set("last_comand", original.Get("last_comand"))
+
+		rescheduled := e.Record.GetString("cron") != original.GetString("cron") ||
+			e.Record.GetString("timezone") != original.GetString("timezone")
+		enabled := e.Record.GetBool("enabled") && !original.GetBool("enabled")
+		if rescheduled || enabled {
+			e.Record.Set("last_checked", time.Now())
+		} else {
+			e.Record.Set("last_checked", original.Get("last_checked"))
+		}
+		return e.Next()
+	})
+}
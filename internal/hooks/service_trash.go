@@ -0,0 +1,69 @@
+package hooks
+
+import (
+	"net/http"
+	"pb_launcher/collections"
+	"pb_launcher/configs"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/internal/trash/domain/repositories"
+	"time"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type trashedServiceResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Deleted    time.Time `json:"deleted"`
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+// RegisterServiceTrashRoutes lists the deleted services and restores them
+// while they are inside the trash retention.
+func RegisterServiceTrashRoutes(app *pocketbase.PocketBase, repository repositories.TrashRepository, c configs.Config) {
+	retention := c.GetTrashRetention()
+
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/x-api/services/trash", func(re *core.RequestEvent) error {
+			trashed, err := repository.TrashedServices(re.Request.Context())
+			if err != nil {
+				return re.InternalServerError("failed to list deleted services", err)
+			}
+			response := make([]trashedServiceResponse, 0, len(trashed))
+			for _, service := range trashed {
+				response = append(response, trashedServiceResponse{
+					ID:         service.ID,
+					Name:       service.Name,
+					Deleted:    service.Deleted,
+					PurgeAfter: service.PurgeAfter(retention),
+				})
+			}
+			return re.JSON(http.StatusOK, response)
+		}).Bind(apis.RequireAuth())
+
+		se.Router.POST("/x-api/service/{service_id}/restore", func(re *core.RequestEvent) error {
+			record, err := re.App.FindRecordById(collections.Services, re.Request.PathValue("service_id"))
+			if err != nil {
+				return re.NotFoundError("service not found", err)
+			}
+			deleted := record.GetDateTime("deleted")
+			if deleted.IsZero() {
+				return re.BadRequestError("service is not deleted", nil)
+			}
+			if time.Now().After(deleted.Time().Add(retention)) {
+				return re.BadRequestError("service is past the trash retention and will be purged", nil)
+			}
+
+			record.Set("deleted", nil)
+			record.Set("status", "stopped")
+			ctx := models.WithCause(re.Request.Context(), models.CauseUser)
+			if err := re.App.SaveWithContext(ctx, record); err != nil {
+				return re.BadRequestError("failed to restore service", err)
+			}
+			return re.JSON(http.StatusOK, map[string]string{"id": record.Id, "status": "stopped"})
+		}).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
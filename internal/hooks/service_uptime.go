@@ -0,0 +1,117 @@
+package hooks
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	launcher "pb_launcher/internal/launcher/domain"
+
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const defaultUptimeRange = 7 * 24 * time.Hour
+
+type serviceUptimeResponse struct {
+	ServiceID       string    `json:"service_id"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	ObservedSeconds float64   `json:"observed_seconds"`
+	UptimeSeconds   float64   `json:"uptime_seconds"`
+	// UptimePercent is nil when the range does not overlap the life of
+	// the service, MTBFSeconds when it saw no failures
+	UptimePercent *float64 `json:"uptime_percent"`
+	MTBFSeconds   *float64 `json:"mtbf_seconds"`
+	Failures      int      `json:"failures"`
+	Restarts      int      `json:"restarts"`
+}
+
+// RegisterServiceUptimeRoutes exposes the uptime statistics computed from
+// the service_events history:
+//
+//	GET /x-api/service/uptime/{service_id}?from=&to=  one service
+//	GET /x-api/services/uptime?from=&to=              every service not in the trash
+//
+// from and to are RFC 3339 times and default to the last 7 days.
+func RegisterServiceUptimeRoutes(app *pocketbase.PocketBase, usecase *launcher.ServiceUptimeUsecase) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.GET("/x-api/service/uptime/{service_id}", func(re *core.RequestEvent) error {
+			from, to, err := uptimeRange(re)
+			if err != nil {
+				return re.BadRequestError(err.Error(), nil)
+			}
+			uptime, err := usecase.ServiceUptime(re.Request.Context(), re.Request.PathValue("service_id"), from, to)
+			if errors.Is(err, sql.ErrNoRows) {
+				return re.NotFoundError("service not found", err)
+			}
+			if err != nil {
+				return re.InternalServerError("failed to compute service uptime", err)
+			}
+			return re.JSON(http.StatusOK, newServiceUptimeResponse(*uptime))
+		}).Bind(apis.RequireAuth())
+
+		se.Router.GET("/x-api/services/uptime", func(re *core.RequestEvent) error {
+			from, to, err := uptimeRange(re)
+			if err != nil {
+				return re.BadRequestError(err.Error(), nil)
+			}
+			uptimes, err := usecase.ServicesUptime(re.Request.Context(), from, to)
+			if err != nil {
+				return re.InternalServerError("failed to compute services uptime", err)
+			}
+			response := make([]serviceUptimeResponse, 0, len(uptimes))
+			for _, uptime := range uptimes {
+				response = append(response, newServiceUptimeResponse(uptime))
+			}
+			return re.JSON(http.StatusOK, response)
+		}).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
+
+func uptimeRange(re *core.RequestEvent) (time.Time, time.Time, error) {
+	query := re.Request.URL.Query()
+	to := time.Now()
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to, expected an RFC 3339 time")
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultUptimeRange)
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from, expected an RFC 3339 time")
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+func newServiceUptimeResponse(uptime launcher.ServiceUptime) serviceUptimeResponse {
+	response := serviceUptimeResponse{
+		ServiceID:       uptime.ServiceID,
+		From:            uptime.From,
+		To:              uptime.To,
+		ObservedSeconds: uptime.Observed.Seconds(),
+		UptimeSeconds:   uptime.Uptime.Seconds(),
+		Failures:        uptime.Failures,
+		Restarts:        uptime.Restarts,
+	}
+	if percent, ok := uptime.Percent(); ok {
+		response.UptimePercent = &percent
+	}
+	if uptime.Failures > 0 {
+		mtbf := uptime.MTBF.Seconds()
+		response.MTBFSeconds = &mtbf
+	}
+	return response
+}
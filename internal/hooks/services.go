@@ -1,24 +1,72 @@
 package hooks
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"pb_launcher/collections"
+	"pb_launcher/configs"
 	"pb_launcher/internal/proxy/domain"
+	"runtime"
 	"slices"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/apis"
 	"github.com/pocketbase/pocketbase/core"
 )
 
+// minIdleTimeout keeps services from being put to sleep between requests
+// of a single page load, in seconds.
+const minIdleTimeout = 60
+
+// resourceLimitFields are applied when the process starts, changing them
+// requires a restart.
+var resourceLimitFields = []string{"memory_limit_mb", "cpu_limit", "pids_limit", "nofile_limit"}
+
+// Lower limits leave PocketBase unable to boot.
+const (
+	minMemoryLimitMB = 64
+	minCPULimit      = 0.05
+	minPidsLimit     = 16
+	minNofileLimit   = 256
+)
+
+// hasProcess reports whether the service status implies a process spawned
+// with the current settings, which a change only reaches after a restart.
+func hasProcess(status string) bool {
+	return status == "running" || status == "starting"
+}
+
 func AddServiceHooks(app *pocketbase.PocketBase,
 	serviceDiscovery *domain.ServiceDiscovery,
+	c configs.Config,
 ) {
+	enforced := enforcedLimits(c)
+
 	app.OnRecordCreateRequest(collections.Services).
 		BindFunc(func(e *core.RecordRequestEvent) error {
 			if e.Auth == nil {
 				return errors.New("unauthorized: no auth record found")
 			}
 
+			// the template provides the release and restart policy unless set
+			if templateID := e.Record.GetString("template"); templateID != "" {
+				template, err := e.App.FindRecordById(collections.ServiceTemplates, templateID)
+				if err != nil {
+					return validation.Errors{
+						"template": validation.NewError("validation_invalid_template", "template not found"),
+					}
+				}
+				if e.Record.GetString("release") == "" {
+					e.Record.Set("release", template.GetString("release"))
+				}
+				if e.Record.GetString("restart_policy") == "" {
+					e.Record.Set("restart_policy", template.GetString("restart_policy"))
+				}
+			}
+
 			restart_policy := e.Record.GetString("restart_policy")
 			if !slices.Contains([]string{"no", "on-failure"}, restart_policy) {
 				restart_policy = "no"
@@ -36,15 +84,54 @@ func AddServiceHooks(app *pocketbase.PocketBase,
 		updatedName := e.Record.GetString("name")
 		updatedPolicy := e.Record.Get("restart_policy")
 		deleted := e.Record.GetDateTime("deleted")
+		argsTemplate := e.Record.GetString("args_template")
+		preferredPort := e.Record.GetInt("preferred_port")
+		backupSchedule := e.Record.GetString("backup_schedule")
+		backupRetention := e.Record.GetInt("backup_retention")
+		idleTimeout := e.Record.GetInt("idle_timeout")
+		bootPriority := e.Record.GetInt("boot_priority")
+		limits := make(map[string]float64, len(resourceLimitFields))
+		for _, field := range resourceLimitFields {
+			limits[field] = e.Record.GetFloat(field)
+		}
 
 		currentRecord, err := e.App.FindRecordById(e.Collection, e.Record.GetString("id"))
 		if err != nil {
 			return err
 		}
+		if !currentRecord.GetDateTime("deleted").IsZero() {
+			return apis.NewBadRequestError("service is deleted, restore it from the trash first", nil)
+		}
 
 		currentRecord.Set("name", updatedName)
 		currentRecord.Set("restart_policy", updatedPolicy)
 		currentRecord.Set("deleted", deleted)
+		currentRecord.Set("backup_schedule", backupSchedule)
+		currentRecord.Set("backup_retention", backupRetention)
+		currentRecord.Set("idle_timeout", idleTimeout)
+		currentRecord.Set("boot_priority", bootPriority)
+		for field, value := range limits {
+			if currentRecord.GetFloat(field) == value {
+				continue
+			}
+			currentRecord.Set(field, value)
+			if hasProcess(currentRecord.GetString("status")) {
+				currentRecord.Set("restart_required", true)
+			}
+		}
+		if currentRecord.GetString("args_template") != argsTemplate {
+			currentRecord.Set("args_template", argsTemplate)
+			if hasProcess(currentRecord.GetString("status")) {
+				currentRecord.Set("restart_required", true)
+			}
+		}
+
+		if currentRecord.GetInt("preferred_port") != preferredPort {
+			currentRecord.Set("preferred_port", preferredPort)
+			if hasProcess(currentRecord.GetString("status")) {
+				currentRecord.Set("restart_required", true)
+			}
+		}
 
 		e.Record = currentRecord
 		if err := e.Next(); err != nil {
@@ -62,6 +149,7 @@ func AddServiceHooks(app *pocketbase.PocketBase,
 			record.Set("status", "pending")
 			record.Set("error_message", "")
 			record.Set("executed", nil)
+			record.Set("cause", "user")
 
 			if err := e.App.Save(record); err != nil {
 				return err
@@ -70,25 +158,41 @@ func AddServiceHooks(app *pocketbase.PocketBase,
 		return nil
 	})
 
-	app.OnRecordAfterCreateSuccess(collections.Services).BindFunc(func(e *core.RecordEvent) error {
-		comandCollection, err := e.App.FindCachedCollectionByNameOrId(collections.ServicesComands)
-		if err != nil {
-			return err
+	app.OnRecordValidate(collections.Services).BindFunc(func(e *core.RecordEvent) error {
+		if idleTimeout := e.Record.GetInt("idle_timeout"); idleTimeout > 0 && idleTimeout < minIdleTimeout {
+			return validation.Errors{
+				"idle_timeout": validation.NewError("validation_idle_timeout_too_short",
+					fmt.Sprintf("idle timeout must be 0 (disabled) or at least %d seconds", minIdleTimeout)),
+			}
 		}
-		record := core.NewRecord(comandCollection)
 
-		record.Set("service", e.Record.Id)
-		record.Set("action", "start")
-		record.Set("status", "pending")
-		record.Set("error_message", "")
-		record.Set("executed", nil)
+		if err := validateResourceLimits(e.Record, enforced); err != nil {
+			return err
+		}
 
-		if err := e.App.Save(record); err != nil {
+		preferredPort := e.Record.GetInt("preferred_port")
+		if preferredPort == 0 || !e.Record.GetDateTime("deleted").IsZero() {
+			return e.Next()
+		}
+		total, err := e.App.CountRecords(collections.Services,
+			dbx.HashExp{"preferred_port": preferredPort},
+			dbx.Not(dbx.HashExp{"id": e.Record.Id}),
+			dbx.NewExp("(deleted IS NULL OR deleted = '')"),
+		)
+		if err != nil {
 			return err
 		}
+		if total > 0 {
+			return validation.Errors{
+				"preferred_port": validation.NewError("validation_port_taken",
+					fmt.Sprintf("port %d is already pinned by another service", preferredPort)),
+			}
+		}
 		return e.Next()
 	})
 
+	app.OnRecordAfterCreateSuccess(collections.Services).BindFunc(queueInitialStart)
+
 	app.OnRecordAfterUpdateSuccess(collections.Services).
 		BindFunc(func(e *core.RecordEvent) error {
 			if err := e.Next(); err != nil {
@@ -99,3 +203,84 @@ func AddServiceHooks(app *pocketbase.PocketBase,
 		})
 
 }
+
+type noAutoStartKey struct{}
+
+// withoutAutoStart marks the services saved with ctx as started by another
+// command, such as the clone that copies their data first.
+func withoutAutoStart(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noAutoStartKey{}, true)
+}
+
+// queueInitialStart starts every new service unless it was created
+// withoutAutoStart.
+func queueInitialStart(e *core.RecordEvent) error {
+	if skip, _ := e.Context.Value(noAutoStartKey{}).(bool); skip {
+		return e.Next()
+	}
+	comandCollection, err := e.App.FindCachedCollectionByNameOrId(collections.ServicesComands)
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(comandCollection)
+
+	record.Set("service", e.Record.Id)
+	record.Set("action", "start")
+	record.Set("status", "pending")
+	record.Set("error_message", "")
+	record.Set("executed", nil)
+	record.Set("cause", "user")
+
+	if err := e.App.Save(record); err != nil {
+		return err
+	}
+	return e.Next()
+}
+
+// enforcedLimits returns the limit fields the launcher configuration can
+// enforce: memory and CPU need a cgroup parent, pids one or a UID per
+// service, the open files limit is an rlimit.
+func enforcedLimits(c configs.Config) map[string]bool {
+	if runtime.GOOS != "linux" {
+		return map[string]bool{}
+	}
+	cgroup := c.GetCgroupParent() != ""
+	return map[string]bool{
+		"memory_limit_mb": cgroup,
+		"cpu_limit":       cgroup,
+		"pids_limit":      cgroup || c.GetIsolationMode() != "",
+		"nofile_limit":    true,
+	}
+}
+
+// validateResourceLimits rejects limits set to a value the launcher would
+// not enforce. Unchanged values are accepted, so services keep saving after
+// the configuration changed.
+func validateResourceLimits(record *core.Record, enforced map[string]bool) error {
+	minimums := map[string]float64{
+		"memory_limit_mb": minMemoryLimitMB,
+		"cpu_limit":       minCPULimit,
+		"pids_limit":      minPidsLimit,
+		"nofile_limit":    minNofileLimit,
+	}
+	errs := validation.Errors{}
+	for _, field := range resourceLimitFields {
+		value := record.GetFloat(field)
+		if value <= 0 {
+			continue
+		}
+		if value < minimums[field] {
+			errs[field] = validation.NewError("validation_limit_too_low",
+				fmt.Sprintf("must be 0 (unlimited) or at least %v", minimums[field]))
+			continue
+		}
+		if !enforced[field] && value != record.Original().GetFloat(field) {
+			errs[field] = validation.NewError("validation_limit_not_enforced",
+				"not enforced by this launcher, it requires a cgroup_parent (or isolation for the pids limit)")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
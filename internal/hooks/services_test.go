@@ -0,0 +1,61 @@
+package hooks
+
+import (
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func TestValidateResourceLimits(t *testing.T) {
+	services := core.NewBaseCollection("services")
+	for _, field := range resourceLimitFields {
+		services.Fields.Add(&core.NumberField{Name: field})
+	}
+	// without a cgroup parent, for a service with a UID of its own
+	enforced := map[string]bool{"pids_limit": true, "nofile_limit": true}
+
+	tests := []struct {
+		name    string
+		field   string
+		value   float64
+		wantErr bool
+	}{
+		{"unlimited", "memory_limit_mb", 0, false},
+		{"below the minimum", "nofile_limit", 10, true},
+		{"enforced", "pids_limit", 64, false},
+		{"memory without a cgroup", "memory_limit_mb", 256, true},
+		{"cpu without a cgroup", "cpu_limit", 0.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := core.NewRecord(services)
+			record.Set(tt.field, tt.value)
+			err := validateResourceLimits(record, enforced)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateResourceLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateResourceLimitsKeepsStoredValues(t *testing.T) {
+	services := core.NewBaseCollection("services")
+	for _, field := range resourceLimitFields {
+		services.Fields.Add(&core.NumberField{Name: field})
+	}
+	// set while the launcher had a cgroup parent
+	record := core.NewRecord(services)
+	record.Id = "service_id"
+	record.Set("memory_limit_mb", 256)
+	if err := record.PostScan(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateResourceLimits(record, map[string]bool{}); err != nil {
+		t.Fatalf("unchanged limit rejected: %v", err)
+	}
+	record.Set("memory_limit_mb", 512)
+	if err := validateResourceLimits(record, map[string]bool{}); err == nil {
+		t.Fatal("changed limit accepted without a cgroup parent")
+	}
+}
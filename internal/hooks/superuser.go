@@ -4,7 +4,10 @@ import (
 	"errors"
 	"net/http"
 
+	"pb_launcher/collections"
+	"pb_launcher/helpers/secrets"
 	launcher "pb_launcher/internal/launcher/domain"
+	"pb_launcher/internal/launcher/domain/models"
 
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
@@ -38,3 +41,50 @@ func RegisterUpsertServiceSuperuserRoute(app *pocketbase.PocketBase, launcherMan
 		return se.Next()
 	})
 }
+
+// RegisterResetSuperuserPasswordRoute hands out the password of a finished
+// reset-superuser command once, to the superuser it was reset for, and
+// blanks it on the command:
+//
+//	POST /x-api/comands/{comand_id}/superuser-password
+func RegisterResetSuperuserPasswordRoute(app *pocketbase.PocketBase, cipher *secrets.Cipher) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		se.Router.POST("/x-api/comands/{comand_id}/superuser-password", func(re *core.RequestEvent) error {
+			var result launcher.ResetSuperuserResult
+			var password string
+			err := re.App.RunInTransaction(func(txApp core.App) error {
+				comand, err := txApp.FindRecordById(collections.ServicesComands, re.Request.PathValue("comand_id"))
+				if err != nil {
+					return re.NotFoundError("command not found", err)
+				}
+				if comand.GetString("action") != string(models.ActionResetSuperuser) ||
+					comand.GetString("status") != "success" {
+					return re.BadRequestError("not a finished reset-superuser command", nil)
+				}
+				if err := comand.UnmarshalJSONField("result", &result); err != nil {
+					return re.InternalServerError("invalid command result", err)
+				}
+				if result.Email == "" || result.Email != re.Auth.GetString("email") {
+					return re.ForbiddenError("the password was reset for another superuser", nil)
+				}
+				if result.SealedPassword == "" {
+					return re.Error(http.StatusGone, "the password was already read", nil)
+				}
+				password, err = cipher.Decrypt(result.SealedPassword)
+				if err != nil {
+					return re.InternalServerError("failed to decrypt the password", err)
+				}
+				comand.Set("result", launcher.ResetSuperuserResult{Email: result.Email})
+				return txApp.Save(comand)
+			})
+			if err != nil {
+				return err
+			}
+			return re.JSON(http.StatusOK, map[string]string{
+				"email":    result.Email,
+				"password": password,
+			})
+		}).Bind(apis.RequireAuth())
+		return se.Next()
+	})
+}
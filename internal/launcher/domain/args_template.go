@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// DefaultArgsTemplate reproduces the flags every instance was launched with
+// before templates existed. ListenAddr is empty when rendering arguments for
+// commands other than "serve" (e.g. "superuser upsert").
+const DefaultArgsTemplate = `--dir {{.DataDir}} --hooksDir {{.HooksDir}} --publicDir {{.PublicDir}} --migrationsDir {{.MigrationsDir}}{{if .ListenAddr}} --http {{.ListenAddr}}{{end}}`
+
+// LaunchArgs holds the placeholders available inside an args template.
+type LaunchArgs struct {
+	ServiceID     string
+	BaseDir       string
+	DataDir       string
+	HooksDir      string
+	PublicDir     string
+	MigrationsDir string
+	ListenAddr    string
+}
+
+func NewLaunchArgs(dataDir, serviceID, listenAddr string) LaunchArgs {
+	baseDir := path.Join(dataDir, serviceID)
+	return LaunchArgs{
+		ServiceID:     serviceID,
+		BaseDir:       baseDir,
+		DataDir:       path.Join(baseDir, "pb_data"),
+		HooksDir:      path.Join(baseDir, "hooks"),
+		PublicDir:     path.Join(baseDir, "public"),
+		MigrationsDir: path.Join(baseDir, "migrations"),
+		ListenAddr:    listenAddr,
+	}
+}
+
+// RenderArgsTemplate executes the template and splits the result into
+// arguments. Single or double quotes group values that contain spaces.
+func RenderArgsTemplate(tmpl string, data LaunchArgs) ([]string, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = DefaultArgsTemplate
+	}
+	t, err := template.New("args").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid args template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render args template: %w", err)
+	}
+	return splitArgs(buf.String())
+}
+
+// serveOnlyFlags are only accepted by the serve command. The arguments
+// rendered without a listen address precede other commands, such as
+// "superuser upsert", so these flags belong inside {{if .ListenAddr}}.
+var serveOnlyFlags = []string{"--http", "--https", "--origins"}
+
+func isServeOnlyFlag(arg string) bool {
+	name, _, _ := strings.Cut(arg, "=")
+	return slices.Contains(serveOnlyFlags, name)
+}
+
+// ValidateArgsTemplate renders the template with sample values and makes sure
+// the serve command still binds to the address assigned by the launcher, and
+// that the render without an address is usable by the other commands.
+func ValidateArgsTemplate(tmpl string) error {
+	if strings.TrimSpace(tmpl) == "" {
+		return nil
+	}
+	sample := NewLaunchArgs("/data", "service_id", "127.0.0.1:8090")
+	args, err := RenderArgsTemplate(tmpl, sample)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(args, "serve") {
+		return errors.New("args template must not contain the serve command")
+	}
+	if !slices.ContainsFunc(args, func(arg string) bool {
+		return strings.Contains(arg, sample.ListenAddr)
+	}) {
+		return errors.New("args template must use {{.ListenAddr}}")
+	}
+	args, err = RenderArgsTemplate(tmpl, NewLaunchArgs("/data", "service_id", ""))
+	if err != nil {
+		return err
+	}
+	if i := slices.IndexFunc(args, isServeOnlyFlag); i >= 0 {
+		return fmt.Errorf("args template must only emit %s inside {{if .ListenAddr}}, commands other than serve reject it", args[i])
+	}
+	return nil
+}
+
+func splitArgs(input string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range input {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote in args template")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRenderArgsTemplate_Default(t *testing.T) {
+	args, err := RenderArgsTemplate("", NewLaunchArgs("/data", "abc", "127.0.0.1:9000"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"--dir", "/data/abc/pb_data",
+		"--hooksDir", "/data/abc/hooks",
+		"--publicDir", "/data/abc/public",
+		"--migrationsDir", "/data/abc/migrations",
+		"--http", "127.0.0.1:9000",
+	}
+	if !slices.Equal(args, expected) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestRenderArgsTemplate_WithoutListenAddr(t *testing.T) {
+	args, err := RenderArgsTemplate("", NewLaunchArgs("/data", "abc", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slices.Contains(args, "--http") {
+		t.Fatalf("--http must be omitted without listen address, got %v", args)
+	}
+}
+
+func TestRenderArgsTemplate_Quotes(t *testing.T) {
+	tmpl := `--dir "{{.DataDir}}" --origins 'https://a.test, https://b.test' --http={{.ListenAddr}}`
+	args, err := RenderArgsTemplate(tmpl, NewLaunchArgs("/my data", "abc", "127.0.0.1:9000"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"--dir", "/my data/abc/pb_data",
+		"--origins", "https://a.test, https://b.test",
+		"--http=127.0.0.1:9000",
+	}
+	if !slices.Equal(args, expected) {
+		t.Fatalf("expected %v, got %v", expected, args)
+	}
+}
+
+func TestValidateArgsTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr bool
+	}{
+		{"empty uses default", "", false},
+		{"default", DefaultArgsTemplate, false},
+		{"custom flags", DefaultArgsTemplate + " --dev --queryTimeout 60", false},
+		{"missing listen addr", "--dir {{.DataDir}}", true},
+		{"serve flags in the if block", "--dir {{.DataDir}}{{if .ListenAddr}} --http={{.ListenAddr}} --origins x{{end}}", false},
+		{"http outside the if block", "--dir {{.DataDir}} --http {{.ListenAddr}}", true},
+		{"http= outside the if block", "--http={{.ListenAddr}}", true},
+		{"origins outside the if block", "--origins x{{if .ListenAddr}} --http {{.ListenAddr}}{{end}}", true},
+		{"contains serve", "serve --http {{.ListenAddr}}", true},
+		{"unknown placeholder", "--http {{.ListenAddr}} {{.Unknown}}", true},
+		{"syntax error", "--http {{.ListenAddr}", true},
+		{"unterminated quote", `--http {{.ListenAddr}} "--dev`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArgsTemplate(tt.tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateArgsTemplate(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+			}
+		})
+	}
+}
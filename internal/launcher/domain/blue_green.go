@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/helpers/process"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/utils/networktools"
+	"strconv"
+	"time"
+)
+
+const swapHealthPollInterval = 500 * time.Millisecond
+
+var ErrSwapUnhealthy = errors.New("new process did not become healthy")
+
+// canSwap reports whether a restart can run the old and new processes side
+// by side. A pinned port cannot be held by both at once.
+func (lm *LauncherManager) canSwap(service models.Service) bool {
+	return lm.zeroDowntimeRestart && service.PreferredPort == 0 && service.Port != 0
+}
+
+// swapService replaces the running process of a service without downtime.
+// The new process starts on a second port and takes over the proxy target
+// once /api/health passes; the old one is stopped in the background after
+// its connections drained. When the new process never gets healthy it is
+// stopped and the old one keeps serving.
+func (lm *LauncherManager) swapService(ctx context.Context, service models.Service) error {
+	old, _ := lm.process(service.ID)
+	oldAddr := net.JoinHostPort(service.IP, strconv.Itoa(service.Port))
+
+	candidate, pf, ip, port, err := lm.spawnCandidate(ctx, service)
+	if err != nil {
+		return err
+	}
+	// promoting records the port on the service
+	defer lm.releasePort(port)
+	lm.lstore.InsertLog(service.ID, logstore.StreamStdout,
+		fmt.Sprintf("Started new process on %s, waiting for it to become healthy...", pf.ListenAddr))
+
+	if err := lm.waitHealthy(ctx, candidate, ip, port); err != nil {
+		lm.rollbackSwap(service.ID, candidate, err)
+		return err
+	}
+
+	// from here on exits of the old process are expected
+	if err := lm.promoteProcess(ctx, service.ID, candidate, pf, ip, port); err != nil {
+		lm.rollbackSwap(service.ID, candidate, err)
+		return err
+	}
+
+	go lm.retireProcess(service.ID, old, oldAddr, pf.LogDir)
+	return nil
+}
+
+// rollbackSwap stops a candidate that did not take over; the old process
+// keeps serving.
+func (lm *LauncherManager) rollbackSwap(serviceID string, candidate *process.Process, reason error) {
+	lm.lstore.InsertLog(serviceID, logstore.StreamStderr,
+		fmt.Sprintf("Rolling back: %v", reason))
+	if err := candidate.Stop(); err != nil {
+		slog.Error("failed to stop candidate process", "serviceID", serviceID, "error", err)
+	}
+}
+
+// spawnCandidate starts a second process of the service on a new port,
+// which stays claimed until releasePort.
+func (lm *LauncherManager) spawnCandidate(ctx context.Context, service models.Service) (*process.Process, pidFile, string, int, error) {
+	lm.launchMtx.Lock()
+	defer lm.launchMtx.Unlock()
+
+	ip, port, err := lm.allocatePort(ctx, service)
+	if err != nil {
+		slog.Error("failed to allocate port", "serviceID", service.ID, "error", err)
+		return nil, pidFile{}, "", 0, err
+	}
+	candidate, pf, err := lm.spawnProcess(ctx, service, ip, port)
+	if err != nil {
+		return nil, pidFile{}, "", 0, err
+	}
+	lm.portClaims[port] = service.ID
+	return candidate, pf, ip, port, nil
+}
+
+func (lm *LauncherManager) releasePort(port int) {
+	lm.launchMtx.Lock()
+	defer lm.launchMtx.Unlock()
+	delete(lm.portClaims, port)
+}
+
+func (lm *LauncherManager) waitHealthy(ctx context.Context, p *process.Process, ip string, port int) error {
+	return lm.waitHealthyWithin(ctx, p, ip, port, lm.swapHealthTimeout)
+}
+
+func (lm *LauncherManager) waitHealthyWithin(ctx context.Context, p *process.Process, ip string, port int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(swapHealthPollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		if !p.IsRunning() {
+			return fmt.Errorf("%w: process exited", ErrSwapUnhealthy)
+		}
+		if lastErr = networktools.CheckHealth(ctx, ip, port, lm.healthTimeout); lastErr == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w within %s: %w", ErrSwapUnhealthy, timeout, lastErr)
+		case <-ticker.C:
+		}
+	}
+}
+
+// retireProcess runs outside the serial executor; it only touches the
+// process it was handed.
+func (lm *LauncherManager) retireProcess(serviceID string, p *process.Process, address, keepLogDir string) {
+	ctx, cancel := context.WithTimeout(context.Background(), lm.drainTimeout)
+	defer cancel()
+	lm.drainer.Drain(ctx, address)
+
+	if err := p.Stop(); err != nil {
+		slog.Error("failed to stop replaced process", "serviceID", serviceID, "error", err)
+		return
+	}
+	lm.pruneLogDirs(serviceID, keepLogDir)
+	lm.lstore.InsertLog(serviceID, logstore.StreamStdout, "Stopped replaced process after draining")
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"pb_launcher/internal/launcher/domain/repositories"
+	"testing"
+)
+
+// unsavedServices fails to record the new address of a service.
+type unsavedServices struct {
+	repositories.ServiceRepository
+}
+
+func (unsavedServices) MarkServiceRunning(ctx context.Context, id, ip, port string) error {
+	return errors.New("database is locked")
+}
+
+func TestPromoteProcessKeepsPrimaryOnError(t *testing.T) {
+	lm := &LauncherManager{repository: unsavedServices{}, primaryPids: map[string]int{"a": 100}}
+
+	if err := lm.promoteProcess(context.Background(), "a", nil, pidFile{PID: 200}, "127.0.0.1", 9000); err == nil {
+		t.Fatal("expected the repository error")
+	}
+	if !lm.isPrimaryPid("a", 100) || lm.isPrimaryPid("a", 200) {
+		t.Errorf("primary pid changed to %d", lm.primaryPids["a"])
+	}
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/helpers/pbdata"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/utils/iouitls"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// cloneService fills the directory of a new service with a copy of the
+// source service and starts it. pb_data is snapshotted with VACUUM INTO, so
+// the source keeps serving; hooks, public and migrations are copied as they
+// are.
+func (lm *LauncherManager) cloneService(ctx context.Context, target models.Service, sourceID string, opts models.CloneOptions) error {
+	if sourceID == "" || sourceID == target.ID {
+		return errors.New("clone requires another service as source")
+	}
+	if opts.RotateSuperuser && opts.SuperuserEmail == "" {
+		return errors.New("rotating the superuser requires an email")
+	}
+	if target.Status != models.Idle {
+		return fmt.Errorf("service %s was already started, only new services can be cloned into", target.ID)
+	}
+	source := NewLaunchArgs(lm.dataDir, sourceID, "")
+	dest := NewLaunchArgs(lm.dataDir, target.ID, "")
+	if _, err := os.Stat(dest.BaseDir); err == nil {
+		return fmt.Errorf("service %s already has a data directory", target.ID)
+	}
+
+	lm.lstore.InsertLog(target.ID, logstore.StreamStdout,
+		fmt.Sprintf("Cloning service %s...", sourceID))
+	if err := lm.copyServiceDirs(ctx, source, dest, opts); err != nil {
+		os.RemoveAll(dest.BaseDir)
+		return fmt.Errorf("failed to clone service %s: %w", sourceID, err)
+	}
+
+	if opts.RotateSuperuser {
+		if err := lm.UpsertSuperuser(ctx, target.ID, opts.SuperuserEmail, core.GenerateDefaultRandomId()); err != nil {
+			return fmt.Errorf("failed to rotate superuser: %w", err)
+		}
+	}
+	return lm.startService(ctx, target)
+}
+
+func (lm *LauncherManager) copyServiceDirs(ctx context.Context, source, dest LaunchArgs, opts models.CloneOptions) error {
+	if err := os.MkdirAll(dest.BaseDir, 0o755); err != nil {
+		return err
+	}
+	if exists(source.DataDir) {
+		if err := pbdata.Snapshot(ctx, source.DataDir, dest.DataDir); err != nil {
+			return err
+		}
+	}
+	for src, dst := range map[string]string{
+		source.HooksDir:      dest.HooksDir,
+		source.PublicDir:     dest.PublicDir,
+		source.MigrationsDir: dest.MigrationsDir,
+	} {
+		if !exists(src) {
+			continue
+		}
+		if err := iouitls.CopyDir(src, dst); err != nil {
+			return err
+		}
+	}
+
+	if !exists(dest.DataDir) {
+		return nil
+	}
+	if opts.DropAuthRecords {
+		if err := pbdata.DropAuthRecords(ctx, dest.DataDir); err != nil {
+			return fmt.Errorf("failed to drop auth records: %w", err)
+		}
+	}
+	if opts.RotateSuperuser {
+		if err := pbdata.DeleteSuperusers(ctx, dest.DataDir); err != nil {
+			return fmt.Errorf("failed to delete superusers: %w", err)
+		}
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"pb_launcher/internal/launcher/domain/models"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyServiceDirs(t *testing.T) {
+	dataDir := t.TempDir()
+	lm := &LauncherManager{dataDir: dataDir}
+	source := NewLaunchArgs(dataDir, "source", "")
+	dest := NewLaunchArgs(dataDir, "clone", "")
+
+	require.NoError(t, os.MkdirAll(source.HooksDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(source.HooksDir, "main.pb.js"), []byte("// hook"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(source.DataDir, "storage"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(source.DataDir, "storage", "file.txt"), []byte("data"), 0o644))
+
+	require.NoError(t, lm.copyServiceDirs(context.Background(), source, dest, models.CloneOptions{}))
+
+	hook, err := os.ReadFile(filepath.Join(dest.HooksDir, "main.pb.js"))
+	require.NoError(t, err)
+	require.Equal(t, "// hook", string(hook))
+	_, err = os.Stat(filepath.Join(dest.DataDir, "storage", "file.txt"))
+	require.NoError(t, err)
+	// missing source directories are skipped
+	require.NoDirExists(t, dest.PublicDir)
+	require.NoDirExists(t, dest.MigrationsDir)
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"pb_launcher/internal/launcher/domain/models"
+	"slices"
+	"sync"
+)
+
+// CommandHandler runs a command against its service. The returned result,
+// if any, is stored as JSON on the command. Handlers run in the worker of
+// the service, concurrently with other services, and must give up once ctx
+// is done.
+type CommandHandler func(ctx context.Context, service models.Service, cmd models.ServiceCommand) (any, error)
+
+// CommandRegistry maps the actions of the comands collection to their
+// handlers. The launcher registers its own actions, other modules add
+// theirs while the application is built.
+type CommandRegistry struct {
+	mu       sync.RWMutex
+	handlers map[models.CommandAction]CommandHandler
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{handlers: make(map[models.CommandAction]CommandHandler)}
+}
+
+// Register adds the handler of an action; registering an action twice is a
+// programming error and panics.
+func (r *CommandRegistry) Register(action models.CommandAction, handler CommandHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[action]; ok {
+		panic(fmt.Sprintf("command action %q already registered", action))
+	}
+	r.handlers[action] = handler
+}
+
+// Handler returns the handler of an action.
+func (r *CommandRegistry) Handler(action models.CommandAction) (CommandHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[action]
+	return handler, ok
+}
+
+// Actions returns the registered actions sorted by name.
+func (r *CommandRegistry) Actions() []models.CommandAction {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	actions := make([]models.CommandAction, 0, len(r.handlers))
+	for action := range r.handlers {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+	return actions
+}
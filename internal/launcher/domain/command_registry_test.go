@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"pb_launcher/internal/launcher/domain/models"
+	"slices"
+	"testing"
+)
+
+func noopCommand(context.Context, models.Service, models.ServiceCommand) (any, error) {
+	return nil, nil
+}
+
+func TestCommandRegistry(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.Register(models.ActionVacuum, noopCommand)
+	registry.Register(models.ActionStart, noopCommand)
+
+	if _, ok := registry.Handler(models.ActionStart); !ok {
+		t.Errorf("Handler(%q) not found", models.ActionStart)
+	}
+	if _, ok := registry.Handler("backup"); ok {
+		t.Errorf("Handler(backup) found before it was registered")
+	}
+	expected := []models.CommandAction{models.ActionStart, models.ActionVacuum}
+	if got := registry.Actions(); !slices.Equal(got, expected) {
+		t.Errorf("Actions() = %v, want %v", got, expected)
+	}
+}
+
+func TestCommandRegistryDuplicate(t *testing.T) {
+	registry := NewCommandRegistry()
+	registry.Register(models.ActionStart, noopCommand)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of a registered action did not panic")
+		}
+	}()
+	registry.Register(models.ActionStart, noopCommand)
+}
+
+func TestServiceCommandDecodeOptions(t *testing.T) {
+	var opts models.CloneOptions
+	cmd := models.ServiceCommand{Options: []byte(`{"rotate_superuser":true,"superuser_email":"a@b.co"}`)}
+	if err := cmd.DecodeOptions(&opts); err != nil {
+		t.Fatalf("DecodeOptions() error = %v", err)
+	}
+	if !opts.RotateSuperuser || opts.SuperuserEmail != "a@b.co" {
+		t.Errorf("DecodeOptions() = %+v", opts)
+	}
+
+	for _, options := range []string{"", "null"} {
+		opts := models.CloneOptions{DropAuthRecords: true}
+		cmd := models.ServiceCommand{Options: []byte(options)}
+		if err := cmd.DecodeOptions(&opts); err != nil || !opts.DropAuthRecords {
+			t.Errorf("DecodeOptions(%q) = %+v, %v; want options untouched", options, opts, err)
+		}
+	}
+}
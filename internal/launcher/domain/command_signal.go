@@ -0,0 +1,25 @@
+package domain
+
+// CommandSignal wakes the launcher runner when a command is queued, so it
+// does not wait for the next command_check_interval.
+type CommandSignal struct {
+	ch chan struct{}
+}
+
+func NewCommandSignal() *CommandSignal {
+	return &CommandSignal{ch: make(chan struct{}, 1)}
+}
+
+// Notify never blocks; notifications sent before the runner got to the
+// previous one collapse into one.
+func (s *CommandSignal) Notify() {
+	select {
+	case s.ch <- struct{}{}:
+	default:
+	}
+}
+
+// C receives a value after each (collapsed) notification.
+func (s *CommandSignal) C() <-chan struct{} {
+	return s.ch
+}
@@ -0,0 +1,270 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/helpers/pbdata"
+	"pb_launcher/internal/launcher/domain/models"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var ErrServiceDeleted = errors.New("service is in the trash, restore it first")
+
+// VacuumResult is the result of a vacuum command.
+type VacuumResult struct {
+	Databases []VacuumedDatabase `json:"databases"`
+}
+
+type VacuumedDatabase struct {
+	Name       string `json:"name"`
+	SizeBefore int64  `json:"size_before"`
+	SizeAfter  int64  `json:"size_after"`
+}
+
+// ResetSuperuserResult is the result of a reset-superuser command. The
+// password is sealed with the launcher master key and handed out once by
+// the superuser password route, which blanks it.
+type ResetSuperuserResult struct {
+	Email          string `json:"email"`
+	SealedPassword string `json:"sealed_password"`
+}
+
+func (lm *LauncherManager) registerCommands() {
+	lm.commands.Register(models.ActionStart, func(ctx context.Context, service models.Service, cmd models.ServiceCommand) (any, error) {
+		if service.Deleted != "" {
+			return nil, ErrServiceDeleted
+		}
+		if cmd.Cause != models.CauseCrash {
+			lm.cancelBackoffStarts(ctx, service.ID, "superseded by a start")
+		}
+		return nil, lm.startWithTemplate(ctx, service)
+	})
+	lm.commands.Register(models.ActionStop, func(ctx context.Context, service models.Service, _ models.ServiceCommand) (any, error) {
+		lm.cancelBackoffStarts(ctx, service.ID, "superseded by a stop")
+		if service.Status == models.Sleeping || service.Status == models.Failure && !lm.isRunning(service.ID) {
+			// keeps the proxy from waking it again, or ends the restart
+			// backoff of a failed one
+			return nil, lm.repository.MarkServiceStoped(ctx, service.ID)
+		}
+		return nil, lm.stopService(ctx, service.ID)
+	})
+	lm.commands.Register(models.ActionRestart, func(ctx context.Context, service models.Service, _ models.ServiceCommand) (any, error) {
+		if service.Deleted != "" {
+			return nil, ErrServiceDeleted
+		}
+		lm.cancelBackoffStarts(ctx, service.ID, "superseded by a restart")
+		return nil, lm.restartService(ctx, service)
+	})
+	lm.commands.Register(models.ActionReset, func(ctx context.Context, service models.Service, _ models.ServiceCommand) (any, error) {
+		return nil, lm.repository.ResetRestartAttempts(ctx, service.ID)
+	})
+	lm.commands.Register(models.ActionUpgrade, func(ctx context.Context, service models.Service, cmd models.ServiceCommand) (any, error) {
+		return nil, lm.upgradeService(ctx, service, cmd.Release)
+	})
+	lm.commands.Register(models.ActionClone, func(ctx context.Context, service models.Service, cmd models.ServiceCommand) (any, error) {
+		var opts models.CloneOptions
+		if err := cmd.DecodeOptions(&opts); err != nil {
+			return nil, fmt.Errorf("invalid clone options: %w", err)
+		}
+		return nil, lm.cloneService(ctx, service, cmd.Source, opts)
+	})
+	lm.commands.Register(models.ActionResetSuperuser, lm.resetSuperuser)
+	lm.commands.Register(models.ActionVacuum, lm.vacuumService)
+}
+
+// resetSuperuser gives the superuser of the options a new random password,
+// creating it when missing. The password is only reported, sealed, in the
+// result.
+func (lm *LauncherManager) resetSuperuser(ctx context.Context, service models.Service, cmd models.ServiceCommand) (any, error) {
+	var opts models.ResetSuperuserOptions
+	if err := cmd.DecodeOptions(&opts); err != nil {
+		return nil, fmt.Errorf("invalid reset-superuser options: %w", err)
+	}
+	if opts.SuperuserEmail == "" {
+		return nil, errors.New("resetting the superuser requires an email")
+	}
+	password := core.GenerateDefaultRandomId()
+	sealed, err := lm.cipher.Encrypt(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal the new password: %w", err)
+	}
+	if err := lm.UpsertSuperuser(ctx, service.ID, opts.SuperuserEmail, password); err != nil {
+		return nil, err
+	}
+	return ResetSuperuserResult{Email: opts.SuperuserEmail, SealedPassword: sealed}, nil
+}
+
+// vacuumService rebuilds the databases of the service to give the space of
+// deleted rows back. VACUUM needs the databases for itself, so a running
+// service is stopped meanwhile.
+func (lm *LauncherManager) vacuumService(ctx context.Context, service models.Service, _ models.ServiceCommand) (any, error) {
+	pbData := NewLaunchArgs(lm.dataDir, service.ID, "").DataDir
+	result := VacuumResult{Databases: []VacuumedDatabase{}}
+	err := lm.withServiceStopped(ctx, service.ID, func() error {
+		for _, name := range pbdata.Databases {
+			path := filepath.Join(pbData, name)
+			before, err := os.Stat(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			lm.lstore.InsertLog(service.ID, logstore.StreamStdout, fmt.Sprintf("Vacuuming %s...", name))
+			if err := pbdata.Vacuum(ctx, path); err != nil {
+				return fmt.Errorf("failed to vacuum %s: %w", name, err)
+			}
+			after, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			result.Databases = append(result.Databases, VacuumedDatabase{
+				Name:       name,
+				SizeBefore: before.Size(),
+				SizeAfter:  after.Size(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// executeCommand runs the handler of the command, bounded by its timeout.
+func (lm *LauncherManager) executeCommand(ctx context.Context, cmd models.ServiceCommand) (any, error) {
+	handler, ok := lm.commands.Handler(cmd.Action)
+	if !ok {
+		return nil, fmt.Errorf("unknown action %q for service %s", cmd.Action, cmd.Service)
+	}
+	service, err := lm.repository.FindService(ctx, cmd.Service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find service %s: %w", cmd.Service, err)
+	}
+
+	ctx = models.WithCause(ctx, cmd.Cause)
+	if cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cmd.Timeout)
+		defer cancel()
+	}
+	result, err := handler(ctx, *service, cmd)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("command timed out after %s: %w", cmd.Timeout, err)
+	}
+	return result, err
+}
+
+// Run hands the due pending commands to a worker per service and returns
+// without waiting for them. The commands of a service run one after the
+// other in creation order, so a slow start only holds back its own service.
+func (lm *LauncherManager) Run(ctx context.Context) error {
+	comands, err := lm.comandsRepository.GetPendingCommands(ctx)
+	if err != nil {
+		slog.Error("failed to get pending commands", "error", err)
+		return err
+	}
+	var order []string
+	byService := make(map[string][]models.ServiceCommand)
+	for _, c := range comands {
+		if _, ok := byService[c.Service]; !ok {
+			order = append(order, c.Service)
+		}
+		byService[c.Service] = append(byService[c.Service], c)
+	}
+	for _, serviceID := range order {
+		lm.startWorker(ctx, serviceID, byService[serviceID])
+	}
+	return nil
+}
+
+// startWorker runs the commands of a service in the background unless a
+// worker of the service is still busy. Workers signal when they are done,
+// so the commands queued meanwhile are picked up by the next Run.
+func (lm *LauncherManager) startWorker(ctx context.Context, serviceID string, comands []models.ServiceCommand) {
+	lm.workersMtx.Lock()
+	defer lm.workersMtx.Unlock()
+	if _, busy := lm.workers[serviceID]; busy {
+		return
+	}
+	lm.workers[serviceID] = struct{}{}
+	lm.workersWg.Add(1)
+
+	go func() {
+		defer lm.workersWg.Done()
+		defer func() {
+			lm.workersMtx.Lock()
+			delete(lm.workers, serviceID)
+			lm.workersMtx.Unlock()
+			lm.commandSignal.Notify()
+		}()
+
+		mtx := lm.serviceLock(serviceID)
+		mtx.Lock()
+		defer mtx.Unlock()
+		for _, c := range comands {
+			if ctx.Err() != nil {
+				return // shutting down, the rest stays pending
+			}
+			lm.runCommand(ctx, c)
+		}
+	}()
+}
+
+// runCommand executes a pending command unless it was cancelled meanwhile.
+// A failed command is retried with the restart backoff until it used up its
+// max_retries. The backoff lets newer commands of the service run first, a
+// retry is dropped once one of them did.
+func (lm *LauncherManager) runCommand(ctx context.Context, c models.ServiceCommand) {
+	if c.Attempts > 0 {
+		superseded, err := lm.comandsRepository.CancelSupersededCommand(ctx, c)
+		if err != nil {
+			slog.Error("failed to check for newer commands", "commandID", c.ID, "error", err)
+			return
+		}
+		if superseded {
+			slog.Info("dropped retry superseded by a newer command", "commandID", c.ID, "action", c.Action)
+			return
+		}
+	}
+	claimed, err := lm.comandsRepository.ClaimCommand(ctx, c.ID)
+	if err != nil {
+		slog.Error("failed to claim command", "commandID", c.ID, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	result, err := lm.executeCommand(ctx, c)
+	if err != nil {
+		if c.Attempts < c.MaxRetries {
+			delay := RestartBackoff(c.Attempts+1, lm.restartBackoffBase, lm.restartBackoffMax)
+			slog.Warn("command failed, retrying",
+				"commandID", c.ID,
+				"action", c.Action,
+				"attempt", c.Attempts+1,
+				"delay", delay,
+				"error", err,
+			)
+			if retryErr := lm.comandsRepository.RetryCommand(ctx, c.ID, err.Error(), time.Now().Add(delay)); retryErr != nil {
+				slog.Error("failed to retry command", "commandID", c.ID, "error", retryErr)
+			}
+			return
+		}
+		if markErr := lm.comandsRepository.MarkCommandError(ctx, c.ID, err.Error()); markErr != nil {
+			slog.Error("failed to mark command as error", "commandID", c.ID, "error", markErr)
+		}
+		return
+	}
+	if err := lm.comandsRepository.MarkCommandSuccess(ctx, c.ID, result); err != nil {
+		slog.Error("failed to mark command as success", "commandID", c.ID, "error", err)
+	}
+}
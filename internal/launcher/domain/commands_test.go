@@ -0,0 +1,250 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/internal/launcher/domain/repositories"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServices only finds services; the commands under test need nothing else.
+type fakeServices struct {
+	repositories.ServiceRepository
+}
+
+func (fakeServices) FindService(ctx context.Context, id string) (*models.Service, error) {
+	return &models.Service{ID: id}, nil
+}
+
+type fakeCommands struct {
+	repositories.CommandsRepository
+	mu       sync.Mutex
+	pending  []models.ServiceCommand
+	finished map[string]string // command id to final status
+	retried  []string
+	// services whose backoff starts were cancelled
+	cancelled []string
+	// retries a newer command of their service ran before
+	superseded []string
+}
+
+func (f *fakeCommands) GetPendingCommands(ctx context.Context) ([]models.ServiceCommand, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.pending), nil
+}
+
+func (f *fakeCommands) ClaimCommand(ctx context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = slices.DeleteFunc(f.pending, func(c models.ServiceCommand) bool { return c.ID == id })
+	return true, nil
+}
+
+func (f *fakeCommands) RetryCommand(ctx context.Context, id, errorMessage string, notBefore time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retried = append(f.retried, id)
+	return nil
+}
+
+func (f *fakeCommands) CancelSupersededCommand(ctx context.Context, cmd models.ServiceCommand) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !slices.Contains(f.superseded, cmd.ID) {
+		return false, nil
+	}
+	f.pending = slices.DeleteFunc(f.pending, func(c models.ServiceCommand) bool { return c.ID == cmd.ID })
+	f.finished[cmd.ID] = "cancelled"
+	return true, nil
+}
+
+func (f *fakeCommands) CancelBackoffStarts(ctx context.Context, serviceID, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cancelled = append(f.cancelled, serviceID)
+	return nil
+}
+
+func (f *fakeCommands) MarkCommandSuccess(ctx context.Context, id string, result any) error {
+	f.finish(id, "success")
+	return nil
+}
+
+func (f *fakeCommands) MarkCommandError(ctx context.Context, id, errorMessage string) error {
+	f.finish(id, "error")
+	return nil
+}
+
+func (f *fakeCommands) finish(id, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.finished[id] = status
+}
+
+func (f *fakeCommands) status(id string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.finished[id]
+}
+
+func newTestCommandManager(comands *fakeCommands) *LauncherManager {
+	return &LauncherManager{
+		repository:         fakeServices{},
+		comandsRepository:  comands,
+		commands:           NewCommandRegistry(),
+		commandSignal:      NewCommandSignal(),
+		restartBackoffBase: time.Second,
+		restartBackoffMax:  time.Minute,
+		serviceLocks:       make(map[string]*sync.Mutex),
+		workers:            make(map[string]struct{}),
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunServicesDoNotWaitForEachOther(t *testing.T) {
+	comands := &fakeCommands{
+		finished: make(map[string]string),
+		pending: []models.ServiceCommand{
+			{ID: "a1", Service: "a", Action: models.ActionStart},
+			{ID: "b1", Service: "b", Action: models.ActionStart},
+			{ID: "a2", Service: "a", Action: models.ActionStop},
+		},
+	}
+	lm := newTestCommandManager(comands)
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	record := func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, id)
+	}
+	lm.commands.Register(models.ActionStart, func(ctx context.Context, service models.Service, cmd models.ServiceCommand) (any, error) {
+		if service.ID == "a" {
+			<-release // a slow start
+		}
+		record(cmd.ID)
+		return nil, nil
+	})
+	lm.commands.Register(models.ActionStop, func(ctx context.Context, service models.Service, cmd models.ServiceCommand) (any, error) {
+		record(cmd.ID)
+		return nil, nil
+	})
+
+	if err := lm.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the command of b", func() bool { return comands.status("b1") == "success" })
+	if status := comands.status("a2"); status != "" {
+		t.Fatalf("a2 finished before a1, status %q", status)
+	}
+
+	close(release)
+	waitFor(t, "the commands of a", func() bool { return comands.status("a2") == "success" })
+	lm.workersWg.Wait()
+	if expected := []string{"b1", "a1", "a2"}; !slices.Equal(order, expected) {
+		t.Errorf("commands ran in order %v, want %v", order, expected)
+	}
+	select {
+	case <-lm.commandSignal.C():
+	default:
+		t.Error("finished workers did not signal the runner")
+	}
+}
+
+func TestRunRetriesFailedCommands(t *testing.T) {
+	comands := &fakeCommands{
+		finished: make(map[string]string),
+		pending: []models.ServiceCommand{
+			{ID: "retry", Service: "a", Action: models.ActionStart, MaxRetries: 2, Attempts: 1},
+			{ID: "last", Service: "b", Action: models.ActionStart, MaxRetries: 2, Attempts: 2},
+		},
+	}
+	lm := newTestCommandManager(comands)
+	lm.commands.Register(models.ActionStart, func(context.Context, models.Service, models.ServiceCommand) (any, error) {
+		return nil, errors.New("no port available")
+	})
+
+	if err := lm.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lm.workersWg.Wait()
+	if !slices.Equal(comands.retried, []string{"retry"}) {
+		t.Errorf("retried %v, want [retry]", comands.retried)
+	}
+	if status := comands.status("last"); status != "error" {
+		t.Errorf("command without retries left has status %q, want error", status)
+	}
+}
+
+func TestRunDropsSupersededRetries(t *testing.T) {
+	comands := &fakeCommands{
+		finished: make(map[string]string),
+		pending: []models.ServiceCommand{
+			{ID: "stop", Service: "a", Action: models.ActionStop, MaxRetries: 2, Attempts: 1},
+		},
+		superseded: []string{"stop"},
+	}
+	lm := newTestCommandManager(comands)
+	ran := false
+	lm.commands.Register(models.ActionStop, func(context.Context, models.Service, models.ServiceCommand) (any, error) {
+		ran = true
+		return nil, nil
+	})
+
+	if err := lm.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lm.workersWg.Wait()
+	if ran || comands.status("stop") != "cancelled" {
+		t.Errorf("superseded retry ran %v with status %q, want cancelled", ran, comands.status("stop"))
+	}
+}
+
+type stoppedServices struct {
+	fakeServices
+	stopped []string
+}
+
+func (s *stoppedServices) MarkServiceStoped(ctx context.Context, id string) error {
+	s.stopped = append(s.stopped, id)
+	return nil
+}
+
+func TestStopCancelsBackoffStarts(t *testing.T) {
+	comands := &fakeCommands{finished: make(map[string]string)}
+	services := &stoppedServices{}
+	lm := newTestCommandManager(comands)
+	lm.repository = services
+	lm.registerCommands()
+
+	stop, _ := lm.commands.Handler(models.ActionStop)
+	if _, err := stop(context.Background(), models.Service{ID: "a", Status: models.Failure}, models.ServiceCommand{}); err != nil {
+		t.Fatalf("stopping a failed service: %v", err)
+	}
+	if !slices.Equal(comands.cancelled, []string{"a"}) || !slices.Equal(services.stopped, []string{"a"}) {
+		t.Errorf("cancelled %v and stopped %v, want [a] and [a]", comands.cancelled, services.stopped)
+	}
+
+	start, _ := lm.commands.Handler(models.ActionStart)
+	_, err := start(context.Background(), models.Service{ID: "b", Deleted: "2026-01-01 00:00:00.000Z"}, models.ServiceCommand{Cause: models.CauseCrash})
+	if !errors.Is(err, ErrServiceDeleted) {
+		t.Errorf("starting a trashed service: got %v, want ErrServiceDeleted", err)
+	}
+}
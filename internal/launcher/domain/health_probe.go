@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/utils/networktools"
+	"sync"
+)
+
+type healthProbeResult struct {
+	service models.Service
+	err     error
+}
+
+// ProbeHealth checks /api/health on every service with a live process and
+// publishes a restart command for on-failure services that stopped answering
+// while their process is still alive.
+func (lm *LauncherManager) ProbeHealth(ctx context.Context) error {
+	services, err := lm.repository.RunningServices(ctx)
+	if err != nil {
+		slog.Error("failed to retrieve running services", "error", err)
+		return err
+	}
+
+	var targets []models.Service
+	for _, service := range services {
+		if !lm.isRunning(service.ID) || service.Port == 0 {
+			continue
+		}
+		targets = append(targets, service)
+	}
+
+	results := make([]healthProbeResult, len(targets))
+	var wg sync.WaitGroup
+	for idx, service := range targets {
+		wg.Add(1)
+		go func(idx int, service models.Service) {
+			defer wg.Done()
+			err := networktools.CheckHealth(ctx, service.IP, service.Port, lm.healthTimeout)
+			results[idx] = healthProbeResult{service: service, err: err}
+		}(idx, service)
+	}
+	wg.Wait()
+
+	alive := make(map[string]struct{}, len(results))
+	for _, result := range results {
+		alive[result.service.ID] = struct{}{}
+		lm.handleProbeResult(ctx, result)
+	}
+	for id := range lm.healthFailures {
+		if _, ok := alive[id]; !ok {
+			delete(lm.healthFailures, id)
+		}
+	}
+	return nil
+}
+
+func (lm *LauncherManager) handleProbeResult(ctx context.Context, result healthProbeResult) {
+	service := result.service
+	if result.err == nil {
+		delete(lm.healthFailures, service.ID)
+		if err := lm.repository.UpdateServiceHealth(ctx, service.ID, models.HealthHealthy, 0); err != nil {
+			slog.Error("failed to update service health", "serviceID", service.ID, "error", err)
+		}
+		return
+	}
+
+	failures := lm.healthFailures[service.ID] + 1
+	lm.healthFailures[service.ID] = failures
+	slog.Warn("health probe failed",
+		"serviceID", service.ID,
+		"failures", failures,
+		"error", result.err,
+	)
+	if err := lm.repository.UpdateServiceHealth(ctx, service.ID, models.HealthUnhealthy, failures); err != nil {
+		slog.Error("failed to update service health", "serviceID", service.ID, "error", err)
+	}
+
+	if failures < lm.healthFailureThreshold || service.RestartPolicy != models.OnFailure {
+		return
+	}
+
+	lm.lstore.InsertLog(service.ID, logstore.StreamStderr, "Health check failed repeatedly, restarting service...")
+	ctx = models.WithCause(ctx, models.CauseHealthCheck)
+	if err := lm.comandsRepository.PublishRestartComand(ctx, service.ID); err != nil {
+		slog.Error("failed to publish restart command", "serviceID", service.ID, "error", err)
+		return
+	}
+	delete(lm.healthFailures, service.ID)
+}
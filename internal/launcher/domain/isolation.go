@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/process"
+	"pb_launcher/helpers/sandbox"
+	"pb_launcher/internal/launcher/domain/models"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+var ErrNoUIDAvailable = errors.New("no UID available in the isolation uid_range")
+
+// sandboxRoot is the mount point of the private root of sandboxed services.
+// Each mount namespace mounts its own tmpfs on it, it stays empty outside.
+var sandboxRoot = filepath.Join(os.TempDir(), "pb_launcher-sandbox")
+
+// assignUID returns the UID the service runs as, taking the lowest free one
+// from the pool on its first isolated start.
+func (lm *LauncherManager) assignUID(ctx context.Context, service models.Service) (int, error) {
+	assigned, err := lm.repository.AssignedUIDs(ctx, service.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load assigned UIDs: %w", err)
+	}
+	uid, err := pickUID(service.UID, assigned, lm.uidStart, lm.uidEnd)
+	if err != nil {
+		return 0, err
+	}
+	if uid != service.UID {
+		if err := lm.repository.SetServiceUID(ctx, service.ID, uid); err != nil {
+			return 0, err
+		}
+	}
+	return uid, nil
+}
+
+// pickUID keeps the current UID while it is in the pool and not used by
+// another service, e.g. a clone created with a copied record.
+func pickUID(current int, assigned map[int]string, start, end int) (int, error) {
+	if current >= start && current <= end {
+		if _, taken := assigned[current]; !taken {
+			return current, nil
+		}
+	}
+	for uid := start; uid <= end; uid++ {
+		if _, taken := assigned[uid]; !taken {
+			return uid, nil
+		}
+	}
+	return 0, ErrNoUIDAvailable
+}
+
+// prepareServiceDir hands the service directory to its user. Files written
+// by the launcher since the last start (templates, restores, the files API)
+// are included. The service user can change the tree while it is walked,
+// so every entry is reached through the descriptor of its parent and no
+// symlink is followed.
+func (lm *LauncherManager) prepareServiceDir(serviceID string, uid int) error {
+	base := path.Join(lm.dataDir, serviceID)
+	if err := os.MkdirAll(base, 0o700); err != nil {
+		return err
+	}
+	fd, err := unix.Open(base, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: base, Err: err}
+	}
+	defer unix.Close(fd)
+	if err := unix.Fchown(fd, uid, uid); err != nil {
+		return fmt.Errorf("failed to chown service directory: %w", err)
+	}
+	if err := chownTree(fd, base, uid); err != nil {
+		return fmt.Errorf("failed to chown service directory: %w", err)
+	}
+	return unix.Fchmod(fd, 0o700)
+}
+
+// chownTree changes the owner of the entries below the open directory dirfd
+// without following symlinks; name is only used in errors. Entries removed
+// during the walk are skipped.
+func chownTree(dirfd int, name string, uid int) error {
+	dup, err := unix.Dup(dirfd)
+	if err != nil {
+		return err
+	}
+	dir := os.NewFile(uintptr(dup), name)
+	entries, err := dir.ReadDir(-1)
+	dir.Close()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err := unix.Fchownat(dirfd, entry.Name(), uid, uid, unix.AT_SYMLINK_NOFOLLOW)
+		if errors.Is(err, unix.ENOENT) {
+			continue
+		}
+		if err != nil {
+			return &os.PathError{Op: "chown", Path: filepath.Join(name, entry.Name()), Err: err}
+		}
+		if !entry.IsDir() {
+			continue
+		}
+		// a directory swapped for a symlink since ReadDir fails with ELOOP
+		child, err := unix.Openat(dirfd, entry.Name(), unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		if errors.Is(err, unix.ENOENT) {
+			continue
+		}
+		if err != nil {
+			return &os.PathError{Op: "open", Path: filepath.Join(name, entry.Name()), Err: err}
+		}
+		err = chownTree(child, filepath.Join(name, entry.Name()), uid)
+		unix.Close(child)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isolate prepares the service user and returns the command, arguments and
+// process options that start the binary in the configured isolation mode.
+func (lm *LauncherManager) isolate(ctx context.Context, service models.Service, executable string, args []string) (string, []string, []process.ProcessOption, error) {
+	if lm.isolationMode == "" {
+		return executable, args, nil, nil
+	}
+	uid, err := lm.assignUID(ctx, service)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if err := lm.prepareServiceDir(service.ID, uid); err != nil {
+		return "", nil, nil, err
+	}
+	if lm.isolationMode == configs.IsolationUser {
+		return executable, args, []process.ProcessOption{process.WithCredential(uint32(uid), uint32(uid))}, nil
+	}
+
+	launcher, err := os.Executable()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to locate the launcher executable: %w", err)
+	}
+	baseDir, err := filepath.Abs(path.Join(lm.dataDir, service.ID))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	workDir, _ := os.Getwd()
+	spec := sandbox.Spec{
+		Root:       sandboxRoot,
+		UID:        uid,
+		GID:        uid,
+		Binds:      []string{baseDir},
+		ReadOnly:   sandbox.SystemFiles,
+		Dir:        workDir,
+		Executable: absExecutable(executable),
+		Args:       args,
+	}
+	return launcher,
+		append([]string{sandbox.Command}, spec.CommandArgs()...),
+		[]process.ProcessOption{process.WithMountNamespace()},
+		nil
+}
+
+// isolateCommand runs a one-off command of the service binary, such as
+// "superuser upsert", as the service user so the files it creates stay
+// accessible to the service.
+func (lm *LauncherManager) isolateCommand(ctx context.Context, service models.Service, cmd *exec.Cmd) error {
+	if lm.isolationMode == "" {
+		return nil
+	}
+	lm.launchMtx.Lock()
+	uid, err := lm.assignUID(ctx, service)
+	lm.launchMtx.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := lm.prepareServiceDir(service.ID, uid); err != nil {
+		return err
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(uid)},
+	}
+	return nil
+}
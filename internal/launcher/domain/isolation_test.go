@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestPickUID(t *testing.T) {
+	assigned := map[int]string{200000: "a", 200001: "b"}
+
+	tests := []struct {
+		name     string
+		current  int
+		expected int
+	}{
+		{"first start takes the lowest free", 0, 200002},
+		{"keeps its own", 200005, 200005},
+		{"copied from another service", 200001, 200002},
+		{"outside the pool", 1000, 200002},
+	}
+	for _, tt := range tests {
+		got, err := pickUID(tt.current, assigned, 200000, 200009)
+		if err != nil || got != tt.expected {
+			t.Errorf("%s: pickUID() = %d, %v, want %d", tt.name, got, err, tt.expected)
+		}
+	}
+
+	if _, err := pickUID(0, assigned, 200000, 200001); !errors.Is(err, ErrNoUIDAvailable) {
+		t.Errorf("pickUID() error = %v, want ErrNoUIDAvailable on an exhausted pool", err)
+	}
+}
+
+func TestPrepareServiceDir(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing file ownership requires root")
+	}
+	lm := &LauncherManager{dataDir: t.TempDir()}
+	pbData := filepath.Join(lm.dataDir, "svc", "pb_data")
+	if err := os.MkdirAll(pbData, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pbData, "data.db"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lm.prepareServiceDir("svc", 200042); err != nil {
+		t.Fatalf("prepareServiceDir() error = %v", err)
+	}
+	for _, p := range []string{filepath.Join(lm.dataDir, "svc"), pbData, filepath.Join(pbData, "data.db")} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uid := info.Sys().(*syscall.Stat_t).Uid; uid != 200042 {
+			t.Errorf("%s is owned by %d, want 200042", p, uid)
+		}
+	}
+	info, _ := os.Stat(filepath.Join(lm.dataDir, "svc"))
+	if info.Mode().Perm() != 0o700 {
+		t.Errorf("service dir mode = %v, want 0700", info.Mode().Perm())
+	}
+}
+
+func TestPrepareServiceDirDoesNotFollowSymlinks(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing file ownership requires root")
+	}
+	lm := &LauncherManager{dataDir: t.TempDir()}
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(lm.dataDir, "svc")
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(base, "pb_data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lm.prepareServiceDir("svc", 200042); err != nil {
+		t.Fatalf("prepareServiceDir() error = %v", err)
+	}
+	for _, p := range []string{outside, filepath.Join(outside, "secret")} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uid := info.Sys().(*syscall.Stat_t).Uid; uid == 200042 {
+			t.Errorf("%s was chowned through a symlink", p)
+		}
+	}
+	link, err := os.Lstat(filepath.Join(base, "pb_data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid := link.Sys().(*syscall.Stat_t).Uid; uid != 200042 {
+		t.Errorf("symlink is owned by %d, want 200042", uid)
+	}
+}
@@ -6,53 +6,176 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"os"
 	"os/exec"
-	"path"
 	"pb_launcher/configs"
 	"pb_launcher/helpers/logstore"
 	"pb_launcher/helpers/process"
+	"pb_launcher/helpers/promexport"
+	"pb_launcher/helpers/secrets"
+	"pb_launcher/helpers/unzip"
 	"pb_launcher/internal/launcher/domain/models"
 	"pb_launcher/internal/launcher/domain/repositories"
 	"pb_launcher/internal/launcher/domain/services"
 	"pb_launcher/utils/iouitls"
-	"pb_launcher/utils/networktools"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type LauncherManager struct {
 	rwMtx               sync.RWMutex
 	dataDir             string
 	ipAddress           string
+	portRangeStart      int
+	portRangeEnd        int
 	installTokenUsecase *CleanServiceInstallTokenUsecase
 	repository          repositories.ServiceRepository
 	comandsRepository   repositories.CommandsRepository
+	commands            *CommandRegistry
+	commandSignal       *CommandSignal
 	finder              services.BinaryFinder
 	lstore              *logstore.ServiceLogDB
+	cipher              *secrets.Cipher
+	drainer             services.ConnectionDrainer
+	activity            services.ActivityTracker
+	templates           services.TemplateArchives
+	unzip               *unzip.Unzip
+	//
+	healthTimeout          time.Duration
+	healthFailureThreshold int
+	healthFailures         map[string]int
+	//
+	maxRestarts        int
+	restartWindow      time.Duration
+	restartBackoffBase time.Duration
+	restartBackoffMax  time.Duration
+	//
+	startTimeout time.Duration
+	//
+	detachOnShutdown bool
+	cgroupParent     string
+	//
+	recoveryConcurrency  int
+	recoveryReadyTimeout time.Duration
+	recovery             recoveryTracker
+	//
+	isolationMode string
+	uidStart      int
+	uidEnd        int
+	//
+	zeroDowntimeRestart bool
+	swapHealthTimeout   time.Duration
+	drainTimeout        time.Duration
+	//
+	started time.Time // idle timeouts count from here for services without traffic
+	//
+	metrics launcherMetrics
 	//
 	processList map[string]*process.Process
 	errChan     chan process.ProcessErrorMessage
+	//
+	launchMtx sync.Mutex
+	// portClaims holds the ports of spawned processes that are not recorded
+	// on their service yet, blue/green candidates; guarded by launchMtx
+	portClaims map[int]string
+	//
+	serviceLocksMtx sync.Mutex
+	serviceLocks    map[string]*sync.Mutex
+	//
+	// workers has a worker for each service with commands being run
+	workersMtx sync.Mutex
+	workers    map[string]struct{}
+	workersWg  sync.WaitGroup
+	//
+	// primaryPids holds the PID serving each service; exits of other
+	// processes of the service (blue/green candidates or the process being
+	// replaced) are not failures of the service
+	pidsMtx     sync.Mutex
+	primaryPids map[string]int
 }
 
 func NewLauncherManager(
 	installTokenUsecase *CleanServiceInstallTokenUsecase,
 	repository repositories.ServiceRepository,
 	comandsRepository repositories.CommandsRepository,
+	commands *CommandRegistry,
+	commandSignal *CommandSignal,
 	finder services.BinaryFinder,
 	lstore *logstore.ServiceLogDB,
+	cipher *secrets.Cipher,
+	drainer services.ConnectionDrainer,
+	activity services.ActivityTracker,
+	templates services.TemplateArchives,
+	uz *unzip.Unzip,
+	registry *promexport.Registry,
 	c configs.Config,
 ) *LauncherManager {
 	lm := &LauncherManager{
 		installTokenUsecase: installTokenUsecase,
 		repository:          repository,
 		comandsRepository:   comandsRepository,
+		commands:            commands,
+		commandSignal:       commandSignal,
 		finder:              finder,
 		lstore:              lstore,
+		cipher:              cipher,
+		drainer:             drainer,
+		activity:            activity,
+		templates:           templates,
+		unzip:               uz,
 		dataDir:             c.GetDataDir(),
 		ipAddress:           c.GetBindIPAddress(),
-		processList:         make(map[string]*process.Process),
-		errChan:             make(chan process.ProcessErrorMessage, 10),
+		//
+		healthTimeout:          c.GetHealthCheckTimeout(),
+		healthFailureThreshold: c.GetHealthCheckFailureThreshold(),
+		healthFailures:         make(map[string]int),
+		//
+		maxRestarts:        c.GetMaxRestarts(),
+		restartWindow:      c.GetRestartWindow(),
+		restartBackoffBase: c.GetRestartBackoffBase(),
+		restartBackoffMax:  c.GetRestartBackoffMax(),
+		//
+		startTimeout: c.GetStartTimeout(),
+		//
+		detachOnShutdown: c.IsDetachOnShutdown(),
+		cgroupParent:     c.GetCgroupParent(),
+		//
+		recoveryConcurrency:  c.GetRecoveryConcurrency(),
+		recoveryReadyTimeout: c.GetRecoveryReadyTimeout(),
+		//
+		isolationMode: c.GetIsolationMode(),
+		//
+		zeroDowntimeRestart: c.IsZeroDowntimeRestart(),
+		swapHealthTimeout:   c.GetSwapHealthTimeout(),
+		drainTimeout:        c.GetDrainTimeout(),
+		//
+		started: time.Now(),
+		//
+		processList:  make(map[string]*process.Process),
+		errChan:      make(chan process.ProcessErrorMessage, 10),
+		primaryPids:  make(map[string]int),
+		portClaims:   make(map[int]string),
+		serviceLocks: make(map[string]*sync.Mutex),
+		workers:      make(map[string]struct{}),
+	}
+	lm.portRangeStart, lm.portRangeEnd = c.GetPortRange()
+	lm.uidStart, lm.uidEnd = c.GetIsolationUIDRange()
+	if lm.isolationMode != "" {
+		// services may traverse the data directory to their own, not list it
+		if err := os.MkdirAll(lm.dataDir, 0o711); err == nil {
+			_ = os.Chmod(lm.dataDir, 0o711)
+		}
+	}
+	lm.registerMetrics(registry)
+	lm.registerCommands()
+	if lm.cgroupParent != "" {
+		if err := process.EnableCgroupControllers(lm.cgroupParent); err != nil {
+			slog.Warn("failed to enable cgroup controllers", "cgroup_parent", lm.cgroupParent, "error", err)
+		}
 	}
 	go lm.handleServiceErrors()
 	return lm
@@ -60,13 +183,28 @@ func NewLauncherManager(
 
 func (lm *LauncherManager) handleServiceErrors() {
 	for serviceErr := range lm.errChan {
-		ctx := context.Background()
+		if !lm.isPrimaryPid(serviceErr.ID, serviceErr.PID) {
+			slog.Info("ignoring exit of replaced process",
+				"serviceID", serviceErr.ID,
+				"pid", serviceErr.PID,
+				"error", serviceErr.Error,
+			)
+			continue
+		}
+		reason := models.FailureExited
+		if errors.Is(serviceErr.Error, process.ErrOOMKilled) {
+			reason = models.FailureOOMKilled
+			lm.lstore.InsertLog(serviceErr.ID, logstore.StreamStderr,
+				"Process killed by the OOM killer after reaching its memory limit")
+		}
+		lm.metrics.failures.Inc(serviceErr.ID, string(reason))
+		ctx := models.WithCause(context.Background(), models.CauseCrash)
 		var errorMessage string
 		if serviceErr.Error != nil {
 			errorMessage = serviceErr.Error.Error()
 		}
 
-		if err := lm.repository.MarkServiceFailure(ctx, serviceErr.ID, errorMessage); err != nil {
+		if err := lm.repository.MarkServiceFailure(ctx, serviceErr.ID, reason, errorMessage); err != nil {
 			slog.Error("failed to update service status",
 				"serviceID", serviceErr.ID,
 				"error", err,
@@ -84,12 +222,12 @@ func (lm *LauncherManager) handleServiceErrors() {
 			continue
 		}
 
-		if service.RestartPolicy != models.OnFailure {
+		if service.RestartPolicy != models.OnFailure || service.Deleted != "" {
 			continue
 		}
 
-		if err := lm.comandsRepository.PublishStartComand(ctx, service.ID); err != nil {
-			slog.Error("failed to publish restart command",
+		if err := lm.scheduleRestart(ctx, *service, errorMessage); err != nil {
+			slog.Error("failed to schedule service restart",
 				"serviceID", service.ID,
 				"error", err,
 			)
@@ -97,14 +235,50 @@ func (lm *LauncherManager) handleServiceErrors() {
 	}
 }
 
-func (lm *LauncherManager) buildArgs(serviceID string) ([]string, error) {
-	pb_data := path.Join(lm.dataDir, serviceID)
-	return []string{
-		"--dir", path.Join(pb_data, "pb_data"),
-		"--hooksDir", path.Join(pb_data, "hooks"),
-		"--publicDir", path.Join(pb_data, "public"),
-		"--migrationsDir", path.Join(pb_data, "migrations"),
-	}, nil
+func (lm *LauncherManager) setPrimaryPid(serviceID string, pid int) {
+	lm.pidsMtx.Lock()
+	defer lm.pidsMtx.Unlock()
+	lm.primaryPids[serviceID] = pid
+}
+
+func (lm *LauncherManager) clearPrimaryPid(serviceID string) {
+	lm.pidsMtx.Lock()
+	defer lm.pidsMtx.Unlock()
+	delete(lm.primaryPids, serviceID)
+}
+
+// isPrimaryPid treats services without a recorded PID as primary, so exits
+// racing with startService are still reported.
+func (lm *LauncherManager) isPrimaryPid(serviceID string, pid int) bool {
+	lm.pidsMtx.Lock()
+	defer lm.pidsMtx.Unlock()
+	primary, ok := lm.primaryPids[serviceID]
+	return !ok || primary == pid
+}
+
+func (lm *LauncherManager) buildArgs(service models.Service, listenAddr string) ([]string, error) {
+	return RenderArgsTemplate(service.ArgsTemplate, NewLaunchArgs(lm.dataDir, service.ID, listenAddr))
+}
+
+// buildEnv returns the child environment configured for the service,
+// decrypting secret values with the launcher master key.
+func (lm *LauncherManager) buildEnv(ctx context.Context, serviceID string) ([]string, error) {
+	vars, err := lm.repository.ServiceEnvironment(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	env := make([]string, 0, len(vars))
+	for _, v := range vars {
+		value := v.Value
+		if v.Secret {
+			value, err = lm.cipher.Decrypt(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt env %s: %w", v.Key, err)
+			}
+		}
+		env = append(env, v.Key+"="+value)
+	}
+	return env, nil
 }
 
 // initializeBootUser sets up the initial boot user for the service instance.
@@ -119,13 +293,17 @@ func (lm *LauncherManager) UpsertSuperuser(ctx context.Context, serviceID, email
 		slog.Error("failed to find binary", "serviceID", service.ID, "error", err)
 		return err
 	}
-	baseArgs, err := lm.buildArgs(service.ID)
+	baseArgs, err := lm.buildArgs(*service, "")
 	if err != nil {
 		slog.Error("failed to build args", "serviceID", service.ID, "error", err)
 		return err
 	}
 	args := append(baseArgs, "superuser", "upsert", email, password)
 	cmd := exec.CommandContext(ctx, binaryPath, args...)
+	if err := lm.isolateCommand(ctx, *service, cmd); err != nil {
+		slog.Error("failed to isolate command", "serviceID", service.ID, "error", err)
+		return err
+	}
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -161,73 +339,244 @@ func (lm *LauncherManager) buildStdoutHandler(serviceID string) iouitls.WriterIn
 	}
 }
 
-func (lm *LauncherManager) startService(ctx context.Context, service models.Service) error {
-	if existingProcess, exists := lm.processList[service.ID]; exists {
-		if existingProcess.IsRunning() {
-			return fmt.Errorf("service %s is already running", service.ID)
-		}
+// processOptions wires the process output into the log store and applies
+// the service resource limits. A non-empty logDir detaches the process, see
+// process.WithDetach.
+func (lm *LauncherManager) processOptions(service models.Service, logDir string) []process.ProcessOption {
+	serviceID := service.ID
+	stdout := iouitls.NewWriterInterceptor(
+		lm.lstore.NewWriter(serviceID, logstore.StreamStdout),
+		lm.buildStdoutHandler(serviceID),
+	)
+	options := []process.ProcessOption{
+		process.WithErrorChan(lm.errChan),
+		process.WithStdout(stdout),
+		process.WithStderr(lm.lstore.NewWriter(serviceID, logstore.StreamStderr)),
+	}
+	if logDir != "" {
+		options = append(options, process.WithDetach(logDir))
+	}
+	if limits := lm.serviceLimits(service); !limits.IsZero() {
+		options = append(options, process.WithLimits(lm.cgroupParent, limits))
 	}
+	return options
+}
+
+// serviceLimits returns the limits of the service; isolated services run as
+// a UID of their own.
+func (lm *LauncherManager) serviceLimits(service models.Service) process.Limits {
+	limits := process.Limits{
+		MemoryMax: int64(service.MemoryLimitMB) << 20,
+		CPUQuota:  service.CPULimit,
+		PidsMax:   int64(service.PidsLimit),
+		NoFile:    uint64(service.NofileLimit),
+	}
+	if limits.IsZero() {
+		return limits
+	}
+	limits.OwnUser = lm.isolationMode != ""
+	return limits
+}
 
+var errProcessStart = errors.New("failed to start process")
+
+// spawnProcess starts an instance of the service listening on ip:port and
+// returns the pidfile describing it. Only start failures wrap
+// errProcessStart.
+func (lm *LauncherManager) spawnProcess(ctx context.Context, service models.Service, ip string, port int) (*process.Process, pidFile, error) {
 	executablePath, err := lm.finder.FindBinary(ctx, service.RepositoryID, service.Version, service.ExecFilePattern)
 	if err != nil {
 		slog.Error("failed to find binary", "serviceID", service.ID, "error", err)
-		return err
+		return nil, pidFile{}, err
 	}
-	ip, port, err := networktools.GetAvailablePort(lm.ipAddress)
+
+	env, err := lm.buildEnv(ctx, service.ID)
 	if err != nil {
-		slog.Error("failed to find free port", "serviceID", service.ID, "error", err)
-		return err
+		slog.Error("failed to build env", "serviceID", service.ID, "error", err)
+		return nil, pidFile{}, err
 	}
 
-	baseArgs, err := lm.buildArgs(service.ID)
+	listenIp := net.JoinHostPort(ip, strconv.Itoa(port))
+	baseArgs, err := lm.buildArgs(service, listenIp)
 	if err != nil {
 		slog.Error("failed to build args", "serviceID", service.ID, "error", err)
-		return err
+		return nil, pidFile{}, err
 	}
+	serveArgs := append([]string{"serve"}, baseArgs...)
 
-	listenIp := fmt.Sprintf("%s:%d", ip, port)
-	serveArgs := append([]string{"serve"}, append(baseArgs, "--http", listenIp)...)
-
-	stdout := iouitls.NewWriterInterceptor(
-		lm.lstore.NewWriter(service.ID, logstore.StreamStdout),
-		lm.buildStdoutHandler(service.ID),
-	)
-
+	var logDir string
+	if lm.detachOnShutdown {
+		logDir = lm.logDir(service.ID, port)
+	}
+	command, commandArgs, isolation, err := lm.isolate(ctx, service, executablePath, serveArgs)
+	if err != nil {
+		slog.Error("failed to isolate service", "serviceID", service.ID, "error", err)
+		return nil, pidFile{}, fmt.Errorf("%w: %w", errProcessStart, err)
+	}
+	options := append(lm.processOptions(service, logDir), process.WithEnv(env))
 	newProcess := process.New(
 		service.ID,
-		executablePath,
-		serveArgs,
-		process.WithErrorChan(lm.errChan),
-		process.WithStdout(stdout),
-		process.WithStderr(lm.lstore.NewWriter(service.ID, logstore.StreamStderr)),
+		command,
+		commandArgs,
+		append(options, isolation...)...,
 	)
 
 	if err := newProcess.Start(); err != nil {
 		slog.Error("failed to start process", "serviceID", service.ID, "error", err)
+		return nil, pidFile{}, fmt.Errorf("%w: %w", errProcessStart, err)
+	}
+
+	return newProcess, pidFile{
+		PID:        newProcess.Pid(),
+		Started:    time.Now(),
+		ListenAddr: listenIp,
+		Executable: absExecutable(executablePath),
+		Args:       serveArgs,
+		Detached:   lm.detachOnShutdown,
+		LogDir:     logDir,
+	}, nil
+}
+
+// startService spawns the service and waits until it answers /api/health.
+// Meanwhile the service is starting and the proxy holds its requests; one
+// that is not ready within start_timeout is stopped and marked as failure.
+func (lm *LauncherManager) startService(ctx context.Context, service models.Service) error {
+	starting, err := lm.launchService(ctx, service)
+	if err != nil {
+		return err
+	}
+	if err := lm.awaitReady(ctx, starting, lm.startTimeout); err != nil {
+		lm.abortStart(ctx, starting, err)
+		return err
+	}
+	return nil
+}
+
+// startingProcess is a spawned instance that did not answer /api/health yet.
+type startingProcess struct {
+	serviceID string
+	process   *process.Process
+	ip        string
+	port      int
+}
+
+// launchService spawns the service and marks it as starting. Port and
+// process start failures are marked as failure; on any error no process is
+// left running.
+func (lm *LauncherManager) launchService(ctx context.Context, service models.Service) (startingProcess, error) {
+	if lm.isRunning(service.ID) {
+		return startingProcess{}, fmt.Errorf("service %s is already running", service.ID)
+	}
+	lm.launchMtx.Lock()
+	defer lm.launchMtx.Unlock()
 
-		stopErr := fmt.Errorf("failed to start process: %w", err)
-		if err := lm.repository.MarkServiceFailure(ctx, service.ID, stopErr.Error()); err != nil {
-			slog.Error("failed to mark service as failed", "serviceID", service.ID, "error", err)
+	ip, port, err := lm.allocatePort(ctx, service)
+	if err != nil {
+		slog.Error("failed to allocate port", "serviceID", service.ID, "error", err)
+		if markErr := lm.repository.MarkServiceFailure(ctx, service.ID, models.FailureStartFailed, err.Error()); markErr != nil {
+			slog.Error("failed to mark service as failed", "serviceID", service.ID, "error", markErr)
 		}
+		return startingProcess{}, err
+	}
+
+	lm.clearPrimaryPid(service.ID)
+	newProcess, pf, err := lm.spawnProcess(ctx, service, ip, port)
+	if err != nil {
+		if errors.Is(err, errProcessStart) {
+			if markErr := lm.repository.MarkServiceFailure(ctx, service.ID, models.FailureStartFailed, err.Error()); markErr != nil {
+				slog.Error("failed to mark service as failed", "serviceID", service.ID, "error", markErr)
+			}
+		}
+		return startingProcess{}, err
+	}
+
+	lm.trackProcess(service.ID, newProcess, pf)
+	lm.pruneLogDirs(service.ID, pf.LogDir)
+	starting := startingProcess{serviceID: service.ID, process: newProcess, ip: ip, port: port}
+	if err := lm.repository.MarkServiceStarting(ctx, service.ID, ip, fmt.Sprint(port)); err != nil {
+		slog.Error("failed to update service status to starting", "serviceID", service.ID, "error", err)
+		// nobody waits for an instance that is not marked as starting
+		if stopErr := lm.stopProcess(service.ID); stopErr != nil {
+			slog.Error("failed to stop service not marked as starting", "serviceID", service.ID, "error", stopErr)
+		}
+		return startingProcess{}, err
+	}
+	return starting, nil
+}
+
+// awaitReady marks the service as running once the starting process
+// answers /api/health.
+func (lm *LauncherManager) awaitReady(ctx context.Context, starting startingProcess, timeout time.Duration) error {
+	if err := lm.waitHealthyWithin(ctx, starting.process, starting.ip, starting.port, timeout); err != nil {
 		return err
 	}
+	err := lm.repository.MarkServiceReady(ctx, starting.serviceID)
+	if err != nil {
+		slog.Error("failed to update service status to running", "serviceID", starting.serviceID, "error", err)
+	}
+	return err
+}
 
-	lm.processList[service.ID] = newProcess
+// abortStart stops a process that did not become ready. An exit with an
+// error is reported by handleServiceErrors, which may have marked the
+// service already.
+func (lm *LauncherManager) abortStart(ctx context.Context, starting startingProcess, reason error) {
+	serviceID := starting.serviceID
+	lm.lstore.InsertLog(serviceID, logstore.StreamStderr, fmt.Sprintf("Service did not become ready: %v", reason))
+	if starting.process.IsRunning() {
+		if err := lm.stopProcess(serviceID); err != nil {
+			slog.Error("failed to stop service that did not become ready", "serviceID", serviceID, "error", err)
+		}
+	}
 
-	if err := lm.repository.MarkServiceRunning(ctx, service.ID, ip, fmt.Sprint(port)); err != nil {
+	service, err := lm.repository.FindService(ctx, serviceID)
+	if err != nil || service.Status != models.Starting {
+		return
+	}
+	if err := lm.repository.MarkServiceFailure(ctx, serviceID, models.FailureStartFailed, reason.Error()); err != nil {
+		slog.Error("failed to mark service as failed", "serviceID", serviceID, "error", err)
+	}
+}
+
+// trackProcess records p as the process serving the service.
+func (lm *LauncherManager) trackProcess(serviceID string, p *process.Process, pf pidFile) {
+	lm.setProcess(serviceID, p)
+	lm.setPrimaryPid(serviceID, pf.PID)
+
+	if err := lm.writePidFile(serviceID, pf); err != nil {
+		slog.Error("failed to write pidfile", "serviceID", serviceID, "error", err)
+	}
+}
+
+// promoteProcess makes p the process serving the service. Saving the
+// service record invalidates the proxy cache, which switches traffic; when
+// it fails the current process stays tracked as the primary.
+func (lm *LauncherManager) promoteProcess(ctx context.Context, serviceID string, p *process.Process, pf pidFile, ip string, port int) error {
+	if err := lm.repository.MarkServiceRunning(ctx, serviceID, ip, fmt.Sprint(port)); err != nil {
 		slog.Error("failed to update service status to running",
-			"serviceID", service.ID,
+			"serviceID", serviceID,
 			"ip", ip,
 			"port", port,
 			"error", err,
 		)
+		return err
 	}
-
-	return err
+	lm.trackProcess(serviceID, p, pf)
+	return nil
 }
 
 func (lm *LauncherManager) stopService(ctx context.Context, serviceID string) error {
-	existingProcess, exists := lm.processList[serviceID]
+	if err := lm.stopProcess(serviceID); err != nil {
+		return err
+	}
+	if err := lm.repository.MarkServiceStoped(ctx, serviceID); err != nil {
+		slog.Error("failed to mark service as stopped", "serviceID", serviceID, "error", err)
+	}
+	return nil
+}
+
+func (lm *LauncherManager) stopProcess(serviceID string) error {
+	existingProcess, exists := lm.process(serviceID)
 	if !exists {
 		return fmt.Errorf("no running process found for service %s", serviceID)
 	}
@@ -240,18 +589,25 @@ func (lm *LauncherManager) stopService(ctx context.Context, serviceID string) er
 		return err
 	}
 
-	delete(lm.processList, serviceID)
-
-	if err := lm.repository.MarkServiceStoped(ctx, serviceID); err != nil {
-		slog.Error("failed to mark service as stopped", "serviceID", serviceID, "error", err)
-	}
+	lm.forgetProcess(serviceID)
+	lm.clearPrimaryPid(serviceID)
+	lm.removePidFile(serviceID)
 	return nil
 }
 
 func (lm *LauncherManager) restartService(ctx context.Context, service models.Service) error {
 	lm.lstore.InsertLog(service.ID, logstore.StreamStdout, "Restarting service...")
 
-	if p, ok := lm.processList[service.ID]; ok && p.IsRunning() {
+	if lm.isRunning(service.ID) && lm.canSwap(service) {
+		if err := lm.swapService(ctx, service); err != nil {
+			slog.Error("restart failed: unable to swap service", "serviceID", service.ID, "error", err)
+			return err
+		}
+		lm.lstore.InsertLog(service.ID, logstore.StreamStdout, "Service restarted successfully")
+		return nil
+	}
+
+	if lm.isRunning(service.ID) {
 		if err := lm.stopService(ctx, service.ID); err != nil {
 			slog.Error("restart failed: unable to stop service", "serviceID", service.ID, "error", err)
 			return err
@@ -265,76 +621,85 @@ func (lm *LauncherManager) restartService(ctx context.Context, service models.Se
 	return nil
 }
 
-// RecoveryLastState restores and starts all services that were active
-// before pb_launcher was shut down.
-func (lm *LauncherManager) RecoveryLastState(ctx context.Context) error {
-	lm.rwMtx.Lock()
-	defer lm.rwMtx.Unlock()
-	services, err := lm.repository.RunningServices(ctx)
-	if err != nil {
-		slog.Error("Failed to retrieve running services", "error", err)
-		return err
+// adoptService takes over the instance recorded in the service pidfile when
+// it is still alive and runs as the service user. Instances spawned without
+// detach lost their output pipes with the previous launcher, so they are
+// terminated and reported as not adopted to be started again.
+func (lm *LauncherManager) adoptService(ctx context.Context, service models.Service) (bool, error) {
+	pf, err := lm.readPidFile(service.ID)
+	if err != nil || pf == nil {
+		return false, err
 	}
 
-	for _, service := range services {
-		if service.Deleted != "" {
-			continue
-		}
-		if err := lm.startService(ctx, service); err != nil {
-			slog.Error("failed to start service",
-				"serviceID", service.ID,
-				"error", err,
-			)
-
-			continue
-		}
+	info, err := process.Inspect(pf.PID)
+	if err != nil || !pf.matches(info) {
+		lm.removePidFile(service.ID)
+		return false, nil
 	}
-
-	return nil
-}
-
-func (lm *LauncherManager) evaluateCommand(ctx context.Context, cmd models.ServiceCommand) error {
-	service, err := lm.repository.FindService(ctx, cmd.Service)
-	if err != nil {
-		return fmt.Errorf("failed to find service %s: %w", cmd.Service, err)
+	// never signal a process the service user could not signal itself
+	if uid := lm.serviceUID(service); info.UID != uid {
+		slog.Warn("pidfile points to a process of another user",
+			"serviceID", service.ID, "pid", pf.PID, "uid", info.UID, "expected_uid", uid)
+		lm.removePidFile(service.ID)
+		return false, nil
 	}
 
-	switch cmd.Action {
-	case models.ActionStart:
-		return lm.startService(ctx, *service)
-	case models.ActionStop:
-		return lm.stopService(ctx, service.ID)
-	case models.ActionRestart:
-		return lm.restartService(ctx, *service)
-	default:
-		return fmt.Errorf("unknown action %q for service %s", cmd.Action, cmd.Service)
+	if !pf.Detached {
+		orphan, err := process.Adopt(service.ID, pf.PID)
+		if err != nil {
+			return false, nil
+		}
+		slog.Info("terminating attached orphan instance", "serviceID", service.ID, "pid", pf.PID)
+		lm.removePidFile(service.ID)
+		return false, orphan.Stop()
 	}
-}
 
-func (lm *LauncherManager) Run(ctx context.Context) error {
-	comands, err := lm.comandsRepository.GetPendingCommands(ctx)
-	if err != nil {
-		slog.Error("failed to get pending commands", "error", err)
-		return err
+	// output files outside the launcher state directory are not followed,
+	// the instance is forwarded again after its next start
+	logDir := pf.LogDir
+	if !lm.trustedLogDir(service.ID, logDir) {
+		logDir = ""
 	}
-	for _, c := range comands {
-		if err := lm.evaluateCommand(ctx, c); err != nil {
-			if markErr := lm.comandsRepository.MarkCommandError(ctx, c.ID, err.Error()); markErr != nil {
-				slog.Error("failed to mark command as error", "commandID", c.ID, "error", markErr)
-			}
-			continue
+	adopted, err := process.Adopt(service.ID, pf.PID, lm.processOptions(service, logDir)...)
+	if err != nil {
+		if errors.Is(err, process.ErrProcessNotFound) {
+			lm.removePidFile(service.ID)
+			return false, nil
 		}
-		if err := lm.comandsRepository.MarkCommandSuccess(ctx, c.ID); err != nil {
-			slog.Error("failed to mark command as success", "commandID", c.ID, "error", err)
+		return false, err
+	}
+	lm.setProcess(service.ID, adopted)
+	lm.setPrimaryPid(service.ID, pf.PID)
+
+	if host, port, err := net.SplitHostPort(pf.ListenAddr); err == nil &&
+		(host != service.IP || port != fmt.Sprint(service.Port)) {
+		if err := lm.repository.MarkServiceRunning(ctx, service.ID, host, port); err != nil {
+			slog.Error("failed to update adopted service address", "serviceID", service.ID, "error", err)
 		}
 	}
-	return nil
+
+	lm.lstore.InsertLog(service.ID, logstore.StreamStdout,
+		fmt.Sprintf("Re-attached to running process (pid %d)", pf.PID))
+	slog.Info("adopted running instance", "serviceID", service.ID, "pid", pf.PID)
+	return true, nil
 }
 
+// Dispose stops every instance, or with detach on shutdown only releases
+// them so they keep serving until the next launcher run adopts them. The
+// command workers see the executor context cancelled and are waited for.
 func (lm *LauncherManager) Dispose() error {
+	lm.workersWg.Wait()
 	lm.rwMtx.Lock()
 	defer lm.rwMtx.Unlock()
 
+	if lm.detachOnShutdown {
+		for _, proc := range lm.processList {
+			proc.Release()
+		}
+		// errChan stays open: released processes may still be exiting
+		return nil
+	}
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var combinedErr error
@@ -345,17 +710,19 @@ func (lm *LauncherManager) Dispose() error {
 		combinedErr = errors.Join(combinedErr, err)
 	}
 
-	for _, proc := range lm.processList {
+	for serviceID, proc := range lm.processList {
 		wg.Add(1)
-		go func(p *process.Process) {
+		go func(serviceID string, p *process.Process) {
 			defer wg.Done()
 			if !p.IsRunning() {
 				return
 			}
 			if err := p.Stop(); err != nil {
 				collectError(err)
+				return
 			}
-		}(proc)
+			lm.removePidFile(serviceID)
+		}(serviceID, proc)
 	}
 
 	wg.Wait()
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pb_launcher/helpers/logstore"
+)
+
+// WithServiceStopped stops the service process when it runs, calls fn and
+// starts the service again. It waits for a command of the service being run.
+func (lm *LauncherManager) WithServiceStopped(ctx context.Context, serviceID string, fn func() error) error {
+	mtx := lm.serviceLock(serviceID)
+	mtx.Lock()
+	defer mtx.Unlock()
+	return lm.withServiceStopped(ctx, serviceID, fn)
+}
+
+// withServiceStopped is WithServiceStopped for the command handlers, which
+// already hold the service lock.
+func (lm *LauncherManager) withServiceStopped(ctx context.Context, serviceID string, fn func() error) error {
+	wasRunning := lm.isRunning(serviceID)
+	if wasRunning {
+		lm.lstore.InsertLog(serviceID, logstore.StreamStdout, "Stopping service for maintenance...")
+		if err := lm.stopService(ctx, serviceID); err != nil {
+			return err
+		}
+	}
+
+	fnErr := fn()
+	if !wasRunning {
+		return fnErr
+	}
+
+	service, err := lm.repository.FindService(ctx, serviceID)
+	if err != nil {
+		return errors.Join(fnErr, fmt.Errorf("failed to find service %s: %w", serviceID, err))
+	}
+	return errors.Join(fnErr, lm.startService(ctx, *service))
+}
+
+// EnsureStopped stops the service process when it runs and forgets it. It
+// waits for a command of the service being run.
+func (lm *LauncherManager) EnsureStopped(ctx context.Context, serviceID string) error {
+	mtx := lm.serviceLock(serviceID)
+	mtx.Lock()
+	defer mtx.Unlock()
+	if lm.isRunning(serviceID) {
+		return lm.stopService(ctx, serviceID)
+	}
+	lm.forgetProcess(serviceID)
+	lm.clearPrimaryPid(serviceID)
+	lm.removePidFile(serviceID)
+	return nil
+}
+
+// ServicePids returns the PID serving each running service.
+func (lm *LauncherManager) ServicePids() map[string]int {
+	list := lm.processes()
+	pids := make(map[string]int, len(list))
+	for serviceID, p := range list {
+		if !p.IsRunning() {
+			continue
+		}
+		if pid := p.Pid(); pid > 0 {
+			pids[serviceID] = pid
+		}
+	}
+	return pids
+}
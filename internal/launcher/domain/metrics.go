@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"context"
+	"pb_launcher/helpers/promexport"
+	"pb_launcher/internal/launcher/domain/models"
+)
+
+var serviceStatuses = []models.ServiceStatus{
+	models.Idle,
+	models.Running,
+	models.Stopped,
+	models.Failure,
+	models.CrashLoop,
+	models.Sleeping,
+	models.Starting,
+}
+
+type launcherMetrics struct {
+	failures *promexport.CounterVec
+	restarts *promexport.CounterVec
+}
+
+func (lm *LauncherManager) registerMetrics(registry *promexport.Registry) {
+	lm.metrics = launcherMetrics{
+		failures: registry.NewCounterVec("pb_launcher_service_failures_total",
+			"Unexpected exits of the process serving a service per failure reason.", "service_id", "reason"),
+		restarts: registry.NewCounterVec("pb_launcher_service_restarts_total",
+			"Automatic restarts scheduled after a failure.", "service_id"),
+	}
+	// one series per status set to 1 for the current one, so alerts can
+	// match on the status label without knowing the previous value
+	registry.NewGaugeFunc("pb_launcher_service_status",
+		"Current status of each service (1 for the active status).",
+		[]string{"service_id", "status"},
+		func(ctx context.Context, set func(float64, ...string)) error {
+			services, err := lm.repository.Services(ctx)
+			if err != nil {
+				return err
+			}
+			for _, service := range services {
+				if service.Deleted != "" {
+					continue
+				}
+				for _, status := range serviceStatuses {
+					value := 0.0
+					if service.Status == status {
+						value = 1
+					}
+					set(value, service.ID, string(status))
+				}
+			}
+			return nil
+		})
+}
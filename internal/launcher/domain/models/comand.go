@@ -1,15 +1,61 @@
 package models
 
-type CommandAction int
+import (
+	"encoding/json"
+	"time"
+)
+
+// CommandAction names the handler of a command. The launcher registers the
+// built-in actions below, other modules register their own.
+type CommandAction string
 
 const (
-	ActionStop CommandAction = iota + 1
-	ActionStart
-	ActionRestart
+	ActionStop           CommandAction = "stop"
+	ActionStart          CommandAction = "start"
+	ActionRestart        CommandAction = "restart"
+	ActionReset          CommandAction = "reset"
+	ActionUpgrade        CommandAction = "upgrade"
+	ActionClone          CommandAction = "clone"
+	ActionResetSuperuser CommandAction = "reset-superuser"
+	ActionVacuum         CommandAction = "vacuum"
 )
 
+// CloneOptions adjust the data copied into a cloned service.
+type CloneOptions struct {
+	// DropAuthRecords empties every auth collection but the superusers.
+	DropAuthRecords bool `json:"drop_auth_records"`
+	// RotateSuperuser replaces the copied superusers with SuperuserEmail
+	// and a new random password.
+	RotateSuperuser bool   `json:"rotate_superuser"`
+	SuperuserEmail  string `json:"superuser_email"`
+}
+
+// ResetSuperuserOptions name the superuser that gets a new random password.
+type ResetSuperuserOptions struct {
+	SuperuserEmail string `json:"superuser_email"`
+}
+
 type ServiceCommand struct {
-	ID      string        `json:"id"`
-	Service string        `json:"service"`
-	Action  CommandAction `json:"action"`
+	ID      string          `json:"id"`
+	Service string          `json:"service"`
+	Action  CommandAction   `json:"action"`
+	Release string          `json:"release"` // target release of ActionUpgrade
+	Source  string          `json:"source"`  // copied service of ActionClone
+	Options json.RawMessage `json:"options"` // decoded by the action handler
+	// Timeout bounds a run of the command; zero means no limit.
+	Timeout    time.Duration `json:"timeout"`
+	MaxRetries int           `json:"max_retries"`
+	Attempts   int           `json:"attempts"` // runs before this one
+	// Cause is recorded on the status changes made by the command.
+	Cause   EventCause `json:"cause"`
+	Created time.Time  `json:"created"`
+}
+
+// DecodeOptions unmarshals the options of the command into v; commands
+// without options leave v untouched.
+func (c ServiceCommand) DecodeOptions(v any) error {
+	if len(c.Options) == 0 || string(c.Options) == "null" {
+		return nil
+	}
+	return json.Unmarshal(c.Options, v)
 }
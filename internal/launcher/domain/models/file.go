@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// FileEntry describes a file or directory inside one of the hooks, public or
+// migrations areas of a service. Path is slash separated and relative to the
+// area.
+type FileEntry struct {
+	Path     string    `json:"path"`
+	Dir      bool      `json:"dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
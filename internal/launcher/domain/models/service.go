@@ -1,15 +1,26 @@
 package models
 
-import "regexp"
+import (
+	"regexp"
+	"time"
+)
 
 type ServiceStatus string
 type RestartPolicy string
+type HealthStatus string
+type FailureReason string
 
 const (
 	Idle    ServiceStatus = "idle"    // Created but never started
 	Running ServiceStatus = "running" // Active and running
 	Stopped ServiceStatus = "stopped" // Stopped manually
 	Failure ServiceStatus = "failure" // Stopped manually
+	// Parked after exceeding the allowed restarts within the restart window
+	CrashLoop ServiceStatus = "crashloop"
+	// Stopped after its idle timeout; the proxy starts it on the next request
+	Sleeping ServiceStatus = "sleeping"
+	// Spawned and waiting for /api/health; the proxy holds its requests
+	Starting ServiceStatus = "starting"
 )
 
 const (
@@ -17,17 +28,53 @@ const (
 	Never     RestartPolicy = "no"         // Never restart automatically
 )
 
+const (
+	HealthUnknown   HealthStatus = "unknown"   // Not probed since the last start
+	HealthHealthy   HealthStatus = "healthy"   // Last probe answered 200 OK
+	HealthUnhealthy HealthStatus = "unhealthy" // Last probe failed
+)
+
+const (
+	FailureStartFailed FailureReason = "start_failed" // No port or the process could not be spawned
+	FailureExited      FailureReason = "exited"       // The process exited with an error
+	FailureOOMKilled   FailureReason = "oom_killed"   // Killed after reaching its memory limit
+)
+
 type Service struct {
 	ID            string
 	Status        ServiceStatus
 	RestartPolicy RestartPolicy
+	IP            string
+	Port          int
+	PreferredPort int
+	LastStarted   time.Time
+	IdleTimeout   time.Duration // inactivity before sleeping, 0 when disabled
+	BootPriority  int           // higher values are recovered first at launcher boot
+	//
+	RestartAttempts    int
+	RestartWindowStart time.Time
+	//
+	MemoryLimitMB int     // 0 when unlimited, as the other limits
+	CPULimit      float64 // cores
+	PidsLimit     int
+	NofileLimit   int
+	UID           int // Unix user of the processes when isolated, 0 until assigned
 	//
+	ReleaseID       string
 	RepositoryID    string
 	Version         string
 	ExecFilePattern *regexp.Regexp
+	ArgsTemplate    string // service template, falling back to the repository default
+	TemplateID      string // service_templates record unpacked before the first start
 	//
 	BootPBInstallPath string
 	BootUserEmail     string
 	BootUserPassword  string
 	Deleted           string
 }
+
+type EnvVar struct {
+	Key    string
+	Value  string
+	Secret bool
+}
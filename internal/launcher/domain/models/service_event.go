@@ -0,0 +1,54 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// EventCause tells why the status of a service changed.
+type EventCause string
+
+const (
+	CauseUser        EventCause = "user"         // Command or change made through the API
+	CauseSchedule    EventCause = "schedule"     // Command queued by a service schedule
+	CauseCrash       EventCause = "crash"        // The process exited on its own
+	CauseHealthCheck EventCause = "health_check" // Restart after repeated failed health probes
+	CauseIdle        EventCause = "idle"         // Put to sleep after its idle timeout
+	CauseWake        EventCause = "wake"         // Started by a proxied request
+	CauseSystem      EventCause = "system"       // Launcher boot, shutdown or housekeeping
+)
+
+type causeKey struct{}
+
+// WithCause attaches the cause of the status changes made with ctx.
+func WithCause(ctx context.Context, cause EventCause) context.Context {
+	return context.WithValue(ctx, causeKey{}, cause)
+}
+
+// CauseFrom returns the cause attached to ctx, CauseSystem when none is.
+func CauseFrom(ctx context.Context) EventCause {
+	if ctx != nil {
+		if cause, ok := ctx.Value(causeKey{}).(EventCause); ok && cause != "" {
+			return cause
+		}
+	}
+	return CauseSystem
+}
+
+// ServiceEvent is a status transition of a service.
+type ServiceEvent struct {
+	From         ServiceStatus
+	To           ServiceStatus
+	Cause        EventCause
+	ErrorMessage string
+	Created      time.Time
+}
+
+// ServiceHistory is the status history of a service over a time range.
+type ServiceHistory struct {
+	Created time.Time     // creation of the service
+	Status  ServiceStatus // current status
+	// Before is the last transition before the range, nil when there is none.
+	Before *ServiceEvent
+	Events []ServiceEvent // transitions within the range, oldest first
+}
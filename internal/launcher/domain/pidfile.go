@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"pb_launcher/helpers/process"
+	"pb_launcher/internal/launcher/domain/models"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// launcherStateDir holds the files the launcher keeps about the services
+// inside the data directory. Service IDs never start with a dot, and unlike
+// the service directories it is never handed to a service user, so its
+// content can be trusted.
+const launcherStateDir = ".launcher"
+
+// pidFile records the instance spawned for a service so that the next
+// launcher run can recognise it and adopt it instead of starting a new one.
+type pidFile struct {
+	PID        int       `json:"pid"`
+	Started    time.Time `json:"started"`
+	ListenAddr string    `json:"listen_addr"`
+	Executable string    `json:"executable"`
+	Args       []string  `json:"args"`
+	// Detached instances write their output to files and survive the
+	// launcher; attached ones lose their pipes when it exits.
+	Detached bool   `json:"detached"`
+	LogDir   string `json:"log_dir,omitempty"`
+}
+
+func (lm *LauncherManager) pidFilePath(serviceID string) string {
+	return pidFilePath(lm.dataDir, serviceID)
+}
+
+func pidFilePath(dataDir, serviceID string) string {
+	return path.Join(dataDir, launcherStateDir, "pids", serviceID+".pid")
+}
+
+// logDir is keyed by port so the two processes of a blue/green swap never
+// share their output files. The files are opened by the launcher, so they
+// live in its state directory where the service user cannot plant symlinks.
+func (lm *LauncherManager) logDir(serviceID string, port int) string {
+	return path.Join(lm.logsRoot(serviceID), strconv.Itoa(port))
+}
+
+func (lm *LauncherManager) logsRoot(serviceID string) string {
+	return logsRoot(lm.dataDir, serviceID)
+}
+
+func logsRoot(dataDir, serviceID string) string {
+	return path.Join(dataDir, launcherStateDir, "logs", serviceID)
+}
+
+// trustedLogDir reports whether dir is a log directory of the service in
+// the launcher state directory.
+func (lm *LauncherManager) trustedLogDir(serviceID, dir string) bool {
+	return dir != "" && path.Dir(path.Clean(dir)) == lm.logsRoot(serviceID)
+}
+
+// RemoveServiceState deletes the pidfile and output files the launcher
+// keeps about a service, once its data directory is gone for good.
+func RemoveServiceState(dataDir, serviceID string) error {
+	err := os.Remove(pidFilePath(dataDir, serviceID))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return errors.Join(err, os.RemoveAll(logsRoot(dataDir, serviceID)))
+}
+
+// pruneLogDirs removes the output files of processes other than keep.
+func (lm *LauncherManager) pruneLogDirs(serviceID, keep string) {
+	root := lm.logsRoot(serviceID)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		dir := path.Join(root, entry.Name())
+		if dir == keep {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			slog.Warn("failed to remove log dir", "serviceID", serviceID, "dir", dir, "error", err)
+		}
+	}
+}
+
+func (lm *LauncherManager) writePidFile(serviceID string, pf pidFile) error {
+	data, err := json.Marshal(pf)
+	if err != nil {
+		return err
+	}
+	file := lm.pidFilePath(serviceID)
+	if err := os.MkdirAll(path.Dir(file), 0o700); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// readPidFile returns nil without error when the service has no pidfile.
+func (lm *LauncherManager) readPidFile(serviceID string) (*pidFile, error) {
+	data, err := os.ReadFile(lm.pidFilePath(serviceID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var pf pidFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return nil, fmt.Errorf("invalid pidfile: %w", err)
+	}
+	return &pf, nil
+}
+
+func (lm *LauncherManager) removePidFile(serviceID string) {
+	if err := os.Remove(lm.pidFilePath(serviceID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to remove pidfile", "serviceID", serviceID, "error", err)
+	}
+}
+
+// serviceUID is the user the instances of the service run as.
+func (lm *LauncherManager) serviceUID(service models.Service) int {
+	if lm.isolationMode == "" {
+		return os.Geteuid()
+	}
+	return service.UID
+}
+
+// matches reports whether a live process is still the one recorded in the
+// pidfile and not an unrelated process that reused the PID.
+func (pf pidFile) matches(info process.ProcessInfo) bool {
+	if len(info.Args) == 0 || !slices.Equal(info.Args[1:], pf.Args) {
+		return false
+	}
+	return sameExecutable(pf.Executable, info.Executable)
+}
+
+func sameExecutable(expected, actual string) bool {
+	if expected == actual {
+		return true
+	}
+	// the recorded path may go through a symlink; a binary removed after
+	// the start can no longer be resolved and only matches literally
+	resolved, err := filepath.EvalSymlinks(expected)
+	return err == nil && resolved == actual
+}
+
+func absExecutable(executablePath string) string {
+	if abs, err := filepath.Abs(executablePath); err == nil {
+		return abs
+	}
+	return executablePath
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"pb_launcher/helpers/process"
+	"testing"
+)
+
+func TestPidFileMatches(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "pocketbase")
+	if err := os.WriteFile(binary, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "current")
+	if err := os.Symlink(binary, link); err != nil {
+		t.Fatal(err)
+	}
+
+	pf := pidFile{Executable: link, Args: []string{"serve", "--http", "127.0.0.1:20000"}}
+
+	tests := []struct {
+		name     string
+		info     process.ProcessInfo
+		expected bool
+	}{
+		{"same process", process.ProcessInfo{Executable: binary, Args: []string{link, "serve", "--http", "127.0.0.1:20000"}}, true},
+		{"other binary", process.ProcessInfo{Executable: "/usr/bin/sleep", Args: []string{link, "serve", "--http", "127.0.0.1:20000"}}, false},
+		{"other args", process.ProcessInfo{Executable: binary, Args: []string{link, "serve", "--http", "127.0.0.1:20001"}}, false},
+		{"no args", process.ProcessInfo{Executable: binary}, false},
+	}
+
+	for _, tt := range tests {
+		if got := pf.matches(tt.info); got != tt.expected {
+			t.Errorf("%s: matches() = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestPidFileRoundTrip(t *testing.T) {
+	lm := &LauncherManager{dataDir: t.TempDir()}
+
+	if pf, err := lm.readPidFile("svc"); err != nil || pf != nil {
+		t.Fatalf("expected no pidfile, got %v, %v", pf, err)
+	}
+
+	want := pidFile{PID: 42, ListenAddr: "127.0.0.1:20000", Executable: "/bin/pb", Args: []string{"serve"}, Detached: true}
+	if err := lm.writePidFile("svc", want); err != nil {
+		t.Fatalf("failed to write pidfile: %v", err)
+	}
+	got, err := lm.readPidFile("svc")
+	if err != nil || got == nil {
+		t.Fatalf("failed to read pidfile: %v", err)
+	}
+	if got.PID != want.PID || got.ListenAddr != want.ListenAddr || !got.Detached {
+		t.Fatalf("unexpected pidfile: %+v", got)
+	}
+
+	if _, err := os.Stat(filepath.Join(lm.dataDir, ".launcher", "pids", "svc.pid")); err != nil {
+		t.Fatalf("pidfile should live outside the service directory: %v", err)
+	}
+
+	lm.removePidFile("svc")
+	if pf, _ := lm.readPidFile("svc"); pf != nil {
+		t.Fatalf("pidfile should be removed")
+	}
+}
+
+func TestTrustedLogDir(t *testing.T) {
+	lm := &LauncherManager{dataDir: "/data"}
+
+	if dir := lm.logDir("svc", 20000); !lm.trustedLogDir("svc", dir) {
+		t.Fatalf("%s should be trusted", dir)
+	}
+	for _, dir := range []string{"", "/data/svc/logs/20000", "/data/.launcher/logs/other/20000", "/data/.launcher/logs/svc/../../../etc"} {
+		if lm.trustedLogDir("svc", dir) {
+			t.Errorf("%q should not be trusted", dir)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/utils/networktools"
+)
+
+var ErrPinnedPortUnavailable = errors.New("pinned port unavailable")
+
+const maxRandomPortAttempts = 10
+
+// allocatePort picks the listen port for a service start: the pinned
+// preferred_port when set, otherwise the last used port when it is still
+// free, otherwise a new port from the configured range (or any free port).
+// It must be called with launchMtx held.
+func (lm *LauncherManager) allocatePort(ctx context.Context, service models.Service) (string, int, error) {
+	reserved, err := lm.repository.ReservedPorts(ctx, service.ID)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to load reserved ports: %w", err)
+	}
+	for port, owner := range lm.portClaims {
+		reserved[port] = owner
+	}
+
+	if service.PreferredPort > 0 {
+		port := service.PreferredPort
+		if owner, taken := reserved[port]; taken {
+			return "", 0, fmt.Errorf("%w: port %d is reserved by service %s", ErrPinnedPortUnavailable, port, owner)
+		}
+		if !networktools.IsPortAvailable(lm.ipAddress, port) {
+			return "", 0, fmt.Errorf("%w: port %d is already in use on %s", ErrPinnedPortUnavailable, port, lm.ipAddress)
+		}
+		return lm.ipAddress, port, nil
+	}
+
+	excluded := make(map[int]struct{}, len(reserved))
+	for port := range reserved {
+		excluded[port] = struct{}{}
+	}
+
+	if last := service.Port; last > 0 && lm.inPortRange(last) {
+		if _, taken := excluded[last]; !taken && networktools.IsPortAvailable(lm.ipAddress, last) {
+			return lm.ipAddress, last, nil
+		}
+	}
+
+	if lm.portRangeStart > 0 {
+		return networktools.GetAvailablePortInRange(lm.ipAddress, lm.portRangeStart, lm.portRangeEnd, excluded)
+	}
+
+	for range maxRandomPortAttempts {
+		ip, port, err := networktools.GetAvailablePort(lm.ipAddress)
+		if err != nil {
+			return "", 0, err
+		}
+		if _, taken := excluded[port]; !taken {
+			return ip, port, nil
+		}
+	}
+	return "", 0, networktools.ErrNoPortAvailable
+}
+
+func (lm *LauncherManager) inPortRange(port int) bool {
+	if lm.portRangeStart == 0 {
+		return true
+	}
+	return port >= lm.portRangeStart && port <= lm.portRangeEnd
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"pb_launcher/helpers/process"
+	"sync"
+)
+
+// Commands of different services run concurrently, each service in its own
+// worker. The state they share is guarded here:
+//   - rwMtx guards the processList map itself.
+//   - launchMtx serializes spawns, from allocating the port and UID to
+//     recording them, so two services never pick the same ones.
+//   - serviceLocks hold off other work on a service, such as putting it to
+//     sleep, while one of its commands runs.
+
+func (lm *LauncherManager) process(serviceID string) (*process.Process, bool) {
+	lm.rwMtx.RLock()
+	defer lm.rwMtx.RUnlock()
+	p, ok := lm.processList[serviceID]
+	return p, ok
+}
+
+// isRunning reports whether the service has a live process.
+func (lm *LauncherManager) isRunning(serviceID string) bool {
+	p, ok := lm.process(serviceID)
+	return ok && p.IsRunning()
+}
+
+func (lm *LauncherManager) setProcess(serviceID string, p *process.Process) {
+	lm.rwMtx.Lock()
+	defer lm.rwMtx.Unlock()
+	lm.processList[serviceID] = p
+}
+
+func (lm *LauncherManager) forgetProcess(serviceID string) {
+	lm.rwMtx.Lock()
+	defer lm.rwMtx.Unlock()
+	delete(lm.processList, serviceID)
+}
+
+// processes returns a copy of the process list.
+func (lm *LauncherManager) processes() map[string]*process.Process {
+	lm.rwMtx.RLock()
+	defer lm.rwMtx.RUnlock()
+	list := make(map[string]*process.Process, len(lm.processList))
+	for serviceID, p := range lm.processList {
+		list[serviceID] = p
+	}
+	return list
+}
+
+// serviceLock returns the lock held while work on the service runs.
+func (lm *LauncherManager) serviceLock(serviceID string) *sync.Mutex {
+	lm.serviceLocksMtx.Lock()
+	defer lm.serviceLocksMtx.Unlock()
+	mtx, ok := lm.serviceLocks[serviceID]
+	if !ok {
+		mtx = &sync.Mutex{}
+		lm.serviceLocks[serviceID] = mtx
+	}
+	return mtx
+}
@@ -0,0 +1,211 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/internal/launcher/domain/models"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RecoveryProgress describes the restore of the services that were running
+// before the launcher stopped.
+type RecoveryProgress struct {
+	Running  bool
+	Total    int
+	Done     int // adopted, started or failed
+	Failures []RecoveryFailure
+	Started  time.Time
+	Finished time.Time
+}
+
+type RecoveryFailure struct {
+	ServiceID string
+	Error     string
+}
+
+type recoveryTracker struct {
+	mu       sync.Mutex
+	progress RecoveryProgress
+}
+
+func (t *recoveryTracker) begin(total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress = RecoveryProgress{Running: true, Total: total, Started: time.Now()}
+}
+
+func (t *recoveryTracker) done(serviceID string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.Done++
+	if err != nil {
+		t.progress.Failures = append(t.progress.Failures, RecoveryFailure{
+			ServiceID: serviceID,
+			Error:     err.Error(),
+		})
+	}
+}
+
+func (t *recoveryTracker) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.progress.Running = false
+	t.progress.Finished = time.Now()
+}
+
+func (t *recoveryTracker) snapshot() RecoveryProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	progress := t.progress
+	progress.Failures = slices.Clone(t.progress.Failures)
+	return progress
+}
+
+// RecoveryProgress reports the state of the boot recovery.
+func (lm *LauncherManager) RecoveryProgress() RecoveryProgress {
+	return lm.recovery.snapshot()
+}
+
+// recoveryOrder sorts services by descending boot priority; services with
+// the same priority keep the repository order.
+func recoveryOrder(services []models.Service) {
+	slices.SortStableFunc(services, func(a, b models.Service) int {
+		return b.BootPriority - a.BootPriority
+	})
+}
+
+// RecoveryLastState restores all services that were active before
+// pb_launcher was shut down. Instances still running are adopted before it
+// returns; the rest are started in the background by a pool of
+// recovery_concurrency workers in boot priority order, RecoveryProgress
+// reports how far they got. A failed start is recorded and does not hold
+// the others, and commands run meanwhile. Commands cut short by the
+// shutdown run again when they have retries left.
+func (lm *LauncherManager) RecoveryLastState(ctx context.Context) error {
+	if err := lm.comandsRepository.RequeueInterruptedCommands(ctx); err != nil {
+		slog.Error("failed to requeue interrupted commands", "error", err)
+	}
+	services, err := lm.repository.Services(ctx)
+	if err != nil {
+		slog.Error("Failed to retrieve running services", "error", err)
+		return err
+	}
+
+	// starting services were interrupted by the previous shutdown
+	services = slices.DeleteFunc(services, func(service models.Service) bool {
+		return service.Deleted != "" ||
+			(service.Status != models.Running && service.Status != models.Starting)
+	})
+	recoveryOrder(services)
+	lm.recovery.begin(len(services))
+
+	var pending []models.Service
+	for _, service := range services {
+		adopted, err := lm.adoptService(ctx, service)
+		if err != nil {
+			slog.Error("failed to adopt service", "serviceID", service.ID, "error", err)
+		}
+		if adopted {
+			if service.Status == models.Starting {
+				// the health probe takes over from here
+				if err := lm.repository.MarkServiceReady(ctx, service.ID); err != nil {
+					slog.Error("failed to mark adopted service as running", "serviceID", service.ID, "error", err)
+				}
+			}
+			lm.recovery.done(service.ID, nil)
+			continue
+		}
+		pending = append(pending, service)
+	}
+
+	// Dispose waits for the recovery like for the command workers
+	lm.workersWg.Add(1)
+	go func() {
+		defer lm.workersWg.Done()
+		lm.recoverServices(ctx, pending)
+		lm.recovery.finish()
+		progress := lm.recovery.snapshot()
+		slog.Info("service recovery finished",
+			"services", progress.Total,
+			"failed", len(progress.Failures),
+			"elapsed", time.Since(progress.Started),
+		)
+	}()
+	return nil
+}
+
+func (lm *LauncherManager) recoverServices(ctx context.Context, services []models.Service) {
+	queue := make(chan models.Service)
+	var wg sync.WaitGroup
+	for range min(lm.recoveryConcurrency, len(services)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for service := range queue {
+				err := lm.recoverPending(ctx, service.ID)
+				if err != nil {
+					slog.Error("failed to recover service", "serviceID", service.ID, "error", err)
+				}
+				lm.recovery.done(service.ID, err)
+			}
+		}()
+	}
+
+dispatch:
+	for _, service := range services {
+		select {
+		case queue <- service:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// recoverPending recovers a service under its lock. Commands run during the
+// recovery, so a service one of them started, stopped or deleted meanwhile
+// is left as it is.
+func (lm *LauncherManager) recoverPending(ctx context.Context, serviceID string) error {
+	mtx := lm.serviceLock(serviceID)
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	service, err := lm.repository.FindService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	if service.Deleted != "" || lm.isRunning(serviceID) ||
+		(service.Status != models.Running && service.Status != models.Starting) {
+		return nil
+	}
+	return lm.recoverService(ctx, *service)
+}
+
+// recoverService starts the service and waits until it answers
+// /api/health; one that is not ready in time is stopped and marked as
+// failure like any other start. Launches are serialized by launchMtx, so
+// only the wait for readiness runs in parallel.
+func (lm *LauncherManager) recoverService(ctx context.Context, service models.Service) error {
+	starting, err := lm.launchService(ctx, service)
+	if err != nil {
+		// a missing binary or broken env would otherwise leave the service
+		// marked as running without a process; launchService marks port and
+		// process start failures itself
+		if current, findErr := lm.repository.FindService(ctx, service.ID); findErr == nil && current.Status == models.Failure {
+			return err
+		}
+		if markErr := lm.repository.MarkServiceFailure(ctx, service.ID, models.FailureStartFailed, err.Error()); markErr != nil {
+			slog.Error("failed to mark service as failed", "serviceID", service.ID, "error", markErr)
+		}
+		return err
+	}
+
+	if err := lm.awaitReady(ctx, starting, lm.recoveryReadyTimeout); err != nil {
+		lm.abortStart(ctx, starting, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/internal/launcher/domain/repositories"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRecoveryOrder(t *testing.T) {
+	services := []models.Service{
+		{ID: "a"},
+		{ID: "b", BootPriority: 10},
+		{ID: "c", BootPriority: -1},
+		{ID: "d"},
+		{ID: "e", BootPriority: 10},
+	}
+	recoveryOrder(services)
+
+	var got []string
+	for _, service := range services {
+		got = append(got, service.ID)
+	}
+	expected := []string{"b", "e", "a", "d", "c"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("recoveryOrder() = %v, want %v", got, expected)
+	}
+}
+
+func TestRecoveryTracker(t *testing.T) {
+	var tracker recoveryTracker
+	tracker.begin(3)
+	tracker.done("a", nil)
+	tracker.done("b", errors.New("no port available"))
+
+	progress := tracker.snapshot()
+	if !progress.Running || progress.Total != 3 || progress.Done != 2 {
+		t.Fatalf("unexpected progress %+v", progress)
+	}
+	if len(progress.Failures) != 1 || progress.Failures[0].ServiceID != "b" {
+		t.Fatalf("unexpected failures %+v", progress.Failures)
+	}
+
+	tracker.done("c", nil)
+	tracker.finish()
+	progress = tracker.snapshot()
+	if progress.Running || progress.Done != 3 || progress.Finished.IsZero() {
+		t.Errorf("unexpected progress after finish %+v", progress)
+	}
+}
+
+// failingPortServices fails every port allocation and records the
+// failures marked on the service.
+type failingPortServices struct {
+	repositories.ServiceRepository
+	mu       sync.Mutex
+	failures int
+}
+
+func (s *failingPortServices) ReservedPorts(ctx context.Context, id string) (map[int]string, error) {
+	return nil, errors.New("database is locked")
+}
+
+func (s *failingPortServices) FindService(ctx context.Context, id string) (*models.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := models.Running
+	if s.failures > 0 {
+		status = models.Failure
+	}
+	return &models.Service{ID: id, Status: status}, nil
+}
+
+func (s *failingPortServices) MarkServiceFailure(ctx context.Context, id string, reason models.FailureReason, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures++
+	return nil
+}
+
+func TestRecoverServiceMarksFailureOnce(t *testing.T) {
+	services := &failingPortServices{}
+	lm := &LauncherManager{repository: services, portClaims: make(map[int]string)}
+
+	if err := lm.recoverService(context.Background(), models.Service{ID: "a", Status: models.Running}); err == nil {
+		t.Fatal("expected the port allocation error")
+	}
+	if services.failures != 1 {
+		t.Errorf("service marked as failure %d times, want 1", services.failures)
+	}
+}
+
+// stoppedDuringRecovery lists a running service, which a command stops
+// while the recovery is under way.
+type stoppedDuringRecovery struct {
+	repositories.ServiceRepository
+	stopped chan struct{}
+}
+
+func (s *stoppedDuringRecovery) Services(ctx context.Context) ([]models.Service, error) {
+	return []models.Service{{ID: "a", Status: models.Running}}, nil
+}
+
+func (s *stoppedDuringRecovery) FindService(ctx context.Context, id string) (*models.Service, error) {
+	<-s.stopped
+	return &models.Service{ID: id, Status: models.Stopped}, nil
+}
+
+type requeueCommands struct {
+	repositories.CommandsRepository
+}
+
+func (requeueCommands) RequeueInterruptedCommands(ctx context.Context) error { return nil }
+
+func TestRecoveryLastStateRunsInTheBackground(t *testing.T) {
+	services := &stoppedDuringRecovery{stopped: make(chan struct{})}
+	lm := &LauncherManager{
+		dataDir:             t.TempDir(),
+		repository:          services,
+		comandsRepository:   requeueCommands{},
+		recoveryConcurrency: 2,
+		serviceLocks:        make(map[string]*sync.Mutex),
+	}
+
+	if err := lm.RecoveryLastState(context.Background()); err != nil {
+		t.Fatalf("RecoveryLastState() error = %v", err)
+	}
+	if progress := lm.RecoveryProgress(); !progress.Running || progress.Done != 0 {
+		t.Fatalf("unexpected progress while recovering %+v", progress)
+	}
+
+	// the stopped service is not started again
+	close(services.stopped)
+	lm.workersWg.Wait()
+	progress := lm.RecoveryProgress()
+	if progress.Running || progress.Done != 1 || len(progress.Failures) != 0 {
+		t.Errorf("unexpected progress after recovery %+v", progress)
+	}
+}
@@ -3,11 +3,30 @@ package repositories
 import (
 	"context"
 	"pb_launcher/internal/launcher/domain/models"
+	"time"
 )
 
 type CommandsRepository interface {
-	PublishStartComand(ctx context.Context, serviceID string) error
+	// PublishStartComand queues the delayed restart of a crashed service.
+	PublishStartComand(ctx context.Context, serviceID string, notBefore time.Time) error
+	// CancelBackoffStarts cancels the pending restarts queued by
+	// PublishStartComand after a crash, recording reason on them.
+	CancelBackoffStarts(ctx context.Context, serviceID string, reason string) error
+	PublishRestartComand(ctx context.Context, serviceID string) error
 	GetPendingCommands(ctx context.Context) ([]models.ServiceCommand, error)
-	MarkCommandSuccess(ctx context.Context, id string) error
+	// ClaimCommand marks a pending command as running and counts the
+	// attempt. It reports false when the command is no longer pending,
+	// e.g. because it was cancelled.
+	ClaimCommand(ctx context.Context, id string) (bool, error)
+	// RetryCommand puts a failed command back to pending until notBefore.
+	RetryCommand(ctx context.Context, id string, errorMessage string, notBefore time.Time) error
+	// CancelSupersededCommand cancels a pending command when a newer
+	// command of its service already ran, e.g. a retried stop after a later
+	// start. It reports whether the command was cancelled.
+	CancelSupersededCommand(ctx context.Context, cmd models.ServiceCommand) (bool, error)
+	// RequeueInterruptedCommands returns the commands left running by the
+	// previous shutdown to pending, or fails them once out of retries.
+	RequeueInterruptedCommands(ctx context.Context) error
+	MarkCommandSuccess(ctx context.Context, id string, result any) error
 	MarkCommandError(ctx context.Context, id string, errorMessage string) error
 }
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+	"pb_launcher/internal/launcher/domain/models"
+	"time"
+)
+
+type ServiceEventsRepository interface {
+	// ServiceHistory returns the status transitions of a service between
+	// from and to, with the last one before from.
+	ServiceHistory(ctx context.Context, serviceID string, from, to time.Time) (*models.ServiceHistory, error)
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"pb_launcher/internal/launcher/domain/models"
+	"time"
 )
 
 type ServiceRepository interface {
@@ -11,9 +12,25 @@ type ServiceRepository interface {
 	FindService(ctx context.Context, id string) (*models.Service, error)
 
 	MarkServiceStoped(ctx context.Context, id string) error
-	MarkServiceFailure(ctx context.Context, id string, errorMessage string) error
+	MarkServiceSleeping(ctx context.Context, id string) error
+	MarkServiceFailure(ctx context.Context, id string, reason models.FailureReason, errorMessage string) error
 	MarkServiceRunning(ctx context.Context, id string, listenIplistenIp, port string) error
+	MarkServiceStarting(ctx context.Context, id string, listenIp, port string) error
+	// MarkServiceReady moves a starting service to running; services that
+	// left starting meanwhile (e.g. the process exited) are not changed.
+	MarkServiceReady(ctx context.Context, id string) error
+	UpdateServiceHealth(ctx context.Context, id string, status models.HealthStatus, failures int) error
+	MarkServiceCrashLoop(ctx context.Context, id string, errorMessage string) error
+	UpdateRestartAttempts(ctx context.Context, id string, attempts int, windowStart time.Time) error
+	ResetRestartAttempts(ctx context.Context, id string) error
 	SetServiceInstallToken(ctx context.Context, serviceID string, _pb_install string) error
 	CleanServiceInstallToken(ctx context.Context, _pb_install string) error
 	UpdateSuperuser(ctx context.Context, serviceID, email, password string) error
+	SetServiceRelease(ctx context.Context, id, releaseID string) error
+	ServiceEnvironment(ctx context.Context, serviceID string) ([]models.EnvVar, error)
+	// ReservedPorts returns the ports held or pinned by every other live service, keyed by port.
+	ReservedPorts(ctx context.Context, excludeServiceID string) (map[int]string, error)
+	// AssignedUIDs returns the UIDs of every other service, trashed ones included, keyed by UID.
+	AssignedUIDs(ctx context.Context, excludeServiceID string) (map[int]string, error)
+	SetServiceUID(ctx context.Context, id string, uid int) error
 }
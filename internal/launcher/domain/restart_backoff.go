@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/internal/launcher/domain/models"
+	"time"
+)
+
+// RestartBackoff returns the delay before the given restart attempt (1-based).
+// The delay starts at base, doubles on every attempt and never exceeds max.
+func RestartBackoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt <= 1 {
+		return min(base, max)
+	}
+	delay := base
+	for range attempt - 1 {
+		delay *= 2
+		if delay >= max || delay <= 0 {
+			return max
+		}
+	}
+	return delay
+}
+
+// scheduleRestart publishes a delayed start command for a failed service, or
+// parks it as crashloop once it exceeded the allowed restarts in the window.
+func (lm *LauncherManager) scheduleRestart(ctx context.Context, service models.Service, errorMessage string) error {
+	now := time.Now()
+	attempts := service.RestartAttempts
+	windowStart := service.RestartWindowStart
+	if windowStart.IsZero() || now.Sub(windowStart) > lm.restartWindow {
+		attempts = 0
+		windowStart = now
+	}
+	attempts++
+
+	if attempts > lm.maxRestarts {
+		reason := fmt.Sprintf("crash loop detected: %d restarts within %s, last error: %s",
+			lm.maxRestarts, lm.restartWindow, errorMessage)
+		lm.lstore.InsertLog(service.ID, logstore.StreamStderr, reason)
+		return lm.repository.MarkServiceCrashLoop(ctx, service.ID, reason)
+	}
+
+	if err := lm.repository.UpdateRestartAttempts(ctx, service.ID, attempts, windowStart); err != nil {
+		return err
+	}
+
+	delay := RestartBackoff(attempts, lm.restartBackoffBase, lm.restartBackoffMax)
+	lm.lstore.InsertLog(service.ID, logstore.StreamStdout,
+		fmt.Sprintf("Restart attempt %d/%d scheduled in %s", attempts, lm.maxRestarts, delay))
+	slog.Info("scheduling service restart",
+		"serviceID", service.ID,
+		"attempt", attempts,
+		"delay", delay,
+	)
+	if err := lm.comandsRepository.PublishStartComand(ctx, service.ID, now.Add(delay)); err != nil {
+		return err
+	}
+	lm.metrics.restarts.Inc(service.ID)
+	return nil
+}
+
+// cancelBackoffStarts drops the restarts scheduled after a crash, an explicit
+// start, stop or deletion of the service supersedes them.
+func (lm *LauncherManager) cancelBackoffStarts(ctx context.Context, serviceID, reason string) {
+	if err := lm.comandsRepository.CancelBackoffStarts(ctx, serviceID, reason); err != nil {
+		slog.Error("failed to cancel scheduled restarts", "serviceID", serviceID, "error", err)
+	}
+}
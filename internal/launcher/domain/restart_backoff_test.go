@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	base := 5 * time.Second
+	maxDelay := time.Minute
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, time.Minute},
+		{50, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := RestartBackoff(tt.attempt, base, maxDelay); got != tt.expected {
+			t.Errorf("RestartBackoff(%d) = %v, want %v", tt.attempt, got, tt.expected)
+		}
+	}
+}
+
+func TestRestartBackoff_BaseAboveMax(t *testing.T) {
+	if got := RestartBackoff(1, time.Hour, time.Minute); got != time.Minute {
+		t.Errorf("RestartBackoff() = %v, want %v", got, time.Minute)
+	}
+}
@@ -0,0 +1,512 @@
+package domain
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"pb_launcher/configs"
+	"pb_launcher/internal/launcher/domain/models"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	ErrInvalidFileArea = errors.New("invalid file area, expected hooks, public or migrations")
+	ErrInvalidFilePath = errors.New("invalid file path")
+	ErrFileTooLarge    = errors.New("file exceeds the upload size limit")
+	ErrFileAreaFull    = errors.New("file area size limit exceeded")
+	ErrFileExists      = errors.New("file already exists")
+)
+
+// ServiceFiles manages the hooks, public and migrations directories of the
+// services. Every path is resolved inside its area through os.Root, so API
+// callers can never reach pb_data or the directories of other services, and
+// symlinks placed by the service user cannot lead the launcher out of the
+// area either.
+type ServiceFiles struct {
+	dataDir     string
+	maxFileSize int64
+	maxAreaSize int64
+}
+
+func NewServiceFiles(c configs.Config) *ServiceFiles {
+	return &ServiceFiles{
+		dataDir:     c.GetDataDir(),
+		maxFileSize: c.GetFilesMaxSize(),
+		maxAreaSize: c.GetFilesMaxAreaSize(),
+	}
+}
+
+// MaxFileSize is the largest accepted upload in bytes.
+func (sf *ServiceFiles) MaxFileSize() int64 { return sf.maxFileSize }
+
+// areaDir returns the service directory and the name of the area in it.
+func (sf *ServiceFiles) areaDir(serviceID, area string) (string, string, error) {
+	if serviceID == "" || strings.ContainsAny(serviceID, `/\.`) {
+		return "", "", ErrInvalidFilePath
+	}
+	switch area {
+	case "hooks", "public", "migrations":
+		return NewLaunchArgs(sf.dataDir, serviceID, "").BaseDir, area, nil
+	}
+	return "", "", ErrInvalidFileArea
+}
+
+// openArea opens the area as the root of every access below it. The service
+// directory itself cannot be replaced by its user, only what it contains.
+// With create the area is created when missing.
+func (sf *ServiceFiles) openArea(serviceID, area string, create bool) (*os.Root, error) {
+	baseDir, name, err := sf.areaDir(serviceID, area)
+	if err != nil {
+		return nil, err
+	}
+	if create {
+		if err := os.MkdirAll(baseDir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	base, err := os.OpenRoot(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	defer base.Close()
+	if create {
+		if err := base.Mkdir(name, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+	}
+	return base.OpenRoot(name)
+}
+
+// cleanName maps a slash separated path to a path relative to the area,
+// with the same checks Unzip applies to archive entries. The area itself
+// (".") is only accepted when allowRoot is set.
+func cleanName(name string, allowRoot bool) (string, error) {
+	if strings.ContainsRune(name, 0) || strings.Contains(name, `\`) {
+		return "", ErrInvalidFilePath
+	}
+	rel := path.Join(".", name)
+	if !insideArea(rel) {
+		return "", ErrInvalidFilePath
+	}
+	if rel == "." && !allowRoot {
+		return "", ErrInvalidFilePath
+	}
+	return rel, nil
+}
+
+func insideArea(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// Stat reports the entry at name; an empty name is the area itself.
+func (sf *ServiceFiles) Stat(serviceID, area, name string) (models.FileEntry, error) {
+	rel, err := cleanName(name, true)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	root, err := sf.openArea(serviceID, area, false)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && rel == "." {
+			return models.FileEntry{Path: "", Dir: true}, nil
+		}
+		return models.FileEntry{}, err
+	}
+	defer root.Close()
+	info, err := root.Stat(rel)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	return toFileEntry(rel, info), nil
+}
+
+// Open returns the file at name for reading, directories are rejected.
+func (sf *ServiceFiles) Open(serviceID, area, name string) (*os.File, models.FileEntry, error) {
+	rel, err := cleanName(name, false)
+	if err != nil {
+		return nil, models.FileEntry{}, err
+	}
+	root, err := sf.openArea(serviceID, area, false)
+	if err != nil {
+		return nil, models.FileEntry{}, err
+	}
+	defer root.Close()
+	f, err := root.Open(rel)
+	if err != nil {
+		return nil, models.FileEntry{}, err
+	}
+	info, err := f.Stat()
+	if err == nil && info.IsDir() {
+		err = fmt.Errorf("%w: %s is a directory", ErrInvalidFilePath, name)
+	}
+	if err != nil {
+		f.Close()
+		return nil, models.FileEntry{}, err
+	}
+	return f, toFileEntry(rel, info), nil
+}
+
+// List walks the directory name recursively. A missing area is reported as
+// empty, it is only created on the first upload.
+func (sf *ServiceFiles) List(serviceID, area, name string) ([]models.FileEntry, error) {
+	dir, err := cleanName(name, true)
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.FileEntry{}
+	root, err := sf.openArea(serviceID, area, false)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && dir == "." {
+			return entries, nil
+		}
+		return nil, err
+	}
+	defer root.Close()
+	err = fs.WalkDir(root.FS(), dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, toFileEntry(p, info))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+func toFileEntry(rel string, info fs.FileInfo) models.FileEntry {
+	if rel == "." {
+		rel = ""
+	}
+	entry := models.FileEntry{
+		Path:     rel,
+		Dir:      info.IsDir(),
+		Modified: info.ModTime(),
+	}
+	if !entry.Dir {
+		entry.Size = info.Size()
+	}
+	return entry
+}
+
+// Write stores reader at name, replacing an existing file atomically.
+func (sf *ServiceFiles) Write(serviceID, area, name string, reader io.Reader) (models.FileEntry, error) {
+	rel, err := cleanName(name, false)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	root, err := sf.openArea(serviceID, area, true)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	defer root.Close()
+	if info, err := root.Stat(rel); err == nil && info.IsDir() {
+		return models.FileEntry{}, ErrFileExists
+	}
+	used, err := areaSize(root)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	used -= fileSize(root, rel)
+
+	if err := mkdirAll(root, path.Dir(rel)); err != nil {
+		return models.FileEntry{}, err
+	}
+	// the upload is written and renamed through the same directory handle
+	parent, err := root.OpenRoot(path.Dir(rel))
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	defer parent.Close()
+	tmpName := ".upload-" + rand.Text()
+	tmp, err := parent.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	defer parent.Remove(tmpName)
+
+	limit := min(sf.maxFileSize, sf.maxAreaSize-used)
+	size, err := io.Copy(tmp, io.LimitReader(reader, limit+1))
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	if size > sf.maxFileSize {
+		return models.FileEntry{}, ErrFileTooLarge
+	}
+	if size > limit {
+		return models.FileEntry{}, ErrFileAreaFull
+	}
+	if err := renameAt(parent, tmpName, parent, path.Base(rel)); err != nil {
+		return models.FileEntry{}, err
+	}
+	info, err := root.Stat(rel)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	return toFileEntry(rel, info), nil
+}
+
+// Extract unpacks the zip archive read from reader into the directory name
+// of the area. The uncompressed size is checked against the area limit
+// before anything is written.
+func (sf *ServiceFiles) Extract(serviceID, area, name string, reader io.Reader) ([]string, error) {
+	dir, err := cleanName(name, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := sf.areaDir(serviceID, area); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp("", "pb-files-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, io.LimitReader(reader, sf.maxFileSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size > sf.maxFileSize {
+		return nil, ErrFileTooLarge
+	}
+
+	r, err := zip.OpenReader(tmp.Name())
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+	defer r.Close()
+
+	root, err := sf.openArea(serviceID, area, true)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	if err := sf.checkArchive(root, &r.Reader, dir); err != nil {
+		return nil, err
+	}
+	return extractArchive(root, &r.Reader, dir)
+}
+
+func (sf *ServiceFiles) checkArchive(root *os.Root, r *zip.Reader, dir string) error {
+	used, err := areaSize(root)
+	if err != nil {
+		return err
+	}
+	for _, f := range r.File {
+		target := path.Join(dir, f.Name)
+		if strings.ContainsRune(f.Name, 0) || !insideArea(target) {
+			return fmt.Errorf("%w: %s", ErrInvalidFilePath, f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return fmt.Errorf("%w: %s is not a regular file", ErrInvalidFilePath, f.Name)
+		}
+		// archive/zip fails reading an entry that is larger than its header
+		used += int64(f.UncompressedSize64) - fileSize(root, target)
+	}
+	if used > sf.maxAreaSize {
+		return ErrFileAreaFull
+	}
+	return nil
+}
+
+// extractArchive writes the entries checked by checkArchive below dir.
+func extractArchive(root *os.Root, r *zip.Reader, dir string) ([]string, error) {
+	if err := mkdirAll(root, dir); err != nil {
+		return nil, err
+	}
+	var extracted []string
+	for _, f := range r.File {
+		target := path.Join(dir, f.Name)
+		var err error
+		if f.FileInfo().IsDir() {
+			err = mkdirAll(root, target)
+		} else if err = mkdirAll(root, path.Dir(target)); err == nil {
+			err = extractFile(root, target, f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract file %s: %w", f.Name, err)
+		}
+		extracted = append(extracted, f.Name)
+	}
+	return extracted, nil
+}
+
+func extractFile(root *os.Root, target string, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	dst, err := root.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, rc)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Rename moves a file or directory inside the area, it never overwrites.
+func (sf *ServiceFiles) Rename(serviceID, area, from, to string) error {
+	source, err := cleanName(from, false)
+	if err != nil {
+		return err
+	}
+	target, err := cleanName(to, false)
+	if err != nil {
+		return err
+	}
+	root, err := sf.openArea(serviceID, area, false)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	if _, err := root.Lstat(source); err != nil {
+		return err
+	}
+	if _, err := root.Lstat(target); err == nil {
+		return ErrFileExists
+	}
+	if strings.HasPrefix(target, source+"/") {
+		return fmt.Errorf("%w: cannot move a directory into itself", ErrInvalidFilePath)
+	}
+	if err := mkdirAll(root, path.Dir(target)); err != nil {
+		return err
+	}
+	return renameAt(root, source, root, target)
+}
+
+// Delete removes a file or a whole directory; the area itself is kept.
+func (sf *ServiceFiles) Delete(serviceID, area, name string) error {
+	rel, err := cleanName(name, false)
+	if err != nil {
+		return err
+	}
+	root, err := sf.openArea(serviceID, area, false)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	if _, err := root.Lstat(rel); err != nil {
+		return err
+	}
+	return removeAll(root, rel)
+}
+
+// mkdirAll is os.MkdirAll below root.
+func mkdirAll(root *os.Root, dir string) error {
+	if dir == "." {
+		return nil
+	}
+	if err := mkdirAll(root, path.Dir(dir)); err != nil {
+		return err
+	}
+	if err := root.Mkdir(dir, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+// removeAll is os.RemoveAll below root; symlinks are removed, not followed.
+func removeAll(root *os.Root, name string) error {
+	info, err := root.Lstat(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.IsDir() {
+		entries, err := fs.ReadDir(root.FS(), name)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := removeAll(root, path.Join(name, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	if err := root.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// renameAt renames from in fromRoot to to in toRoot. The parent directories
+// are opened through the roots and rename never follows the last component.
+func renameAt(fromRoot *os.Root, from string, toRoot *os.Root, to string) error {
+	fromDir, err := fromRoot.Open(path.Dir(from))
+	if err != nil {
+		return err
+	}
+	defer fromDir.Close()
+	toDir, err := toRoot.Open(path.Dir(to))
+	if err != nil {
+		return err
+	}
+	defer toDir.Close()
+	err = unix.Renameat(int(fromDir.Fd()), path.Base(from), int(toDir.Fd()), path.Base(to))
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: err}
+	}
+	return nil
+}
+
+func areaSize(root *os.Root) (int64, error) {
+	var total int64
+	err := fs.WalkDir(root.FS(), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+func fileSize(root *os.Root, name string) int64 {
+	info, err := root.Stat(name)
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServiceFiles(t *testing.T) (*ServiceFiles, LaunchArgs) {
+	dataDir := t.TempDir()
+	sf := &ServiceFiles{dataDir: dataDir, maxFileSize: 512, maxAreaSize: 600}
+	return sf, NewLaunchArgs(dataDir, "svc", "")
+}
+
+func zipArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return &buf
+}
+
+func TestServiceFilesCleanName(t *testing.T) {
+	rel, err := cleanName("lib/utils.js", false)
+	require.NoError(t, err)
+	require.Equal(t, "lib/utils.js", rel)
+
+	// a leading slash stays inside the area
+	rel, err = cleanName("/index.html", false)
+	require.NoError(t, err)
+	require.Equal(t, "index.html", rel)
+
+	rel, err = cleanName("", true)
+	require.NoError(t, err)
+	require.Equal(t, ".", rel)
+
+	for _, name := range []string{"../pb_data/data.db", "a/../../x", "..", "", "a\\..\\..\\x"} {
+		_, err := cleanName(name, false)
+		require.ErrorIs(t, err, ErrInvalidFilePath, name)
+	}
+
+	sf, _ := newTestServiceFiles(t)
+	_, err = sf.Stat("svc", "pb_data", "data.db")
+	require.ErrorIs(t, err, ErrInvalidFileArea)
+	_, err = sf.Stat("../other", "hooks", "x.js")
+	require.ErrorIs(t, err, ErrInvalidFilePath)
+}
+
+func TestServiceFilesSymlinks(t *testing.T) {
+	sf, args := newTestServiceFiles(t)
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("secret"), 0o644))
+
+	// symlinks planted by the service user must not lead out of the area
+	require.NoError(t, os.MkdirAll(args.HooksDir, 0o755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(args.HooksDir, "escape")))
+	require.NoError(t, os.Symlink(secret, filepath.Join(args.HooksDir, "secret.txt")))
+
+	_, err := sf.Write("svc", "hooks", "escape/x.js", strings.NewReader("x"))
+	require.Error(t, err)
+	require.NoFileExists(t, filepath.Join(outside, "x.js"))
+
+	_, _, err = sf.Open("svc", "hooks", "secret.txt")
+	require.Error(t, err)
+
+	_, err = sf.Extract("svc", "hooks", "escape", zipArchive(t, map[string]string{"x.js": "x"}))
+	require.Error(t, err)
+	require.NoFileExists(t, filepath.Join(outside, "x.js"))
+
+	// replacing the link swaps the link itself, not its target
+	_, err = sf.Write("svc", "hooks", "secret.txt", strings.NewReader("hook"))
+	require.NoError(t, err)
+	content, err := os.ReadFile(secret)
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(content))
+
+	require.NoError(t, sf.Delete("svc", "hooks", "escape"))
+	require.FileExists(t, secret)
+
+	// the area itself is opened inside the service directory only
+	require.NoError(t, os.RemoveAll(args.PublicDir))
+	require.NoError(t, os.Symlink(outside, args.PublicDir))
+	_, err = sf.List("svc", "public", "")
+	require.Error(t, err)
+}
+
+func TestServiceFilesWriteAndLimits(t *testing.T) {
+	sf, args := newTestServiceFiles(t)
+
+	entries, err := sf.List("svc", "hooks", "")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	entry, err := sf.Write("svc", "hooks", "lib/main.pb.js", strings.NewReader("// hook"))
+	require.NoError(t, err)
+	require.Equal(t, "lib/main.pb.js", entry.Path)
+	require.EqualValues(t, 7, entry.Size)
+	content, err := os.ReadFile(filepath.Join(args.HooksDir, "lib", "main.pb.js"))
+	require.NoError(t, err)
+	require.Equal(t, "// hook", string(content))
+
+	_, err = sf.Write("svc", "hooks", "big.js", strings.NewReader(strings.Repeat("x", 513)))
+	require.ErrorIs(t, err, ErrFileTooLarge)
+	require.NoFileExists(t, filepath.Join(args.HooksDir, "big.js"))
+
+	_, err = sf.Write("svc", "hooks", "a.js", strings.NewReader(strings.Repeat("x", 500)))
+	require.NoError(t, err)
+	_, err = sf.Write("svc", "hooks", "b.js", strings.NewReader(strings.Repeat("x", 150)))
+	require.ErrorIs(t, err, ErrFileAreaFull)
+	// replacing a file only counts the difference
+	_, err = sf.Write("svc", "hooks", "a.js", strings.NewReader(strings.Repeat("x", 512)))
+	require.NoError(t, err)
+
+	entries, err = sf.List("svc", "hooks", "")
+	require.NoError(t, err)
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	require.Equal(t, []string{"a.js", "lib", "lib/main.pb.js"}, paths)
+}
+
+func TestServiceFilesExtract(t *testing.T) {
+	sf, args := newTestServiceFiles(t)
+
+	files, err := sf.Extract("svc", "public", "assets", zipArchive(t, map[string]string{
+		"index.html": "<html>",
+		"js/app.js":  "app",
+	}))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.FileExists(t, filepath.Join(args.PublicDir, "assets", "js", "app.js"))
+
+	_, err = sf.Extract("svc", "public", "", zipArchive(t, map[string]string{"../escape.txt": "x"}))
+	require.ErrorIs(t, err, ErrInvalidFilePath)
+	require.NoFileExists(t, filepath.Join(args.BaseDir, "escape.txt"))
+
+	_, err = sf.Extract("svc", "public", "", zipArchive(t, map[string]string{"huge.txt": strings.Repeat("x", 1000)}))
+	require.ErrorIs(t, err, ErrFileAreaFull)
+}
+
+func TestServiceFilesRenameAndDelete(t *testing.T) {
+	sf, args := newTestServiceFiles(t)
+
+	_, err := sf.Write("svc", "migrations", "1_init.js", strings.NewReader("init"))
+	require.NoError(t, err)
+	_, err = sf.Write("svc", "migrations", "2_next.js", strings.NewReader("next"))
+	require.NoError(t, err)
+
+	require.ErrorIs(t, sf.Rename("svc", "migrations", "1_init.js", "2_next.js"), ErrFileExists)
+	require.ErrorIs(t, sf.Rename("svc", "migrations", "1_init.js", "../1_init.js"), ErrInvalidFilePath)
+	require.NoError(t, sf.Rename("svc", "migrations", "1_init.js", "old/1_init.js"))
+	require.FileExists(t, filepath.Join(args.MigrationsDir, "old", "1_init.js"))
+	require.ErrorIs(t, sf.Rename("svc", "migrations", "old", "old/nested"), ErrInvalidFilePath)
+
+	require.NoError(t, sf.Delete("svc", "migrations", "old"))
+	require.NoDirExists(t, filepath.Join(args.MigrationsDir, "old"))
+	require.ErrorIs(t, sf.Delete("svc", "migrations", ""), ErrInvalidFilePath)
+	require.ErrorIs(t, sf.Delete("svc", "migrations", "missing.js"), os.ErrNotExist)
+	require.DirExists(t, args.MigrationsDir)
+}
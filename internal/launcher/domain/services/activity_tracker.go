@@ -0,0 +1,12 @@
+package services
+
+import "time"
+
+// ActivityTracker reports the proxied traffic of the services so idle ones
+// can be put to sleep.
+type ActivityTracker interface {
+	// LastActivity returns when the last request was proxied to the
+	// service, the current time while a connection is still open, or the
+	// zero time when no request was seen since the launcher started.
+	LastActivity(serviceID string) time.Time
+}
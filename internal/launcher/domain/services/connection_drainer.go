@@ -0,0 +1,11 @@
+package services
+
+import "context"
+
+// ConnectionDrainer gives access to the proxied connections of an upstream
+// address so a replaced process can be stopped without cutting requests.
+type ConnectionDrainer interface {
+	// Drain waits for the connections to address to finish and closes the
+	// ones still open when ctx is done.
+	Drain(ctx context.Context, address string)
+}
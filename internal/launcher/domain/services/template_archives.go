@@ -0,0 +1,10 @@
+package services
+
+import "context"
+
+// TemplateArchives gives access to the archives uploaded to the service
+// templates.
+type TemplateArchives interface {
+	// FetchTemplateArchive copies the archive of the template to dst.
+	FetchTemplateArchive(ctx context.Context, templateID, dst string) error
+}
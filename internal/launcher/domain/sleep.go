@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/internal/launcher/domain/models"
+	"time"
+)
+
+// SleepIdleServices stops the running services with an idle timeout that
+// had no proxied traffic for that long and marks them as sleeping, so the
+// proxy starts them again on the next request.
+func (lm *LauncherManager) SleepIdleServices(ctx context.Context) error {
+	services, err := lm.repository.RunningServices(ctx)
+	if err != nil {
+		slog.Error("failed to retrieve running services", "error", err)
+		return err
+	}
+
+	ctx = models.WithCause(ctx, models.CauseIdle)
+	now := time.Now()
+	for _, service := range services {
+		if service.IdleTimeout <= 0 || service.Deleted != "" {
+			continue
+		}
+		if !lm.isRunning(service.ID) {
+			continue
+		}
+		last := lastActivity(service, lm.activity.LastActivity(service.ID), lm.started)
+		if now.Sub(last) < service.IdleTimeout {
+			continue
+		}
+		lm.trySleepService(ctx, service.ID)
+	}
+	return nil
+}
+
+// lastActivity is the latest of the last proxied request, the last start of
+// the service and the launcher start, since requests are only tracked in
+// memory.
+func lastActivity(service models.Service, lastRequest, started time.Time) time.Time {
+	last := started
+	if service.LastStarted.After(last) {
+		last = service.LastStarted
+	}
+	if lastRequest.After(last) {
+		last = lastRequest
+	}
+	return last
+}
+
+// trySleepService skips services with a command being run, the next check
+// finds them again once it is done.
+func (lm *LauncherManager) trySleepService(ctx context.Context, serviceID string) {
+	mtx := lm.serviceLock(serviceID)
+	if !mtx.TryLock() {
+		return
+	}
+	defer mtx.Unlock()
+	if !lm.isRunning(serviceID) {
+		return
+	}
+	if err := lm.sleepService(ctx, serviceID); err != nil {
+		slog.Error("failed to put idle service to sleep", "serviceID", serviceID, "error", err)
+	}
+}
+
+func (lm *LauncherManager) sleepService(ctx context.Context, serviceID string) error {
+	if err := lm.stopProcess(serviceID); err != nil {
+		return err
+	}
+	lm.lstore.InsertLog(serviceID, logstore.StreamStdout, "Service idle, going to sleep")
+	slog.Info("service put to sleep", "serviceID", serviceID)
+	return lm.repository.MarkServiceSleeping(ctx, serviceID)
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"pb_launcher/internal/launcher/domain/models"
+	"testing"
+	"time"
+)
+
+func TestLastActivity(t *testing.T) {
+	started := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		lastStarted time.Time
+		lastRequest time.Time
+		expected    time.Time
+	}{
+		{"no traffic since launcher start", time.Time{}, time.Time{}, started},
+		{"started after launcher", started.Add(time.Minute), time.Time{}, started.Add(time.Minute)},
+		{"request after start", started.Add(time.Minute), started.Add(time.Hour), started.Add(time.Hour)},
+		{"request before restart", started.Add(time.Hour), started.Add(time.Minute), started.Add(time.Hour)},
+	}
+
+	for _, tt := range tests {
+		service := models.Service{LastStarted: tt.lastStarted}
+		if got := lastActivity(service, tt.lastRequest, started); !got.Equal(tt.expected) {
+			t.Errorf("%s: lastActivity() = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/internal/launcher/domain/models"
+	"strconv"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	templateSeedFile = "seed.json"
+	// pendingSeedFile keeps the template seed in the service directory until
+	// the first start imported it
+	pendingSeedFile = ".seed.pending.json"
+	failedSeedFile  = ".seed.failed.json"
+)
+
+// templateDirs maps the top level directories of a template archive to the
+// directories of the service.
+func templateDirs(args LaunchArgs) map[string]string {
+	return map[string]string{
+		"pb_hooks":      args.HooksDir,
+		"pb_public":     args.PublicDir,
+		"pb_migrations": args.MigrationsDir,
+	}
+}
+
+// startWithTemplate unpacks the template of a service that was never
+// started, starts it and imports the template seed once the instance
+// answers. PocketBase applies the template migrations itself on boot.
+func (lm *LauncherManager) startWithTemplate(ctx context.Context, service models.Service) error {
+	if service.Status == models.Idle && service.TemplateID != "" {
+		if err := lm.applyTemplate(ctx, service); err != nil {
+			err = fmt.Errorf("failed to apply template: %w", err)
+			if markErr := lm.repository.MarkServiceFailure(ctx, service.ID, models.FailureStartFailed, err.Error()); markErr != nil {
+				slog.Error("failed to mark service as failed", "serviceID", service.ID, "error", markErr)
+			}
+			return err
+		}
+	}
+	if err := lm.startService(ctx, service); err != nil {
+		return err
+	}
+	return lm.importPendingSeed(ctx, service.ID)
+}
+
+func (lm *LauncherManager) applyTemplate(ctx context.Context, service models.Service) error {
+	lm.lstore.InsertLog(service.ID, logstore.StreamStdout, "Applying service template...")
+	args := NewLaunchArgs(lm.dataDir, service.ID, "")
+	if err := os.MkdirAll(args.BaseDir, 0o755); err != nil {
+		return err
+	}
+
+	archive, err := os.CreateTemp(args.BaseDir, ".template-*.zip")
+	if err != nil {
+		return err
+	}
+	archive.Close()
+	defer os.Remove(archive.Name())
+
+	if err := lm.templates.FetchTemplateArchive(ctx, service.TemplateID, archive.Name()); err != nil {
+		return err
+	}
+	hasSeed, err := lm.unpackTemplate(archive.Name(), args)
+	if err != nil {
+		return err
+	}
+	if !hasSeed {
+		return nil
+	}
+
+	// the seed is imported through the instance API as the boot superuser
+	email, password := service.BootUserEmail, service.BootUserPassword
+	if email == "" {
+		email = "launcher@" + service.ID + ".localhost"
+	}
+	if password == "" {
+		password = core.GenerateDefaultRandomId()
+	}
+	return lm.UpsertSuperuser(ctx, service.ID, email, password)
+}
+
+// unpackTemplate extracts the archive into the service directories and
+// reports whether it carried a seed. Files of the service with the same
+// path are replaced.
+func (lm *LauncherManager) unpackTemplate(archive string, args LaunchArgs) (bool, error) {
+	staging, err := os.MkdirTemp(args.BaseDir, ".template-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(staging)
+
+	if _, err := lm.unzip.Extract(archive, staging); err != nil {
+		return false, err
+	}
+	root, err := templateRoot(staging)
+	if err != nil {
+		return false, err
+	}
+
+	dirs := templateDirs(args)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return false, err
+	}
+	hasSeed := false
+	for _, entry := range entries {
+		src := filepath.Join(root, entry.Name())
+		if dst, ok := dirs[entry.Name()]; ok && entry.IsDir() {
+			if err := mergeDir(src, dst); err != nil {
+				return false, err
+			}
+			continue
+		}
+		if entry.Name() == templateSeedFile && entry.Type().IsRegular() {
+			if err := os.Rename(src, filepath.Join(args.BaseDir, pendingSeedFile)); err != nil {
+				return false, err
+			}
+			hasSeed = true
+			continue
+		}
+		return false, fmt.Errorf("unexpected entry %q in template archive", entry.Name())
+	}
+	return hasSeed, nil
+}
+
+// templateRoot skips a single wrapping directory, as created when zipping a
+// template folder instead of its content. macOS metadata is ignored.
+func templateRoot(staging string) (string, error) {
+	os.RemoveAll(filepath.Join(staging, "__MACOSX"))
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return staging, nil
+	}
+	if _, known := templateDirs(LaunchArgs{})[entries[0].Name()]; known {
+		return staging, nil
+	}
+	return filepath.Join(staging, entries[0].Name()), nil
+}
+
+// mergeDir moves the files of src into dst, replacing existing files.
+func mergeDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		return os.Rename(path, target)
+	})
+}
+
+// importPendingSeed imports the seed left by the template once the instance
+// is healthy. The seed is attempted only once; a failed seed is kept as
+// .seed.failed.json next to pb_data for inspection.
+func (lm *LauncherManager) importPendingSeed(ctx context.Context, serviceID string) error {
+	args := NewLaunchArgs(lm.dataDir, serviceID, "")
+	pending := filepath.Join(args.BaseDir, pendingSeedFile)
+	if !exists(pending) {
+		return nil
+	}
+
+	err := lm.importSeedFile(ctx, serviceID, pending)
+	if err != nil {
+		os.Rename(pending, filepath.Join(args.BaseDir, failedSeedFile))
+		lm.lstore.InsertLog(serviceID, logstore.StreamStderr, "Seed import failed: "+err.Error())
+		return fmt.Errorf("failed to import seed: %w", err)
+	}
+	if err := os.Remove(pending); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (lm *LauncherManager) importSeedFile(ctx context.Context, serviceID, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	seed, err := parseSeed(data)
+	if err != nil {
+		return err
+	}
+	service, err := lm.repository.FindService(ctx, serviceID)
+	if err != nil {
+		return err
+	}
+	p, ok := lm.process(serviceID)
+	if !ok {
+		return fmt.Errorf("service %s is not running", serviceID)
+	}
+	if err := lm.waitHealthy(ctx, p, service.IP, service.Port); err != nil {
+		return err
+	}
+
+	baseURL := "http://" + net.JoinHostPort(service.IP, strconv.Itoa(service.Port))
+	imported, err := importSeed(ctx, baseURL, service.BootUserEmail, service.BootUserPassword, seed)
+	if err != nil {
+		return err
+	}
+	lm.lstore.InsertLog(serviceID, logstore.StreamStdout,
+		fmt.Sprintf("Imported %d seed records", imported))
+	return nil
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pb_launcher/helpers/unzip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeZip(t *testing.T, files map[string]string) string {
+	path := filepath.Join(t.TempDir(), "template.zip")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, f.Close())
+	return path
+}
+
+func TestUnpackTemplate(t *testing.T) {
+	dataDir := t.TempDir()
+	lm := &LauncherManager{dataDir: dataDir, unzip: unzip.NewUnzip()}
+	args := NewLaunchArgs(dataDir, "svc", "")
+	require.NoError(t, os.MkdirAll(args.HooksDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(args.HooksDir, "own.pb.js"), []byte("own"), 0o644))
+
+	// zipped folder instead of its content
+	archive := writeZip(t, map[string]string{
+		"blog/pb_hooks/main.pb.js":         "hook",
+		"blog/pb_migrations/1_init.js":     "migration",
+		"blog/pb_public/assets/index.html": "<html>",
+		"blog/seed.json":                   `[]`,
+		"__MACOSX/blog/._seed.json":        "",
+	})
+	hasSeed, err := lm.unpackTemplate(archive, args)
+	require.NoError(t, err)
+	require.True(t, hasSeed)
+
+	for path, content := range map[string]string{
+		filepath.Join(args.HooksDir, "main.pb.js"):            "hook",
+		filepath.Join(args.HooksDir, "own.pb.js"):             "own",
+		filepath.Join(args.MigrationsDir, "1_init.js"):        "migration",
+		filepath.Join(args.PublicDir, "assets", "index.html"): "<html>",
+		filepath.Join(args.BaseDir, pendingSeedFile):          "[]",
+	} {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err, path)
+		require.Equal(t, content, string(data))
+	}
+	entries, err := filepath.Glob(filepath.Join(args.BaseDir, ".template-*"))
+	require.NoError(t, err)
+	require.Empty(t, entries, "staging directory is removed")
+
+	_, err = lm.unpackTemplate(writeZip(t, map[string]string{
+		"pb_hooks/main.pb.js": "hook",
+		"pb_data/data.db":     "db",
+	}), args)
+	require.ErrorContains(t, err, `unexpected entry "pb_data"`)
+}
+
+func TestImportSeed(t *testing.T) {
+	var created []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/collections/_superusers/auth-with-password":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			if body["identity"] != "admin@test.localhost" || body["password"] != "secret123" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"token": "token"})
+		default:
+			if r.Header.Get("Authorization") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var record map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&record))
+			if record["title"] == "invalid" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"message":"Failed to create record."}`))
+				return
+			}
+			created = append(created, r.URL.Path+":"+record["title"].(string))
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	seed, err := parseSeed([]byte(`[
+		{"collection": "authors", "records": [{"title": "alice"}]},
+		{"collection": "posts", "records": [{"title": "hello"}, {"title": "world"}]}
+	]`))
+	require.NoError(t, err)
+
+	imported, err := importSeed(context.Background(), server.URL, "admin@test.localhost", "secret123", seed)
+	require.NoError(t, err)
+	require.Equal(t, 3, imported)
+	require.Equal(t, []string{
+		"/api/collections/authors/records:alice",
+		"/api/collections/posts/records:hello",
+		"/api/collections/posts/records:world",
+	}, created)
+
+	seed[1].Records = append(seed[1].Records, map[string]any{"title": "invalid"})
+	imported, err = importSeed(context.Background(), server.URL, "admin@test.localhost", "secret123", seed)
+	require.ErrorContains(t, err, "record 2 of posts: status 400")
+	require.Equal(t, 3, imported)
+
+	_, err = importSeed(context.Background(), server.URL, "admin@test.localhost", "wrong", seed)
+	require.ErrorContains(t, err, "superuser authentication failed")
+
+	_, err = parseSeed([]byte(`[{"records": []}]`))
+	require.Error(t, err)
+	_, err = parseSeed([]byte(`{"posts": []}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"pb_launcher/helpers/logstore"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/utils/iouitls"
+)
+
+const upgradeSnapshotDir = "pb_data.pre-upgrade"
+
+// upgradeService moves a service to another release. pb_data is copied
+// while the instance is stopped, then the new binary is started so its
+// migrations run, and it has to pass /api/health. When it does not, the
+// copy and the previous release are restored. Either way the service ends
+// up running only if it was before. The last snapshot is kept next to
+// pb_data for manual recovery.
+func (lm *LauncherManager) upgradeService(ctx context.Context, service models.Service, releaseID string) error {
+	if releaseID == "" {
+		return errors.New("upgrade requires a target release")
+	}
+	if releaseID == service.ReleaseID {
+		return fmt.Errorf("service %s already runs release %s", service.ID, releaseID)
+	}
+
+	wasRunning := false
+	if lm.isRunning(service.ID) {
+		wasRunning = true
+		if err := lm.stopService(ctx, service.ID); err != nil {
+			return err
+		}
+	}
+
+	lm.lstore.InsertLog(service.ID, logstore.StreamStdout,
+		fmt.Sprintf("Upgrading from version %s, taking a snapshot of pb_data...", service.Version))
+	hasSnapshot, err := lm.snapshotData(service.ID)
+	if err != nil {
+		slog.Error("failed to snapshot pb_data", "serviceID", service.ID, "error", err)
+		return errors.Join(fmt.Errorf("failed to snapshot pb_data: %w", err), lm.resumeAfterUpgrade(ctx, service, wasRunning))
+	}
+
+	if err := lm.repository.SetServiceRelease(ctx, service.ID, releaseID); err != nil {
+		return errors.Join(err, lm.resumeAfterUpgrade(ctx, service, wasRunning))
+	}
+
+	// the new release starts like any other start: the service is starting
+	// while its migrations run and has to answer /api/health in time
+	upgraded, err := lm.repository.FindService(ctx, service.ID)
+	if err == nil {
+		err = lm.startService(ctx, *upgraded)
+	}
+	if err == nil {
+		lm.lstore.InsertLog(service.ID, logstore.StreamStdout,
+			fmt.Sprintf("Upgraded to version %s", upgraded.Version))
+		if wasRunning {
+			return nil
+		}
+		if err := lm.stopProcess(service.ID); err != nil {
+			return fmt.Errorf("upgraded, but failed to stop the service again: %w", err)
+		}
+		return lm.restoreStopped(ctx, service)
+	}
+
+	slog.Error("upgrade failed, rolling back", "serviceID", service.ID, "release", releaseID, "error", err)
+	lm.lstore.InsertLog(service.ID, logstore.StreamStderr,
+		fmt.Sprintf("Upgrade failed, restoring version %s: %v", service.Version, err))
+
+	upgradeErr := fmt.Errorf("upgrade to release %s failed and was rolled back: %w", releaseID, err)
+	if err := lm.restoreData(service.ID, hasSnapshot); err != nil {
+		return errors.Join(upgradeErr, fmt.Errorf("failed to restore pb_data: %w", err))
+	}
+	if err := lm.repository.SetServiceRelease(ctx, service.ID, service.ReleaseID); err != nil {
+		return errors.Join(upgradeErr, fmt.Errorf("failed to restore release: %w", err))
+	}
+	// a crash of the new release may have scheduled a restart of it
+	lm.cancelBackoffStarts(ctx, service.ID, "superseded by the upgrade rollback")
+	if !wasRunning {
+		return errors.Join(upgradeErr, lm.restoreStopped(ctx, service))
+	}
+	return errors.Join(upgradeErr, lm.resumeAfterUpgrade(ctx, service, wasRunning))
+}
+
+// restoreStopped gives a service without process its status from before the
+// upgrade back; a sleeping service is still woken by the proxy.
+func (lm *LauncherManager) restoreStopped(ctx context.Context, service models.Service) error {
+	if service.Status == models.Sleeping {
+		return lm.repository.MarkServiceSleeping(ctx, service.ID)
+	}
+	return lm.repository.MarkServiceStoped(ctx, service.ID)
+}
+
+func (lm *LauncherManager) resumeAfterUpgrade(ctx context.Context, service models.Service, wasRunning bool) error {
+	if !wasRunning {
+		return nil
+	}
+	return lm.startService(ctx, service)
+}
+
+func (lm *LauncherManager) dataDirs(serviceID string) (data, snapshot string) {
+	args := NewLaunchArgs(lm.dataDir, serviceID, "")
+	return args.DataDir, path.Join(args.BaseDir, upgradeSnapshotDir)
+}
+
+// snapshotData replaces the previous snapshot with a copy of pb_data. It
+// reports false when the service has no data yet.
+func (lm *LauncherManager) snapshotData(serviceID string) (bool, error) {
+	data, snapshot := lm.dataDirs(serviceID)
+	if _, err := os.Stat(data); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err := os.RemoveAll(snapshot); err != nil {
+		return false, err
+	}
+	if err := iouitls.CopyDir(data, snapshot); err != nil {
+		os.RemoveAll(snapshot)
+		return false, err
+	}
+	return true, nil
+}
+
+// restoreData puts the snapshot back in place of pb_data, keeping a copy so
+// the snapshot survives the restore. Without a snapshot the service had no
+// data before the upgrade, and the pb_data migrated by the new release is
+// removed.
+func (lm *LauncherManager) restoreData(serviceID string, hasSnapshot bool) error {
+	data, snapshot := lm.dataDirs(serviceID)
+	if err := os.RemoveAll(data); err != nil {
+		return err
+	}
+	if !hasSnapshot {
+		return nil
+	}
+	return iouitls.CopyDir(snapshot, data)
+}
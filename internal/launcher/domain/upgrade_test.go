@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotAndRestoreData(t *testing.T) {
+	lm := &LauncherManager{dataDir: t.TempDir()}
+	data, snapshot := lm.dataDirs("svc")
+
+	if ok, err := lm.snapshotData("svc"); err != nil || ok {
+		t.Fatalf("snapshot without pb_data = %v, %v; want false, nil", ok, err)
+	}
+
+	if err := os.MkdirAll(data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(data, "data.db"), []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := lm.snapshotData("svc"); err != nil || !ok {
+		t.Fatalf("snapshot = %v, %v; want true, nil", ok, err)
+	}
+
+	// the new release migrates the database
+	if err := os.WriteFile(filepath.Join(data, "data.db"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lm.restoreData("svc", true); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(data, "data.db"))
+	if err != nil || string(got) != "v1" {
+		t.Fatalf("restored data = %q, %v; want v1", got, err)
+	}
+	if _, err := os.Stat(snapshot); err != nil {
+		t.Fatalf("snapshot should survive the restore: %v", err)
+	}
+}
+
+func TestRestoreDataWithoutSnapshot(t *testing.T) {
+	lm := &LauncherManager{dataDir: t.TempDir()}
+	data, _ := lm.dataDirs("svc")
+
+	if ok, err := lm.snapshotData("svc"); err != nil || ok {
+		t.Fatalf("snapshot without pb_data = %v, %v; want false, nil", ok, err)
+	}
+	// the new release creates and migrates pb_data before failing
+	if err := os.MkdirAll(data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(data, "data.db"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := lm.restoreData("svc", false); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if _, err := os.Stat(data); !os.IsNotExist(err) {
+		t.Fatalf("pb_data of the new release should be removed, stat error = %v", err)
+	}
+}
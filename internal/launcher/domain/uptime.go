@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"context"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/internal/launcher/domain/repositories"
+	"time"
+)
+
+// ServiceUptime sums up the status history of a service over a time range.
+type ServiceUptime struct {
+	ServiceID string
+	// From and To are the observed range: the requested one, starting no
+	// earlier than the creation of the service and ending no later than now.
+	From     time.Time
+	To       time.Time
+	Observed time.Duration
+	Uptime   time.Duration
+	Failures int
+	Restarts int
+	// MTBF is the mean up time between failures, 0 without failures.
+	MTBF time.Duration
+}
+
+// Percent returns the share of the observed range the service was up, false
+// when nothing was observed.
+func (u ServiceUptime) Percent() (float64, bool) {
+	if u.Observed <= 0 {
+		return 0, false
+	}
+	return float64(u.Uptime) / float64(u.Observed) * 100, true
+}
+
+type ServiceUptimeUsecase struct {
+	repository repositories.ServiceRepository
+	events     repositories.ServiceEventsRepository
+}
+
+func NewServiceUptimeUsecase(repository repositories.ServiceRepository, events repositories.ServiceEventsRepository) *ServiceUptimeUsecase {
+	return &ServiceUptimeUsecase{repository: repository, events: events}
+}
+
+// ServiceUptime computes the uptime of a service between from and to.
+func (u *ServiceUptimeUsecase) ServiceUptime(ctx context.Context, serviceID string, from, to time.Time) (*ServiceUptime, error) {
+	if now := time.Now(); to.After(now) {
+		to = now
+	}
+	history, err := u.events.ServiceHistory(ctx, serviceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	uptime := computeUptime(*history, from, to)
+	uptime.ServiceID = serviceID
+	return &uptime, nil
+}
+
+// ServicesUptime computes the uptime of every service not in the trash.
+func (u *ServiceUptimeUsecase) ServicesUptime(ctx context.Context, from, to time.Time) ([]ServiceUptime, error) {
+	services, err := u.repository.Services(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ServiceUptime, 0, len(services))
+	for _, service := range services {
+		if service.Deleted != "" {
+			continue
+		}
+		uptime, err := u.ServiceUptime(ctx, service.ID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *uptime)
+	}
+	return results, nil
+}
+
+// isUp reports whether a service in the status serves requests. Sleeping
+// services count as up, the proxy wakes them on demand.
+func isUp(status models.ServiceStatus) bool {
+	return status == models.Running || status == models.Sleeping
+}
+
+func isFailed(status models.ServiceStatus) bool {
+	return status == models.Failure || status == models.CrashLoop
+}
+
+// isRestart reports whether the transition starts a service again after it
+// was stopped or failed. Blue-green swaps keep the service running and are
+// not counted, neither are the first start and wakeups.
+func isRestart(event models.ServiceEvent) bool {
+	started := event.To == models.Starting ||
+		(event.To == models.Running && event.From != models.Starting)
+	return started && (event.From == models.Stopped || isFailed(event.From))
+}
+
+// computeUptime walks the status history between from and to. The status at
+// the start of the range is the one left by the last transition before it,
+// else the one the first transition in the range left behind, else the
+// current one.
+func computeUptime(history models.ServiceHistory, from, to time.Time) ServiceUptime {
+	start := from
+	if history.Created.After(start) {
+		start = history.Created
+	}
+	uptime := ServiceUptime{From: start, To: to}
+	if !to.After(start) {
+		uptime.To = start
+		return uptime
+	}
+
+	state := history.Status
+	if history.Before != nil {
+		state = history.Before.To
+	} else if len(history.Events) > 0 {
+		state = history.Events[0].From
+	}
+
+	cursor := start
+	for _, event := range history.Events {
+		at := event.Created
+		if at.Before(start) {
+			at = start
+		} else if at.After(to) {
+			at = to
+		}
+		if isUp(state) {
+			uptime.Uptime += at.Sub(cursor)
+		}
+		cursor = at
+		// failure to crashloop is the same failure
+		if isFailed(event.To) && !isFailed(event.From) {
+			uptime.Failures++
+		}
+		if isRestart(event) {
+			uptime.Restarts++
+		}
+		state = event.To
+	}
+	if isUp(state) {
+		uptime.Uptime += to.Sub(cursor)
+	}
+
+	uptime.Observed = to.Sub(start)
+	if uptime.Failures > 0 {
+		uptime.MTBF = uptime.Uptime / time.Duration(uptime.Failures)
+	}
+	return uptime
+}
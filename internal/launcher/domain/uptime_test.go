@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"pb_launcher/internal/launcher/domain/models"
+	"testing"
+	"time"
+)
+
+func TestComputeUptime(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return from.Add(time.Duration(minutes) * time.Minute) }
+	to := at(100)
+
+	history := models.ServiceHistory{
+		Created: from.Add(-time.Hour),
+		Status:  models.Running,
+		Before:  &models.ServiceEvent{From: models.Starting, To: models.Running, Created: from.Add(-time.Minute)},
+		Events: []models.ServiceEvent{
+			{From: models.Running, To: models.Failure, Created: at(20)},
+			{From: models.Failure, To: models.Starting, Created: at(25)},
+			{From: models.Starting, To: models.Running, Created: at(26)},
+			{From: models.Running, To: models.Failure, Created: at(50)},
+			{From: models.Failure, To: models.CrashLoop, Created: at(51)},
+			{From: models.CrashLoop, To: models.Stopped, Created: at(60)},
+			{From: models.Stopped, To: models.Starting, Created: at(70)},
+			{From: models.Starting, To: models.Running, Created: at(72)},
+			{From: models.Running, To: models.Sleeping, Created: at(80)},
+			{From: models.Sleeping, To: models.Starting, Created: at(90)},
+			{From: models.Starting, To: models.Running, Created: at(91)},
+		},
+	}
+
+	got := computeUptime(history, from, to)
+	// up 0-20, 26-50, 72-90 (sleeping counts) and 91-100
+	if want := 71 * time.Minute; got.Uptime != want {
+		t.Errorf("Uptime = %v, want %v", got.Uptime, want)
+	}
+	if got.Observed != 100*time.Minute {
+		t.Errorf("Observed = %v, want %v", got.Observed, 100*time.Minute)
+	}
+	if got.Failures != 2 {
+		t.Errorf("Failures = %d, want 2", got.Failures)
+	}
+	// from failure and from stopped, the wakeup is not a restart
+	if got.Restarts != 2 {
+		t.Errorf("Restarts = %d, want 2", got.Restarts)
+	}
+	if want := 71 * time.Minute / 2; got.MTBF != want {
+		t.Errorf("MTBF = %v, want %v", got.MTBF, want)
+	}
+	if percent, ok := got.Percent(); !ok || percent != 71 {
+		t.Errorf("Percent() = %v, %v, want 71, true", percent, ok)
+	}
+}
+
+func TestComputeUptime_CreatedWithinRange(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := from.Add(30 * time.Minute)
+	to := from.Add(90 * time.Minute)
+
+	history := models.ServiceHistory{
+		Created: created,
+		Status:  models.Running,
+		Events: []models.ServiceEvent{
+			{From: models.Idle, To: models.Starting, Created: created.Add(10 * time.Minute)},
+			{From: models.Starting, To: models.Running, Created: created.Add(15 * time.Minute)},
+		},
+	}
+
+	got := computeUptime(history, from, to)
+	if !got.From.Equal(created) {
+		t.Errorf("From = %v, want %v", got.From, created)
+	}
+	if got.Observed != time.Hour {
+		t.Errorf("Observed = %v, want %v", got.Observed, time.Hour)
+	}
+	if got.Uptime != 45*time.Minute {
+		t.Errorf("Uptime = %v, want %v", got.Uptime, 45*time.Minute)
+	}
+	if got.Restarts != 0 || got.Failures != 0 || got.MTBF != 0 {
+		t.Errorf("Restarts, Failures, MTBF = %d, %d, %v, want zero", got.Restarts, got.Failures, got.MTBF)
+	}
+}
+
+func TestComputeUptime_NoEvents(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	running := computeUptime(models.ServiceHistory{Created: from.Add(-time.Hour), Status: models.Running}, from, to)
+	if running.Uptime != time.Hour {
+		t.Errorf("running Uptime = %v, want %v", running.Uptime, time.Hour)
+	}
+	stopped := computeUptime(models.ServiceHistory{Created: from.Add(-time.Hour), Status: models.Stopped}, from, to)
+	if stopped.Uptime != 0 {
+		t.Errorf("stopped Uptime = %v, want 0", stopped.Uptime)
+	}
+
+	later := computeUptime(models.ServiceHistory{Created: to.Add(time.Hour), Status: models.Running}, from, to)
+	if _, ok := later.Percent(); ok || later.Observed != 0 {
+		t.Errorf("service created after the range observed %v", later.Observed)
+	}
+}
@@ -20,13 +20,25 @@ var Module = fx.Module("launcher",
 			repos.NewCommandsRepository,
 			fx.As(new(repositories.CommandsRepository)),
 		),
+		fx.Annotate(
+			repos.NewServiceEventsRepository,
+			fx.As(new(repositories.ServiceEventsRepository)),
+		),
 	),
 	fx.Provide(
 		fx.Annotate(
 			launcher_services.NewBinaryFinder,
 			fx.As(new(services.BinaryFinder)),
 		),
+		fx.Annotate(
+			launcher_services.NewTemplateArchives,
+			fx.As(new(services.TemplateArchives)),
+		),
 	),
 	fx.Provide(domain.NewCleanServiceInstallTokenUsecase),
+	fx.Provide(domain.NewCommandRegistry),
+	fx.Provide(domain.NewCommandSignal),
 	fx.Provide(domain.NewLauncherManager),
+	fx.Provide(domain.NewServiceFiles),
+	fx.Provide(domain.NewServiceUptimeUsecase),
 )
@@ -2,6 +2,7 @@ package repos
 
 import (
 	"context"
+	"encoding/json"
 	"pb_launcher/collections"
 	"pb_launcher/internal/launcher/domain/models"
 	"pb_launcher/internal/launcher/domain/repositories"
@@ -10,6 +11,7 @@ import (
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 type CommandsRepository struct {
@@ -22,7 +24,39 @@ func NewCommandsRepository(app *pocketbase.PocketBase) *CommandsRepository {
 	return &CommandsRepository{app: app}
 }
 
-func (c *CommandsRepository) PublishStartComand(ctx context.Context, serviceID string) error {
+func (c *CommandsRepository) PublishStartComand(ctx context.Context, serviceID string, notBefore time.Time) error {
+	return c.publishComand(ctx, serviceID, "start", notBefore)
+}
+
+// CancelBackoffStarts implements repositories.CommandsRepository.
+func (c *CommandsRepository) CancelBackoffStarts(ctx context.Context, serviceID string, reason string) error {
+	records, err := c.app.FindAllRecords(collections.ServicesComands, dbx.HashExp{
+		"service": serviceID,
+		"action":  "start",
+		"status":  "pending",
+		"cause":   string(models.CauseCrash),
+	})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		record.Set("status", "cancelled")
+		record.Set("executed", time.Now())
+		record.Set("error_message", reason)
+		if err := c.app.Save(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishRestartComand implements repositories.CommandsRepository.
+func (c *CommandsRepository) PublishRestartComand(ctx context.Context, serviceID string) error {
+	return c.publishComand(ctx, serviceID, "restart", time.Time{})
+}
+
+// publishComand queues an action with the cause attached to ctx.
+func (c *CommandsRepository) publishComand(ctx context.Context, serviceID, action string, notBefore time.Time) error {
 	comandCollection, err := c.app.FindCachedCollectionByNameOrId(collections.ServicesComands)
 	if err != nil {
 		return err
@@ -30,10 +64,14 @@ func (c *CommandsRepository) PublishStartComand(ctx context.Context, serviceID s
 	record := core.NewRecord(comandCollection)
 
 	record.Set("service", serviceID)
-	record.Set("action", "start")
+	record.Set("action", action)
 	record.Set("status", "pending")
 	record.Set("error_message", "")
 	record.Set("executed", nil)
+	record.Set("cause", string(models.CauseFrom(ctx)))
+	if !notBefore.IsZero() {
+		record.Set("not_before", notBefore)
+	}
 
 	return c.app.Save(record)
 }
@@ -42,32 +80,121 @@ func (c *CommandsRepository) PublishStartComand(ctx context.Context, serviceID s
 func (c *CommandsRepository) GetPendingCommands(ctx context.Context) ([]models.ServiceCommand, error) {
 	var records []*core.Record
 	query := c.app.RecordQuery(collections.ServicesComands).
-		Select("id", "service", "action").
+		Select("id", "service", "action", "release", "source", "options", "timeout", "max_retries", "attempts", "cause", "created").
 		AndWhere(dbx.NewExp("status = 'pending'")).
+		AndWhere(dbx.NewExp(
+			"(not_before IS NULL OR not_before = '' OR not_before <= {:now})",
+			dbx.Params{"now": types.NowDateTime().String()},
+		)).
 		OrderBy("created")
 	if err := query.All(&records); err != nil {
 		return nil, err
 	}
 	comands := make([]models.ServiceCommand, 0, len(records))
 	for _, r := range records {
-		var action models.CommandAction
-		switch r.GetString("action") {
-		case "stop":
-			action = models.ActionStop
-		case "start":
-			action = models.ActionStart
-		case "restart":
-			action = models.ActionRestart
+		comand := models.ServiceCommand{
+			ID:         r.Id,
+			Service:    r.GetString("service"),
+			Action:     models.CommandAction(r.GetString("action")),
+			Release:    r.GetString("release"),
+			Source:     r.GetString("source"),
+			Timeout:    time.Duration(r.GetInt("timeout")) * time.Second,
+			MaxRetries: r.GetInt("max_retries"),
+			Attempts:   r.GetInt("attempts"),
+			Cause:      models.EventCause(r.GetString("cause")),
+			Created:    r.GetDateTime("created").Time(),
+		}
+		if options, ok := r.Get("options").(types.JSONRaw); ok && len(options) > 0 {
+			comand.Options = json.RawMessage(options)
 		}
-		comands = append(comands, models.ServiceCommand{
-			ID:      r.Id,
-			Service: r.GetString("service"),
-			Action:  action,
-		})
+		comands = append(comands, comand)
 	}
 	return comands, nil
 }
 
+// ClaimCommand implements repositories.CommandsRepository.
+func (c *CommandsRepository) ClaimCommand(ctx context.Context, id string) (bool, error) {
+	claimed := false
+	err := c.app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindRecordById(collections.ServicesComands, id)
+		if err != nil {
+			return err
+		}
+		if record.GetString("status") != "pending" {
+			return nil
+		}
+		record.Set("status", "running")
+		record.Set("attempts", record.GetInt("attempts")+1)
+		claimed = true
+		return txApp.Save(record)
+	})
+	return claimed, err
+}
+
+// RetryCommand implements repositories.CommandsRepository.
+func (c *CommandsRepository) RetryCommand(ctx context.Context, id string, errorMessage string, notBefore time.Time) error {
+	record, err := c.app.FindRecordById(collections.ServicesComands, id)
+	if err != nil {
+		return err
+	}
+	record.Set("status", "pending")
+	record.Set("error_message", errorMessage)
+	record.Set("not_before", notBefore)
+	return c.app.Save(record)
+}
+
+// CancelSupersededCommand implements repositories.CommandsRepository.
+func (c *CommandsRepository) CancelSupersededCommand(ctx context.Context, cmd models.ServiceCommand) (bool, error) {
+	created, err := types.ParseDateTime(cmd.Created)
+	if err != nil {
+		return false, err
+	}
+	cancelled := false
+	err = c.app.RunInTransaction(func(txApp core.App) error {
+		newer, err := txApp.CountRecords(collections.ServicesComands,
+			dbx.HashExp{"service": cmd.Service, "status": []any{"running", "success", "error"}},
+			dbx.NewExp("created > {:created}", dbx.Params{"created": created.String()}),
+		)
+		if err != nil || newer == 0 {
+			return err
+		}
+		record, err := txApp.FindRecordById(collections.ServicesComands, cmd.ID)
+		if err != nil {
+			return err
+		}
+		if record.GetString("status") != "pending" {
+			return nil
+		}
+		record.Set("status", "cancelled")
+		record.Set("executed", time.Now())
+		record.Set("error_message", "superseded by a newer command of the service")
+		cancelled = true
+		return txApp.Save(record)
+	})
+	return cancelled, err
+}
+
+// RequeueInterruptedCommands implements repositories.CommandsRepository.
+func (c *CommandsRepository) RequeueInterruptedCommands(ctx context.Context) error {
+	records, err := c.app.FindAllRecords(collections.ServicesComands, dbx.HashExp{"status": "running"})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if record.GetInt("attempts") <= record.GetInt("max_retries") {
+			record.Set("status", "pending")
+		} else {
+			record.Set("status", "error")
+			record.Set("executed", time.Now())
+			record.Set("error_message", "interrupted by a launcher shutdown")
+		}
+		if err := c.app.Save(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // MarkCommandError implements repositories.CommandsRepository.
 func (c *CommandsRepository) MarkCommandError(ctx context.Context, id string, errorMessage string) error {
 	record, err := c.app.FindRecordById(collections.ServicesComands, id)
@@ -81,7 +208,7 @@ func (c *CommandsRepository) MarkCommandError(ctx context.Context, id string, er
 }
 
 // MarkCommandSuccess implements repositories.CommandsRepository.
-func (c *CommandsRepository) MarkCommandSuccess(ctx context.Context, id string) error {
+func (c *CommandsRepository) MarkCommandSuccess(ctx context.Context, id string, result any) error {
 	record, err := c.app.FindRecordById(collections.ServicesComands, id)
 	if err != nil {
 		return err
@@ -89,5 +216,6 @@ func (c *CommandsRepository) MarkCommandSuccess(ctx context.Context, id string)
 	record.Set("executed", time.Now())
 	record.Set("error_message", nil)
 	record.Set("status", "success")
+	record.Set("result", result)
 	return c.app.Save(record)
 }
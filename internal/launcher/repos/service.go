@@ -9,11 +9,14 @@ import (
 	"pb_launcher/internal/launcher/domain/models"
 	"pb_launcher/internal/launcher/domain/repositories"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
 )
 
 type ServiceRepository struct {
@@ -32,9 +35,25 @@ func (s *ServiceRepository) services(ids ...string) ([]models.Service, error) {
 			s.id, 
 			s.status, 
 			s.restart_policy, 
+			s.ip,
+			s.port,
+			s.preferred_port,
+			s.last_started,
+			s.idle_timeout,
+			s.restart_attempts,
+			s.restart_window_start,
+			s.memory_limit_mb,
+			s.cpu_limit,
+			s.pids_limit,
+			s.nofile_limit,
+			s.uid,
+			s.boot_priority,
+			r.id as release_id,
 			r.version, 
 			r.repository, 
 			rpo.exec_file_pattern,
+			coalesce(nullif(s.args_template, ''), rpo.args_template) as args_template,
+			s.template,
 			s._pb_install,
 			s.boot_user_email,
 			s.boot_user_password,
@@ -66,9 +85,25 @@ func (s *ServiceRepository) services(ids ...string) ([]models.Service, error) {
 		id, _ := row["id"]
 		status, _ := row["status"]
 		restartPolicy, _ := row["restart_policy"]
+		ip, _ := row["ip"]
+		port, _ := row["port"]
+		preferredPort, _ := row["preferred_port"]
+		lastStarted, _ := row["last_started"]
+		idleTimeout, _ := row["idle_timeout"]
+		restartAttempts, _ := row["restart_attempts"]
+		restartWindowStart, _ := row["restart_window_start"]
+		memoryLimit, _ := row["memory_limit_mb"]
+		cpuLimit, _ := row["cpu_limit"]
+		pidsLimit, _ := row["pids_limit"]
+		nofileLimit, _ := row["nofile_limit"]
+		uid, _ := row["uid"]
+		bootPriority, _ := row["boot_priority"]
+		releaseID, _ := row["release_id"]
 		version, _ := row["version"]
 		repository, _ := row["repository"]
 		execPattern, _ := row["exec_file_pattern"]
+		argsTemplate, _ := row["args_template"]
+		template, _ := row["template"]
 		_pb_install, _ := row["_pb_install"]
 		bootUserEmail, _ := row["boot_user_email"]
 		bootUserPassword, _ := row["boot_user_password"]
@@ -81,22 +116,62 @@ func (s *ServiceRepository) services(ids ...string) ([]models.Service, error) {
 		}
 
 		services = append(services, models.Service{
-			ID:                id.String,
-			Status:            models.ServiceStatus(status.String),
-			RestartPolicy:     models.RestartPolicy(restartPolicy.String),
-			Version:           version.String,
-			RepositoryID:      repository.String,
-			ExecFilePattern:   ExecFilePattern,
-			BootPBInstallPath: _pb_install.String,
-			BootUserEmail:     bootUserEmail.String,
-			BootUserPassword:  bootUserPassword.String,
-			Deleted:           deleted.String,
+			ID:                 id.String,
+			Status:             models.ServiceStatus(status.String),
+			RestartPolicy:      models.RestartPolicy(restartPolicy.String),
+			IP:                 ip.String,
+			Port:               parseInt(port.String),
+			PreferredPort:      parseInt(preferredPort.String),
+			LastStarted:        parseDate(lastStarted.String),
+			IdleTimeout:        time.Duration(parseInt(idleTimeout.String)) * time.Second,
+			RestartAttempts:    parseInt(restartAttempts.String),
+			RestartWindowStart: parseDate(restartWindowStart.String),
+			MemoryLimitMB:      parseInt(memoryLimit.String),
+			CPULimit:           parseFloat(cpuLimit.String),
+			PidsLimit:          parseInt(pidsLimit.String),
+			NofileLimit:        parseInt(nofileLimit.String),
+			UID:                parseInt(uid.String),
+			BootPriority:       parseInt(bootPriority.String),
+			ReleaseID:          releaseID.String,
+			Version:            version.String,
+			RepositoryID:       repository.String,
+			ExecFilePattern:    ExecFilePattern,
+			ArgsTemplate:       argsTemplate.String,
+			TemplateID:         template.String,
+			BootPBInstallPath:  _pb_install.String,
+			BootUserEmail:      bootUserEmail.String,
+			BootUserPassword:   bootUserPassword.String,
+			Deleted:            deleted.String,
 		})
 	}
 
 	return services, nil
 }
 
+func parseInt(raw string) int {
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0
+	}
+	return int(value)
+}
+
+func parseFloat(raw string) float64 {
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+func parseDate(raw string) time.Time {
+	date, err := types.ParseDateTime(raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return date.Time()
+}
+
 // Services implements repositories.ServiceRepository.
 func (s *ServiceRepository) Services(ctx context.Context) ([]models.Service, error) {
 	return s.services()
@@ -129,6 +204,13 @@ func (s *ServiceRepository) FindService(ctx context.Context, id string) (*models
 	return &services[0], nil
 }
 
+// saveStatus saves a status change with ctx, which carries its cause to the
+// service events hook. The change is recorded even when ctx is cancelled,
+// e.g. by a launcher shutdown.
+func (s *ServiceRepository) saveStatus(ctx context.Context, record *core.Record) error {
+	return s.app.SaveWithContext(context.WithoutCancel(ctx), record)
+}
+
 // MarkServiceStoped implements repositories.ServiceRepository.
 func (s *ServiceRepository) MarkServiceStoped(ctx context.Context, id string) error {
 
@@ -139,16 +221,33 @@ func (s *ServiceRepository) MarkServiceStoped(ctx context.Context, id string) er
 
 	record.Set("status", string(models.Stopped))
 	record.Set("error_message", nil)
+	record.Set("health_status", string(models.HealthUnknown))
+	record.Set("health_failures", 0)
 
-	if err := s.app.Save(record); err != nil {
+	if err := s.saveStatus(ctx, record); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// MarkServiceSleeping implements repositories.ServiceRepository.
+func (s *ServiceRepository) MarkServiceSleeping(ctx context.Context, id string) error {
+	record, err := s.app.FindRecordById(collections.Services, id)
+	if err != nil {
+		return err
+	}
+
+	record.Set("status", string(models.Sleeping))
+	record.Set("error_message", nil)
+	record.Set("health_status", string(models.HealthUnknown))
+	record.Set("health_failures", 0)
+
+	return s.saveStatus(ctx, record)
+}
+
 // MarkServiceFailure implements repositories.ServiceRepository.
-func (s *ServiceRepository) MarkServiceFailure(ctx context.Context, id string, errorMessage string) error {
+func (s *ServiceRepository) MarkServiceFailure(ctx context.Context, id string, reason models.FailureReason, errorMessage string) error {
 
 	record, err := s.app.FindRecordById(collections.Services, id)
 	if err != nil {
@@ -156,9 +255,10 @@ func (s *ServiceRepository) MarkServiceFailure(ctx context.Context, id string, e
 	}
 
 	record.Set("status", string(models.Failure))
+	record.Set("failure_reason", string(reason))
 	record.Set("error_message", errorMessage)
 
-	if err := s.app.Save(record); err != nil {
+	if err := s.saveStatus(ctx, record); err != nil {
 		return err
 	}
 
@@ -167,25 +267,119 @@ func (s *ServiceRepository) MarkServiceFailure(ctx context.Context, id string, e
 
 // MarkServiceRunning implements repositories.ServiceRepository.
 func (s *ServiceRepository) MarkServiceRunning(ctx context.Context, id, listenIp, port string) error {
+	return s.markServiceStarted(ctx, id, models.Running, listenIp, port)
+}
+
+// MarkServiceStarting implements repositories.ServiceRepository.
+func (s *ServiceRepository) MarkServiceStarting(ctx context.Context, id, listenIp, port string) error {
+	return s.markServiceStarted(ctx, id, models.Starting, listenIp, port)
+}
+
+func (s *ServiceRepository) markServiceStarted(ctx context.Context, id string, status models.ServiceStatus, listenIp, port string) error {
 
 	record, err := s.app.FindRecordById(collections.Services, id)
 	if err != nil {
 		return err
 	}
 
-	record.Set("status", string(models.Running))
+	record.Set("status", string(status))
 	record.Set("last_started", time.Now())
 	record.Set("error_message", nil)
+	record.Set("failure_reason", "")
 	record.Set("ip", listenIp)
 	record.Set("port", port)
+	record.Set("restart_required", false)
+	record.Set("health_status", string(models.HealthUnknown))
+	record.Set("health_failures", 0)
 
-	if err := s.app.Save(record); err != nil {
+	if err := s.saveStatus(ctx, record); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// MarkServiceReady implements repositories.ServiceRepository.
+func (s *ServiceRepository) MarkServiceReady(ctx context.Context, id string) error {
+	record, err := s.app.FindRecordById(collections.Services, id)
+	if err != nil {
+		return err
+	}
+	if record.GetString("status") != string(models.Starting) {
+		return nil
+	}
+	record.Set("status", string(models.Running))
+	record.Set("health_status", string(models.HealthHealthy))
+	return s.saveStatus(ctx, record)
+}
+
+// MarkServiceCrashLoop implements repositories.ServiceRepository.
+func (s *ServiceRepository) MarkServiceCrashLoop(ctx context.Context, id string, errorMessage string) error {
+	record, err := s.app.FindRecordById(collections.Services, id)
+	if err != nil {
+		return err
+	}
+
+	record.Set("status", string(models.CrashLoop))
+	record.Set("error_message", errorMessage)
+
+	return s.saveStatus(ctx, record)
+}
+
+// UpdateRestartAttempts implements repositories.ServiceRepository.
+func (s *ServiceRepository) UpdateRestartAttempts(ctx context.Context, id string, attempts int, windowStart time.Time) error {
+	record, err := s.app.FindRecordById(collections.Services, id)
+	if err != nil {
+		return err
+	}
+
+	record.Set("restart_attempts", attempts)
+	record.Set("restart_window_start", windowStart)
+
+	return s.app.Save(record)
+}
+
+// ResetRestartAttempts implements repositories.ServiceRepository.
+func (s *ServiceRepository) ResetRestartAttempts(ctx context.Context, id string) error {
+	record, err := s.app.FindRecordById(collections.Services, id)
+	if err != nil {
+		return err
+	}
+
+	record.Set("restart_attempts", 0)
+	record.Set("restart_window_start", nil)
+	if record.GetString("status") == string(models.CrashLoop) {
+		record.Set("status", string(models.Stopped))
+	}
+
+	return s.saveStatus(ctx, record)
+}
+
+// UpdateServiceHealth implements repositories.ServiceRepository.
+func (s *ServiceRepository) UpdateServiceHealth(ctx context.Context, id string, status models.HealthStatus, failures int) error {
+	db := s.app.DB()
+
+	query := fmt.Sprintf(
+		`UPDATE %s
+			SET health_status = {:status},
+				health_failures = {:failures},
+				last_health_check = {:checked}
+			WHERE id = {:id}`,
+		collections.Services,
+	)
+	_, execErr := db.NewQuery(query).
+		WithContext(ctx).
+		Bind(dbx.Params{
+			"id":       id,
+			"status":   string(status),
+			"failures": failures,
+			"checked":  types.NowDateTime().String(),
+		}).
+		Execute()
+
+	return execErr
+}
+
 // SetPbInstallToken implements repositories.ServiceRepository.
 func (s *ServiceRepository) SetServiceInstallToken(ctx context.Context, id string, _pb_install string) error {
 	record, err := s.app.FindRecordById(collections.Services, id)
@@ -237,3 +431,90 @@ func (s *ServiceRepository) UpdateSuperuser(ctx context.Context, serviceID, emai
 
 	return execErr
 }
+
+// SetServiceRelease implements repositories.ServiceRepository.
+func (s *ServiceRepository) SetServiceRelease(ctx context.Context, id, releaseID string) error {
+	record, err := s.app.FindRecordById(collections.Services, id)
+	if err != nil {
+		return err
+	}
+	record.Set("release", releaseID)
+	return s.app.Save(record)
+}
+
+// ServiceEnvironment implements repositories.ServiceRepository.
+func (s *ServiceRepository) ServiceEnvironment(ctx context.Context, serviceID string) ([]models.EnvVar, error) {
+	records, err := s.app.FindAllRecords(collections.ServiceEnv,
+		dbx.HashExp{"service": serviceID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	env := make([]models.EnvVar, 0, len(records))
+	for _, r := range records {
+		env = append(env, models.EnvVar{
+			Key:    r.GetString("key"),
+			Value:  r.GetString("value"),
+			Secret: r.GetBool("secret"),
+		})
+	}
+	return env, nil
+}
+
+// ReservedPorts implements repositories.ServiceRepository.
+func (s *ServiceRepository) ReservedPorts(ctx context.Context, excludeServiceID string) (map[int]string, error) {
+	query := fmt.Sprintf(
+		`SELECT id, status, port, preferred_port FROM %s
+			WHERE id != {:id} AND (deleted IS NULL OR deleted = '')`,
+		collections.Services,
+	)
+	rows := []dbx.NullStringMap{}
+	if err := s.app.DB().NewQuery(query).
+		WithContext(ctx).
+		Bind(dbx.Params{"id": excludeServiceID}).
+		All(&rows); err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[int]string, len(rows))
+	for _, row := range rows {
+		id := row["id"].String
+		if port := parseInt(row["preferred_port"].String); port > 0 {
+			reserved[port] = id
+		}
+		if status := row["status"].String; status == string(models.Running) || status == string(models.Starting) {
+			if port := parseInt(row["port"].String); port > 0 {
+				reserved[port] = id
+			}
+		}
+	}
+	return reserved, nil
+}
+
+// AssignedUIDs implements repositories.ServiceRepository. Trashed services
+// keep their UID, their data directory is still owned by it.
+func (s *ServiceRepository) AssignedUIDs(ctx context.Context, excludeServiceID string) (map[int]string, error) {
+	query := fmt.Sprintf(`SELECT id, uid FROM %s WHERE id != {:id} AND uid > 0`, collections.Services)
+	rows := []dbx.NullStringMap{}
+	if err := s.app.DB().NewQuery(query).
+		WithContext(ctx).
+		Bind(dbx.Params{"id": excludeServiceID}).
+		All(&rows); err != nil {
+		return nil, err
+	}
+	assigned := make(map[int]string, len(rows))
+	for _, row := range rows {
+		assigned[parseInt(row["uid"].String)] = row["id"].String
+	}
+	return assigned, nil
+}
+
+// SetServiceUID implements repositories.ServiceRepository.
+func (s *ServiceRepository) SetServiceUID(ctx context.Context, id string, uid int) error {
+	record, err := s.app.FindRecordById(collections.Services, id)
+	if err != nil {
+		return err
+	}
+	record.Set("uid", uid)
+	return s.app.Save(record)
+}
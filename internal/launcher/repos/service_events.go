@@ -0,0 +1,90 @@
+package repos
+
+import (
+	"context"
+	"pb_launcher/collections"
+	"pb_launcher/internal/launcher/domain/models"
+	"pb_launcher/internal/launcher/domain/repositories"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type ServiceEventsRepository struct {
+	app *pocketbase.PocketBase
+}
+
+var _ repositories.ServiceEventsRepository = (*ServiceEventsRepository)(nil)
+
+func NewServiceEventsRepository(app *pocketbase.PocketBase) *ServiceEventsRepository {
+	return &ServiceEventsRepository{app: app}
+}
+
+// ServiceHistory implements repositories.ServiceEventsRepository.
+func (r *ServiceEventsRepository) ServiceHistory(ctx context.Context, serviceID string, from, to time.Time) (*models.ServiceHistory, error) {
+	service, err := r.app.FindRecordById(collections.Services, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	history := &models.ServiceHistory{
+		Created: service.GetDateTime("created").Time(),
+		Status:  models.ServiceStatus(service.GetString("status")),
+	}
+
+	fromDate, err := types.ParseDateTime(from)
+	if err != nil {
+		return nil, err
+	}
+	toDate, err := types.ParseDateTime(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var before []*core.Record
+	err = r.app.RecordQuery(collections.ServiceEvents).
+		WithContext(ctx).
+		AndWhere(dbx.HashExp{"service": serviceID}).
+		AndWhere(dbx.NewExp("created < {:from}", dbx.Params{"from": fromDate.String()})).
+		OrderBy("created DESC", "rowid DESC").
+		Limit(1).
+		All(&before)
+	if err != nil {
+		return nil, err
+	}
+	if len(before) > 0 {
+		event := serviceEvent(before[0])
+		history.Before = &event
+	}
+
+	var records []*core.Record
+	err = r.app.RecordQuery(collections.ServiceEvents).
+		WithContext(ctx).
+		AndWhere(dbx.HashExp{"service": serviceID}).
+		AndWhere(dbx.NewExp(
+			"created >= {:from} AND created <= {:to}",
+			dbx.Params{"from": fromDate.String(), "to": toDate.String()},
+		)).
+		OrderBy("created", "rowid").
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+	history.Events = make([]models.ServiceEvent, 0, len(records))
+	for _, record := range records {
+		history.Events = append(history.Events, serviceEvent(record))
+	}
+	return history, nil
+}
+
+func serviceEvent(record *core.Record) models.ServiceEvent {
+	return models.ServiceEvent{
+		From:         models.ServiceStatus(record.GetString("from")),
+		To:           models.ServiceStatus(record.GetString("to")),
+		Cause:        models.EventCause(record.GetString("cause")),
+		ErrorMessage: record.GetString("error_message"),
+		Created:      record.GetDateTime("created").Time(),
+	}
+}
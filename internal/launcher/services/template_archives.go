@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"pb_launcher/collections"
+	"pb_launcher/internal/launcher/domain/services"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+// TemplateArchives reads template archives from the launcher file storage.
+type TemplateArchives struct {
+	app *pocketbase.PocketBase
+}
+
+var _ services.TemplateArchives = (*TemplateArchives)(nil)
+
+func NewTemplateArchives(app *pocketbase.PocketBase) *TemplateArchives {
+	return &TemplateArchives{app: app}
+}
+
+func (t *TemplateArchives) FetchTemplateArchive(ctx context.Context, templateID, dst string) error {
+	record, err := t.app.FindRecordById(collections.ServiceTemplates, templateID)
+	if err != nil {
+		return fmt.Errorf("failed to find template %s: %w", templateID, err)
+	}
+	name := record.GetString("archive")
+	if name == "" {
+		return fmt.Errorf("template %s has no archive", templateID)
+	}
+
+	fsys, err := t.app.NewFilesystem()
+	if err != nil {
+		return err
+	}
+	defer fsys.Close()
+	fsys.SetContext(ctx)
+
+	reader, err := fsys.GetReader(record.BaseFilesPath() + "/" + name)
+	if err != nil {
+		return fmt.Errorf("failed to open archive of template %s: %w", templateID, err)
+	}
+	defer reader.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, reader)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
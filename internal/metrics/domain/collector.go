@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/metricstore"
+	"pb_launcher/helpers/process"
+	"pb_launcher/internal/metrics/domain/services"
+	"time"
+)
+
+// Collector samples the CPU, memory and open files of every service process
+// and the size of its directory.
+type Collector struct {
+	processes services.ProcessSource
+	store     services.MetricsStore
+	dataDir   string
+	retention time.Duration
+	readStats func(pid int) (process.Stats, error)
+	// previous CPU time per service, to turn it into a usage percentage
+	previous map[string]cpuSample
+}
+
+type cpuSample struct {
+	pid     int
+	cpuTime time.Duration
+	at      time.Time
+}
+
+func NewCollector(processes services.ProcessSource, store services.MetricsStore, c configs.Config) *Collector {
+	return &Collector{
+		processes: processes,
+		store:     store,
+		dataDir:   c.GetDataDir(),
+		retention: c.GetMetricsRetention(),
+		readStats: process.ReadStats,
+		previous:  make(map[string]cpuSample),
+	}
+}
+
+// Collect stores one sample per running service and drops the samples
+// older than the retention. Like the launcher it must be called from the
+// SequentialExecutor, which owns the process list.
+func (c *Collector) Collect(ctx context.Context) error {
+	now := time.Now()
+	pids := c.processes.ServicePids()
+
+	samples := make([]metricstore.ServiceMetrics, 0, len(pids))
+	for serviceID, pid := range pids {
+		stats, err := c.readStats(pid)
+		if err != nil {
+			slog.Warn("failed to read process stats", "serviceID", serviceID, "pid", pid, "error", err)
+			continue
+		}
+		disk, err := diskUsage(filepath.Join(c.dataDir, serviceID))
+		if err != nil {
+			slog.Warn("failed to measure service directory", "serviceID", serviceID, "error", err)
+		}
+		samples = append(samples, metricstore.ServiceMetrics{
+			ServiceID:  serviceID,
+			Timestamp:  now,
+			PID:        pid,
+			CPUPercent: c.cpuPercent(serviceID, pid, stats.CPUTime, now),
+			CPUSeconds: stats.CPUTime.Seconds(),
+			RSSBytes:   stats.RSS,
+			OpenFDs:    stats.OpenFDs,
+			DiskBytes:  disk,
+		})
+	}
+	for serviceID := range c.previous {
+		if _, ok := pids[serviceID]; !ok {
+			delete(c.previous, serviceID)
+		}
+	}
+
+	if err := c.store.InsertMetrics(samples); err != nil {
+		return err
+	}
+	return c.store.PruneMetrics(now.Add(-c.retention))
+}
+
+// cpuPercent is the CPU used since the previous sample of the same process,
+// in percent of one core; 0 for the first sample.
+func (c *Collector) cpuPercent(serviceID string, pid int, cpuTime time.Duration, now time.Time) float64 {
+	prev, ok := c.previous[serviceID]
+	c.previous[serviceID] = cpuSample{pid: pid, cpuTime: cpuTime, at: now}
+	if !ok || prev.pid != pid || !now.After(prev.at) || cpuTime < prev.cpuTime {
+		return 0
+	}
+	return float64(cpuTime-prev.cpuTime) / float64(now.Sub(prev.at)) * 100
+}
+
+// diskUsage adds the size of the regular files under dir.
+func diskUsage(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// files may vanish while the service runs
+			if path != dir {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"pb_launcher/helpers/metricstore"
+	"pb_launcher/helpers/process"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProcessSource struct {
+	pids map[string]int
+}
+
+func (f *fakeProcessSource) ServicePids() map[string]int { return f.pids }
+
+type fakeMetricsStore struct {
+	samples []metricstore.ServiceMetrics
+	pruned  time.Time
+}
+
+func (f *fakeMetricsStore) InsertMetrics(samples []metricstore.ServiceMetrics) error {
+	f.samples = append(f.samples, samples...)
+	return nil
+}
+
+func (f *fakeMetricsStore) PruneMetrics(before time.Time) error {
+	f.pruned = before
+	return nil
+}
+
+func TestCollector_Collect(t *testing.T) {
+	dataDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "svc", "pb_data"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "svc", "pb_data", "data.db"), make([]byte, 1500), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "svc", "pidfile"), make([]byte, 500), 0o644))
+
+	cpu := map[int]time.Duration{10: time.Second}
+	store := &fakeMetricsStore{}
+	c := &Collector{
+		processes: &fakeProcessSource{pids: map[string]int{"svc": 10, "gone": 20}},
+		store:     store,
+		dataDir:   dataDir,
+		retention: time.Hour,
+		readStats: func(pid int) (process.Stats, error) {
+			cputime, ok := cpu[pid]
+			if !ok {
+				return process.Stats{}, errors.New("no such process")
+			}
+			return process.Stats{CPUTime: cputime, RSS: 4096, OpenFDs: 7}, nil
+		},
+		previous: make(map[string]cpuSample),
+	}
+
+	require.NoError(t, c.Collect(context.Background()))
+	require.Len(t, store.samples, 1)
+	first := store.samples[0]
+	require.Equal(t, "svc", first.ServiceID)
+	require.Equal(t, 10, first.PID)
+	require.Equal(t, 0.0, first.CPUPercent)
+	require.Equal(t, 1.0, first.CPUSeconds)
+	require.Equal(t, int64(4096), first.RSSBytes)
+	require.Equal(t, 7, first.OpenFDs)
+	require.Equal(t, int64(2000), first.DiskBytes)
+	require.WithinDuration(t, time.Now().Add(-time.Hour), store.pruned, time.Second)
+
+	// half a core over the time since the first sample
+	c.previous["svc"] = cpuSample{pid: 10, cpuTime: time.Second, at: first.Timestamp.Add(-4 * time.Second)}
+	cpu[10] = 3 * time.Second
+	require.NoError(t, c.Collect(context.Background()))
+	require.Len(t, store.samples, 2)
+	require.InDelta(t, 50.0, store.samples[1].CPUPercent, 5)
+}
+
+func TestCollector_CPUPercentResetsOnNewProcess(t *testing.T) {
+	now := time.Now()
+	c := &Collector{previous: map[string]cpuSample{
+		"svc": {pid: 10, cpuTime: 10 * time.Second, at: now.Add(-10 * time.Second)},
+	}}
+
+	require.Equal(t, 0.0, c.cpuPercent("svc", 11, time.Second, now))
+	require.Equal(t, 100.0, c.cpuPercent("svc", 11, 11*time.Second, now.Add(10*time.Second)))
+}
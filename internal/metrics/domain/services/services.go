@@ -0,0 +1,17 @@
+package services
+
+import (
+	"pb_launcher/helpers/metricstore"
+	"time"
+)
+
+// ProcessSource lists the processes serving the running services.
+type ProcessSource interface {
+	ServicePids() map[string]int
+}
+
+// MetricsStore keeps the collected samples.
+type MetricsStore interface {
+	InsertMetrics(samples []metricstore.ServiceMetrics) error
+	PruneMetrics(before time.Time) error
+}
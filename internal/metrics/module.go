@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"pb_launcher/helpers/metricstore"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+	"pb_launcher/internal/metrics/domain"
+	"pb_launcher/internal/metrics/domain/services"
+
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("metrics",
+	fx.Provide(metricstore.NewServiceMetricsDB),
+	fx.Provide(
+		func(lm *launcherdomain.LauncherManager) services.ProcessSource { return lm },
+		func(store *metricstore.ServiceMetricsDB) services.MetricsStore { return store },
+	),
+	fx.Provide(domain.NewCollector),
+)
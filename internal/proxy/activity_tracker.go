@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"pb_launcher/internal/launcher/domain/services"
+	"sync"
+	"time"
+)
+
+// ActivityTracker records the last request proxied to each service. It is
+// kept in memory only: after a launcher restart the idle timeouts count
+// from the launcher start.
+type ActivityTracker struct {
+	mu        sync.Mutex
+	upstreams *UpstreamTracker
+	services  map[string]serviceActivity
+}
+
+type serviceActivity struct {
+	last    time.Time
+	address string
+}
+
+var _ services.ActivityTracker = (*ActivityTracker)(nil)
+
+func NewActivityTracker(upstreams *UpstreamTracker) *ActivityTracker {
+	return &ActivityTracker{
+		upstreams: upstreams,
+		services:  make(map[string]serviceActivity),
+	}
+}
+
+// Touch records a request to the service served at address.
+func (a *ActivityTracker) Touch(serviceID, address string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.services[serviceID] = serviceActivity{last: time.Now(), address: address}
+}
+
+// LastActivity implements services.ActivityTracker. Open connections, such
+// as realtime subscriptions, keep the service active.
+func (a *ActivityTracker) LastActivity(serviceID string) time.Time {
+	a.mu.Lock()
+	activity, ok := a.services[serviceID]
+	a.mu.Unlock()
+	if !ok {
+		return time.Time{}
+	}
+	if a.upstreams.Active(activity.address) > 0 {
+		return time.Now()
+	}
+	return activity.last
+}
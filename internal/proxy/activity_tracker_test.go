@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivityTracker_LastActivity(t *testing.T) {
+	upstreams := NewUpstreamTracker()
+	tracker := NewActivityTracker(upstreams)
+
+	if got := tracker.LastActivity("svc"); !got.IsZero() {
+		t.Fatalf("LastActivity() = %v, want zero time for an unseen service", got)
+	}
+
+	before := time.Now()
+	tracker.Touch("svc", "127.0.0.1:9000")
+	last := tracker.LastActivity("svc")
+	if last.Before(before) {
+		t.Fatalf("LastActivity() = %v, want at least %v", last, before)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if got := tracker.LastActivity("svc"); !got.Equal(last) {
+		t.Errorf("LastActivity() = %v, want %v without new requests", got, last)
+	}
+
+	id := upstreams.add("127.0.0.1:9000", func() {})
+	if got := tracker.LastActivity("svc"); !got.After(last) {
+		t.Errorf("LastActivity() = %v, want the current time while a connection is open", got)
+	}
+	upstreams.remove("127.0.0.1:9000", id)
+	if got := tracker.LastActivity("svc"); !got.Equal(last) {
+		t.Errorf("LastActivity() = %v, want %v after the connection closed", got, last)
+	}
+}
@@ -10,4 +10,8 @@ var ErrNotFound = errors.New("not found")
 
 type ServiceRepository interface {
 	FindRunningServiceByID(ctx context.Context, id string) (*dtos.RunningServiceDto, error)
+	// PublishWakeComand queues a start command for a sleeping service unless
+	// one is already pending; a starting service needs none. ErrNotFound when
+	// the service is neither sleeping nor starting.
+	PublishWakeComand(ctx context.Context, id string) error
 }
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/internal/proxy/domain/dtos"
+	"pb_launcher/internal/proxy/domain/repositories"
+	"pb_launcher/utils/networktools"
+	"sync"
+	"time"
+)
+
+const wakePollInterval = 250 * time.Millisecond
+
+// ErrServiceWaking is returned when a sleeping or starting service was not
+// healthy within the wake timeout; it keeps starting in the background.
+var ErrServiceWaking = errors.New("service is starting, try again shortly")
+
+// ServiceWaker starts sleeping services on demand and holds requests to
+// services that are starting. Concurrent requests to the same service share
+// a single wait.
+type ServiceWaker struct {
+	repo          repositories.ServiceRepository
+	discovery     *ServiceDiscovery
+	timeout       time.Duration
+	healthTimeout time.Duration
+
+	mu     sync.Mutex
+	waking map[string]*wakeCall
+}
+
+type wakeCall struct {
+	done    chan struct{}
+	service *dtos.RunningServiceDto
+	err     error
+}
+
+func NewServiceWaker(repo repositories.ServiceRepository, discovery *ServiceDiscovery, cfg configs.Config) *ServiceWaker {
+	return &ServiceWaker{
+		repo:          repo,
+		discovery:     discovery,
+		timeout:       cfg.GetWakeTimeout(),
+		healthTimeout: time.Second,
+		waking:        make(map[string]*wakeCall),
+	}
+}
+
+// Wake starts the sleeping service id, or waits for the starting one, until
+// it answers its health check. It returns repositories.ErrNotFound when the
+// service is neither sleeping nor starting.
+func (w *ServiceWaker) Wake(ctx context.Context, id string) (*dtos.RunningServiceDto, error) {
+	w.mu.Lock()
+	call, ok := w.waking[id]
+	if !ok {
+		call = &wakeCall{done: make(chan struct{})}
+		w.waking[id] = call
+		go w.wake(id, call)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.service, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (w *ServiceWaker) wake(id string, call *wakeCall) {
+	defer func() {
+		w.mu.Lock()
+		delete(w.waking, id)
+		w.mu.Unlock()
+		close(call.done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	if err := w.repo.PublishWakeComand(ctx, id); err != nil {
+		call.err = err
+		return
+	}
+	slog.Info("holding requests until the service is running", "service_id", id)
+
+	ticker := time.NewTicker(wakePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Warn("service not ready within the wake timeout", "service_id", id)
+			call.err = ErrServiceWaking
+			return
+		case <-ticker.C:
+		}
+
+		service, err := w.discovery.FindRunningServiceByID(ctx, id)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			call.err = err
+			return
+		}
+		if networktools.CheckHealth(ctx, service.IP, service.Port, w.healthTimeout) == nil {
+			call.service = service
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"pb_launcher/internal/proxy/domain/dtos"
+	"pb_launcher/internal/proxy/domain/repositories"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeServiceRepository reports the service as running once ready is set;
+// until then it is starting.
+type fakeServiceRepository struct {
+	address string
+	ready   atomic.Bool
+}
+
+func (f *fakeServiceRepository) FindRunningServiceByID(ctx context.Context, id string) (*dtos.RunningServiceDto, error) {
+	if !f.ready.Load() {
+		return nil, repositories.ErrNotFound
+	}
+	host, port, _ := net.SplitHostPort(f.address)
+	p, _ := strconv.Atoi(port)
+	return &dtos.RunningServiceDto{ID: id, IP: host, Port: p}, nil
+}
+
+func (f *fakeServiceRepository) PublishWakeComand(ctx context.Context, id string) error {
+	return nil
+}
+
+func newTestWaker(t *testing.T, repo *fakeServiceRepository, timeout time.Duration) *ServiceWaker {
+	t.Helper()
+	discovery, err := NewServiceDiscovery(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ServiceWaker{
+		repo:          repo,
+		discovery:     discovery,
+		timeout:       timeout,
+		healthTimeout: time.Second,
+		waking:        make(map[string]*wakeCall),
+	}
+}
+
+func TestWakeHoldsRequestsUntilRunning(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	repo := &fakeServiceRepository{address: upstream.Listener.Addr().String()}
+	waker := newTestWaker(t, repo, 5*time.Second)
+	time.AfterFunc(600*time.Millisecond, func() { repo.ready.Store(true) })
+
+	started := time.Now()
+	service, err := waker.Wake(context.Background(), "svc")
+	if err != nil {
+		t.Fatalf("Wake() error = %v", err)
+	}
+	if service.ID != "svc" {
+		t.Errorf("Wake() service = %q, want svc", service.ID)
+	}
+	if elapsed := time.Since(started); elapsed < 500*time.Millisecond {
+		t.Errorf("Wake() returned after %s, before the service was running", elapsed)
+	}
+}
+
+func TestWakeTimesOutWhileStarting(t *testing.T) {
+	repo := &fakeServiceRepository{}
+	waker := newTestWaker(t, repo, 600*time.Millisecond)
+
+	_, err := waker.Wake(context.Background(), "svc")
+	if !errors.Is(err, ErrServiceWaking) {
+		t.Errorf("Wake() error = %v, want ErrServiceWaking", err)
+	}
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"net/http"
+	"pb_launcher/helpers/promexport"
+	"strconv"
+	"time"
+)
+
+// unresolvedHost labels requests for hosts that match no service, so
+// scanners probing random names do not create a series per host.
+const unresolvedHost = "unresolved"
+
+type ProxyMetrics struct {
+	requests       *promexport.CounterVec
+	duration       *promexport.HistogramVec
+	upstreamErrors *promexport.CounterVec
+}
+
+func NewProxyMetrics(registry *promexport.Registry) *ProxyMetrics {
+	return &ProxyMetrics{
+		requests: registry.NewCounterVec("pb_launcher_proxy_requests_total",
+			"Requests handled by the proxy per host and status code.", "host", "code"),
+		duration: registry.NewHistogramVec("pb_launcher_proxy_request_duration_seconds",
+			"Latency of proxied requests per host, excluding websockets and event streams.",
+			promexport.DefBuckets, "host"),
+		upstreamErrors: registry.NewCounterVec("pb_launcher_proxy_upstream_errors_total",
+			"Requests that failed to reach the upstream service per host.", "host"),
+	}
+}
+
+func (m *ProxyMetrics) observe(host string, status int, elapsed time.Duration, streaming bool) {
+	m.requests.Inc(host, strconv.Itoa(status))
+	// long-lived connections would only skew the latency buckets
+	if !streaming {
+		m.duration.Observe(elapsed.Seconds(), host)
+	}
+}
+
+func (m *ProxyMetrics) upstreamError(host string) {
+	m.upstreamErrors.Inc(host)
+}
+
+// statusRecorder keeps the status code written by the proxy.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the flusher and hijacker of
+// the underlying writer, needed for event streams and websockets.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pb_launcher/helpers/promexport"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyMetrics_Observe(t *testing.T) {
+	registry := promexport.NewRegistry()
+	m := NewProxyMetrics(registry)
+
+	m.observe("a.example.com", http.StatusOK, 20*time.Millisecond, false)
+	m.observe("a.example.com", http.StatusOK, time.Hour, true)
+	m.upstreamError("a.example.com")
+
+	var out bytes.Buffer
+	if err := registry.Write(context.Background(), &out); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	for _, want := range []string{
+		`pb_launcher_proxy_requests_total{host="a.example.com",code="200"} 2`,
+		// the websocket/event stream request is counted but not timed
+		`pb_launcher_proxy_request_duration_seconds_count{host="a.example.com"} 1`,
+		`pb_launcher_proxy_upstream_errors_total{host="a.example.com"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics output is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestStatusRecorder(t *testing.T) {
+	rec := &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+	rec.WriteHeader(http.StatusBadGateway)
+	rec.WriteHeader(http.StatusOK)
+	if rec.status != http.StatusBadGateway {
+		t.Fatalf("status = %d, want the first written code %d", rec.status, http.StatusBadGateway)
+	}
+
+	rec = &statusRecorder{ResponseWriter: httptest.NewRecorder()}
+	if _, err := rec.Write([]byte("ok")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if rec.status != http.StatusOK {
+		t.Fatalf("status = %d, want %d after an implicit header", rec.status, http.StatusOK)
+	}
+	if err := http.NewResponseController(rec).Flush(); err != nil {
+		t.Fatalf("Flush() through Unwrap error = %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	launcherservices "pb_launcher/internal/launcher/domain/services"
 	"pb_launcher/internal/proxy/domain"
 	"pb_launcher/internal/proxy/domain/repositories"
 	"pb_launcher/internal/proxy/repos"
@@ -28,7 +29,15 @@ var Module = fx.Module(
 		domain.NewServiceDiscovery,
 		domain.NewDomainServiceDiscovery,
 		domain.NewProxyEntryDiscovery,
+		domain.NewServiceWaker,
 	),
+	fx.Provide(
+		NewUpstreamTracker,
+		func(t *UpstreamTracker) launcherservices.ConnectionDrainer { return t },
+		NewActivityTracker,
+		func(a *ActivityTracker) launcherservices.ActivityTracker { return a },
+	),
+	fx.Provide(NewProxyMetrics),
 	fx.Provide(NewDynamicReverseProxyDiscovery),
 	fx.Provide(NewDynamicReverseProxy),
 	fx.Invoke(RunHttpProxy, RunHTTPSProxy, PrintProxyInfo),
@@ -12,6 +12,7 @@ import (
 	"pb_launcher/configs"
 	launcherdomain "pb_launcher/internal/launcher/domain"
 	proxydomain "pb_launcher/internal/proxy/domain"
+	"pb_launcher/internal/proxy/domain/dtos"
 	"pb_launcher/internal/proxy/domain/repositories"
 	"pb_launcher/utils/networktools"
 	"strconv"
@@ -25,6 +26,10 @@ type DynamicReverseProxyDiscovery struct {
 	proxyEntryDiscovery *proxydomain.ProxyEntryDiscovery
 	domainDiscovery     *proxydomain.DomainServiceDiscovery
 	installTokenUsecase *launcherdomain.CleanServiceInstallTokenUsecase
+	tracker             *UpstreamTracker
+	activity            *ActivityTracker
+	waker               *proxydomain.ServiceWaker
+	metrics             *ProxyMetrics
 	apiDomain           string
 	internalApiAddress  string
 }
@@ -34,6 +39,10 @@ func NewDynamicReverseProxyDiscovery(
 	proxyEntryDiscovery *proxydomain.ProxyEntryDiscovery,
 	domainDiscovery *proxydomain.DomainServiceDiscovery,
 	installTokenUsecase *launcherdomain.CleanServiceInstallTokenUsecase,
+	tracker *UpstreamTracker,
+	activity *ActivityTracker,
+	waker *proxydomain.ServiceWaker,
+	metrics *ProxyMetrics,
 	cfg configs.Config,
 	pbConf *apis.ServeConfig) *DynamicReverseProxyDiscovery {
 	return &DynamicReverseProxyDiscovery{
@@ -41,6 +50,10 @@ func NewDynamicReverseProxyDiscovery(
 		proxyEntryDiscovery: proxyEntryDiscovery,
 		domainDiscovery:     domainDiscovery,
 		installTokenUsecase: installTokenUsecase,
+		tracker:             tracker,
+		activity:            activity,
+		waker:               waker,
+		metrics:             metrics,
 		apiDomain:           cfg.GetDomain(),
 		internalApiAddress:  pbConf.HttpAddr,
 	}
@@ -67,6 +80,7 @@ func (rp *DynamicReverseProxyDiscovery) extractID(host string) (string, error) {
 
 func (rp *DynamicReverseProxyDiscovery) proxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
 	slog.Error("proxy error", "error", err)
+	rp.metrics.upstreamError(strings.Split(r.Host, ":")[0])
 	http.Error(w, "upstream error", http.StatusBadGateway)
 }
 
@@ -90,11 +104,25 @@ func (rp *DynamicReverseProxyDiscovery) buildReverseProxy(target *url.URL) *http
 		originalDirector(req)
 		networktools.PrepareProxyHeaders(req, target)
 	}
+	proxy.Transport = rp.tracker.Transport(target.Host, http.DefaultTransport)
 	proxy.ModifyResponse = rp.proxyModifyResponse
 	proxy.ErrorHandler = rp.proxyErrorHandler
 	return proxy
 }
 
+// buildServiceProxy proxies to a running service and records the request
+// for its idle timeout.
+func (rp *DynamicReverseProxyDiscovery) buildServiceProxy(service *dtos.RunningServiceDto) *httputil.ReverseProxy {
+	address := net.JoinHostPort(service.IP, strconv.Itoa(service.Port))
+	rp.activity.Touch(service.ID, address)
+	return rp.buildReverseProxy(&url.URL{
+		Scheme: "http",
+		Host:   address,
+	})
+}
+
+// ResolveTarget finds the upstream for host. Requests to a sleeping or
+// starting service are held until it is running and healthy.
 func (rp *DynamicReverseProxyDiscovery) ResolveTarget(ctx context.Context, host string) (*httputil.ReverseProxy, error) {
 	if host == rp.apiDomain {
 		return rp.buildReverseProxy(&url.URL{
@@ -115,13 +143,16 @@ func (rp *DynamicReverseProxyDiscovery) ResolveTarget(ctx context.Context, host
 		}
 		if target.Service != nil {
 			service, err := rp.serviceDiscovery.FindRunningServiceByID(ctx, *target.Service)
+			if errors.Is(err, repositories.ErrNotFound) {
+				service, err = rp.waker.Wake(ctx, *target.Service)
+			}
+			if errors.Is(err, proxydomain.ErrServiceWaking) {
+				return nil, err
+			}
 			if err != nil {
 				return nil, fmt.Errorf("service not found for id: %s", *target.Service)
 			}
-			return rp.buildReverseProxy(&url.URL{
-				Scheme: "http",
-				Host:   net.JoinHostPort(service.IP, strconv.Itoa(service.Port)),
-			}), nil
+			return rp.buildServiceProxy(service), nil
 		}
 		if target.ProxyEntry != nil {
 			entry, err := rp.proxyEntryDiscovery.FindEnabledProxyEntryByID(ctx, *target.ProxyEntry)
@@ -139,10 +170,7 @@ func (rp *DynamicReverseProxyDiscovery) ResolveTarget(ctx context.Context, host
 
 	service, err := rp.serviceDiscovery.FindRunningServiceByID(ctx, id)
 	if err == nil {
-		return rp.buildReverseProxy(&url.URL{
-			Scheme: "http",
-			Host:   net.JoinHostPort(service.IP, strconv.Itoa(service.Port)),
-		}), nil
+		return rp.buildServiceProxy(service), nil
 	}
 	if !errors.Is(err, repositories.ErrNotFound) {
 		return nil, fmt.Errorf("failed to resolve service by id: %s", id)
@@ -159,5 +187,13 @@ func (rp *DynamicReverseProxyDiscovery) ResolveTarget(ctx context.Context, host
 	if !errors.Is(err, repositories.ErrNotFound) {
 		return nil, fmt.Errorf("failed to resolve proxy entry by id: %s", id)
 	}
+
+	service, err = rp.waker.Wake(ctx, id)
+	if err == nil {
+		return rp.buildServiceProxy(service), nil
+	}
+	if !errors.Is(err, repositories.ErrNotFound) {
+		return nil, err
+	}
 	return nil, fmt.Errorf("no target found for host: %s with id: %s", host, id)
 }
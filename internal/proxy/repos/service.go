@@ -10,6 +10,7 @@ import (
 
 	"github.com/pocketbase/dbx"
 	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
 )
 
 type ServiceRepository struct {
@@ -39,3 +40,44 @@ func (r *ServiceRepository) FindRunningServiceByID(ctx context.Context, id strin
 		Port: record.GetInt("port"),
 	}, nil
 }
+
+func (r *ServiceRepository) PublishWakeComand(ctx context.Context, id string) error {
+	service, err := r.app.FindRecordById(collections.Services, id, func(q *dbx.SelectQuery) error {
+		q.AndWhere(dbx.NewExp("(deleted IS NULL OR deleted = '') AND status IN ('sleeping', 'starting')"))
+		return nil
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return repositories.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if service.GetString("status") == "starting" {
+		return nil
+	}
+
+	pending, err := r.app.CountRecords(collections.ServicesComands, dbx.HashExp{
+		"service": id,
+		"action":  "start",
+		"status":  "pending",
+	})
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return nil
+	}
+
+	comandCollection, err := r.app.FindCachedCollectionByNameOrId(collections.ServicesComands)
+	if err != nil {
+		return err
+	}
+	record := core.NewRecord(comandCollection)
+	record.Set("service", id)
+	record.Set("action", "start")
+	record.Set("status", "pending")
+	record.Set("error_message", "")
+	record.Set("executed", nil)
+	record.Set("cause", "wake")
+	return r.app.Save(record)
+}
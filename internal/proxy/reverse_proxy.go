@@ -2,11 +2,13 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httputil"
 	"pb_launcher/configs"
 	http01 "pb_launcher/internal/certificates/http_01"
+	"pb_launcher/internal/proxy/domain"
 	"pb_launcher/utils/networktools"
 	"strings"
 	"time"
@@ -19,6 +21,7 @@ type DynamicReverseProxy struct {
 	httpsPort         string
 	timeout           time.Duration
 	http01Store       *http01.Http01ChallengeAddressPublisher
+	metrics           *ProxyMetrics
 }
 
 var _ http.Handler = (*DynamicReverseProxy)(nil)
@@ -26,11 +29,13 @@ var _ http.Handler = (*DynamicReverseProxy)(nil)
 func NewDynamicReverseProxy(
 	proxyResolver *DynamicReverseProxyDiscovery,
 	http01Store *http01.Http01ChallengeAddressPublisher,
+	metrics *ProxyMetrics,
 	cfg configs.Config,
 ) *DynamicReverseProxy {
 	return &DynamicReverseProxy{
 		proxyResolver:     proxyResolver,
 		http01Store:       http01Store,
+		metrics:           metrics,
 		useHttps:          cfg.IsHttpsEnabled(),
 		skipHttpsRedirect: cfg.IsHttpsRedirectDisabled(),
 		httpsPort:         cfg.GetHttpsPort(),
@@ -53,8 +58,17 @@ func (rp *DynamicReverseProxy) shouldSkipTimeout(r *http.Request) bool {
 }
 
 func (rp *DynamicReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), rp.timeout)
-	defer cancel()
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w}
+	host := rp.serve(rec, r)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rp.metrics.observe(host, rec.status, time.Since(start), rp.shouldSkipTimeout(r))
+}
+
+// serve handles the request and returns the host label for its metrics.
+func (rp *DynamicReverseProxy) serve(w http.ResponseWriter, r *http.Request) string {
 	cleanHost := strings.Split(r.Host, ":")[0]
 
 	var proxy *httputil.ReverseProxy
@@ -65,19 +79,29 @@ func (rp *DynamicReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		if err != nil {
 			slog.Warn("failed to resolve HTTP-01 challenge address", "error", err)
 			http.Error(w, "not found", http.StatusInternalServerError)
-			return
+			return unresolvedHost
 		}
 		proxy = httputil.NewSingleHostReverseProxy(targetURL) // For some reason, Let's Encrypt doesn't seem to work well with my buildReverseProxy
 	} else {
 		var err error
-		proxy, err = rp.proxyResolver.ResolveTarget(ctx, cleanHost)
+		// resolving may wait for a sleeping or starting service, bounded by the
+		// wake timeout
+		proxy, err = rp.proxyResolver.ResolveTarget(r.Context(), cleanHost)
+		if errors.Is(err, domain.ErrServiceWaking) {
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return cleanHost
+		}
 		if err != nil || proxy == nil {
 			slog.Warn("target resolution failed", "host", r.Host, "error", err)
 			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+			return unresolvedHost
 		}
 	}
 
+	ctx, cancel := context.WithTimeout(r.Context(), rp.timeout)
+	defer cancel()
+
 	var handler http.Handler
 	if rp.shouldSkipTimeout(r) {
 		handler = proxy
@@ -89,9 +113,10 @@ func (rp *DynamicReverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		if rp.shouldSkipTimeout(r) {
 		}
 		handler.ServeHTTP(w, r.WithContext(ctx))
-		return
+		return cleanHost
 	}
 
 	redirectUrl := networktools.BuildHostURL("https", cleanHost, rp.httpsPort, r.URL.RequestURI())
 	http.Redirect(w, r, redirectUrl, http.StatusPermanentRedirect)
+	return cleanHost
 }
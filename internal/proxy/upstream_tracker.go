@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"pb_launcher/internal/launcher/domain/services"
+	"sync"
+	"time"
+)
+
+const drainPollInterval = 100 * time.Millisecond
+
+// UpstreamTracker keeps the proxied connections open per upstream address,
+// including streamed responses (SSE) and upgraded websockets, until their
+// body is closed.
+type UpstreamTracker struct {
+	mu     sync.Mutex
+	nextID uint64
+	active map[string]map[uint64]func()
+}
+
+var _ services.ConnectionDrainer = (*UpstreamTracker)(nil)
+
+func NewUpstreamTracker() *UpstreamTracker {
+	return &UpstreamTracker{active: make(map[string]map[uint64]func())}
+}
+
+func (t *UpstreamTracker) add(address string, abort func()) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	if t.active[address] == nil {
+		t.active[address] = make(map[uint64]func())
+	}
+	t.active[address][t.nextID] = abort
+	return t.nextID
+}
+
+func (t *UpstreamTracker) remove(address string, id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.active[address], id)
+	if len(t.active[address]) == 0 {
+		delete(t.active, address)
+	}
+}
+
+// Active returns the number of open connections to address.
+func (t *UpstreamTracker) Active(address string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.active[address])
+}
+
+func (t *UpstreamTracker) Drain(ctx context.Context, address string) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for t.Active(address) > 0 {
+		select {
+		case <-ctx.Done():
+			t.mu.Lock()
+			aborts := make([]func(), 0, len(t.active[address]))
+			for _, abort := range t.active[address] {
+				aborts = append(aborts, abort)
+			}
+			t.mu.Unlock()
+			slog.Info("closing connections left after drain", "address", address, "connections", len(aborts))
+			for _, abort := range aborts {
+				abort()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Transport wraps base so every round trip to address is tracked until
+// its response body is closed.
+func (t *UpstreamTracker) Transport(address string, base http.RoundTripper) http.RoundTripper {
+	return &trackedTransport{tracker: t, address: address, base: base}
+}
+
+type trackedTransport struct {
+	tracker *UpstreamTracker
+	address string
+	base    http.RoundTripper
+}
+
+func (tt *trackedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	var body io.Closer
+	var bodyMu sync.Mutex
+	abort := func() {
+		cancel()
+		bodyMu.Lock()
+		defer bodyMu.Unlock()
+		if body != nil {
+			body.Close()
+		}
+	}
+	id := tt.tracker.add(tt.address, abort)
+
+	res, err := tt.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		tt.tracker.remove(tt.address, id)
+		cancel()
+		return nil, err
+	}
+
+	done := func() {
+		tt.tracker.remove(tt.address, id)
+		cancel()
+	}
+	// upgraded connections must stay an io.ReadWriteCloser for the proxy
+	if rwc, ok := res.Body.(io.ReadWriteCloser); ok && res.StatusCode == http.StatusSwitchingProtocols {
+		res.Body = &trackedReadWriteCloser{ReadWriteCloser: rwc, done: done}
+	} else {
+		res.Body = &trackedBody{ReadCloser: res.Body, done: done}
+	}
+	bodyMu.Lock()
+	body = res.Body
+	bodyMu.Unlock()
+	return res, nil
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+type trackedReadWriteCloser struct {
+	io.ReadWriteCloser
+	once sync.Once
+	done func()
+}
+
+func (b *trackedReadWriteCloser) Close() error {
+	err := b.ReadWriteCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
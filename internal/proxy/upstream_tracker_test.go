@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestUpstreamTrackerDrainClosesStreams(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	target, _ := url.Parse(upstream.URL)
+	tracker := NewUpstreamTracker()
+	client := &http.Client{Transport: tracker.Transport(target.Host, http.DefaultTransport)}
+
+	res, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := tracker.Active(target.Host); got != 1 {
+		t.Fatalf("Active() = %d, want 1", got)
+	}
+
+	readDone := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		close(readDone)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	tracker.Drain(ctx, target.Host)
+
+	select {
+	case <-readDone:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("stream was not closed by Drain")
+	}
+	if got := tracker.Active(target.Host); got != 0 {
+		t.Fatalf("Active() = %d after drain, want 0", got)
+	}
+}
+
+func TestUpstreamTrackerDrainWaitsForRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+	tracker := NewUpstreamTracker()
+	client := &http.Client{Transport: tracker.Transport(target.Host, http.DefaultTransport)}
+
+	done := make(chan error, 1)
+	go func() {
+		res, err := client.Get(upstream.URL)
+		if err == nil {
+			_, err = io.ReadAll(res.Body)
+			res.Body.Close()
+		}
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	tracker.Drain(ctx, target.Host)
+
+	if err := <-done; err != nil {
+		t.Fatalf("in-flight request should complete, got: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("drain should finish before the timeout")
+	}
+}
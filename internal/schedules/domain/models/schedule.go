@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type ScheduleAction string
+type CatchUpPolicy string
+
+const (
+	ActionStart   ScheduleAction = "start"
+	ActionStop    ScheduleAction = "stop"
+	ActionRestart ScheduleAction = "restart"
+)
+
+const (
+	CatchUpSkip CatchUpPolicy = "skip" // runs missed while the launcher was down are dropped
+	CatchUpOnce CatchUpPolicy = "once" // the latest missed run is published once
+)
+
+type Schedule struct {
+	ID          string
+	ServiceID   string
+	Cron        string
+	Action      ScheduleAction
+	Timezone    string // IANA name, empty is UTC
+	CatchUp     CatchUpPolicy
+	LastChecked time.Time
+	Created     time.Time
+	//
+	ServiceStatus  string
+	ServiceInTrash bool
+}
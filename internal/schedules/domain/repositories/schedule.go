@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"pb_launcher/internal/schedules/domain/models"
+	"time"
+)
+
+type ScheduleRepository interface {
+	// EnabledSchedules returns the enabled schedules, including the ones of
+	// services in the trash.
+	EnabledSchedules(ctx context.Context) ([]models.Schedule, error)
+	// PublishComand queues the action of the schedule in the comands
+	// collection and records it as the last run.
+	PublishComand(ctx context.Context, schedule models.Schedule, scheduledAt time.Time) error
+	// MarkChecked records that the schedules were evaluated up to at.
+	MarkChecked(ctx context.Context, ids []string, at time.Time) error
+}
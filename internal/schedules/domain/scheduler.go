@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/internal/schedules/domain/models"
+	"pb_launcher/internal/schedules/domain/repositories"
+	"time"
+	_ "time/tzdata" // schedule timezones must not depend on the host zoneinfo
+
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// Scheduler publishes the start, stop and restart commands of the service
+// schedules; LauncherManager.Run executes them like any other command.
+type Scheduler struct {
+	repo          repositories.ScheduleRepository
+	catchUpWindow time.Duration
+	// started separates runs missed while the launcher was down from runs
+	// delayed by other executor tasks
+	started time.Time
+}
+
+func NewScheduler(repo repositories.ScheduleRepository, c configs.Config) *Scheduler {
+	return &Scheduler{
+		repo:          repo,
+		catchUpWindow: c.GetScheduleCatchUpWindow(),
+		started:       time.Now(),
+	}
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	now := time.Now()
+	schedules, err := s.repo.EnabledSchedules(ctx)
+	if err != nil {
+		slog.Error("failed to get service schedules", "error", err)
+		return err
+	}
+
+	checked := make([]string, 0, len(schedules))
+	for _, schedule := range schedules {
+		if schedule.ServiceInTrash {
+			// kept checked so a restored service does not catch up on them
+			checked = append(checked, schedule.ID)
+			continue
+		}
+		runAt, due, err := s.plan(schedule, now)
+		if err != nil {
+			slog.Warn("invalid service schedule", "scheduleID", schedule.ID, "cron", schedule.Cron, "error", err)
+			continue
+		}
+		if due && applies(schedule.Action, schedule.ServiceStatus) {
+			if err := s.repo.PublishComand(ctx, schedule, runAt); err != nil {
+				// left unchecked, the run is retried on the next tick
+				slog.Error("failed to publish scheduled command", "scheduleID", schedule.ID, "error", err)
+				continue
+			}
+		} else if due {
+			slog.Info("skipping scheduled command, service already in the target state",
+				"scheduleID", schedule.ID,
+				"serviceID", schedule.ServiceID,
+				"action", schedule.Action,
+				"status", schedule.ServiceStatus,
+			)
+		}
+		checked = append(checked, schedule.ID)
+	}
+	if len(checked) == 0 {
+		return nil
+	}
+	return s.repo.MarkChecked(ctx, checked, now)
+}
+
+// plan returns the run to publish at now. Runs since the last check are on
+// time, unless they fell before the launcher started: those were missed
+// and follow the catch-up policy of the schedule. Only the latest due run
+// is published, a schedule never queues a backlog of commands.
+func (s *Scheduler) plan(schedule models.Schedule, now time.Time) (time.Time, bool, error) {
+	expr, err := cron.NewSchedule(schedule.Cron)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	from := schedule.LastChecked
+	if from.IsZero() {
+		from = schedule.Created
+	}
+	if lookback := now.Add(-s.catchUpWindow); from.Before(lookback) {
+		from = lookback
+	}
+
+	var onTime, missed time.Time
+	for t := from.Truncate(time.Minute).Add(time.Minute); !t.After(now); t = t.Add(time.Minute) {
+		if !expr.IsDue(cron.NewMoment(t.In(loc))) {
+			continue
+		}
+		if t.Before(s.started) {
+			missed = t
+		} else {
+			onTime = t
+		}
+	}
+	switch {
+	case !onTime.IsZero():
+		return onTime, true, nil
+	case !missed.IsZero() && schedule.CatchUp == models.CatchUpOnce:
+		return missed, true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// applies drops commands that would not change the service: starting a
+// running or starting service, stopping or restarting one that is not
+// running. Stopping a sleeping service applies, so the proxy no longer wakes
+// it.
+func applies(action models.ScheduleAction, status string) bool {
+	live := status == "running" || status == "starting"
+	switch action {
+	case models.ActionStart:
+		return !live
+	case models.ActionStop:
+		return live || status == "sleeping"
+	}
+	return live
+}
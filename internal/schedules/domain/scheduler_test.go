@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"pb_launcher/internal/schedules/domain/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeScheduleRepository struct {
+	schedules []models.Schedule
+	published map[string]time.Time
+	checked   []string
+}
+
+func (f *fakeScheduleRepository) EnabledSchedules(ctx context.Context) ([]models.Schedule, error) {
+	return f.schedules, nil
+}
+
+func (f *fakeScheduleRepository) PublishComand(ctx context.Context, schedule models.Schedule, scheduledAt time.Time) error {
+	f.published[schedule.ID] = scheduledAt
+	return nil
+}
+
+func (f *fakeScheduleRepository) MarkChecked(ctx context.Context, ids []string, at time.Time) error {
+	f.checked = append(f.checked, ids...)
+	return nil
+}
+
+func date(value string) time.Time {
+	t, err := time.Parse(time.DateTime, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSchedulerPlan(t *testing.T) {
+	s := &Scheduler{catchUpWindow: 24 * time.Hour, started: date("2026-06-01 07:00:00")}
+	daily := models.Schedule{Cron: "0 8 * * *", LastChecked: date("2026-06-01 07:59:40")}
+
+	runAt, due, err := s.plan(daily, date("2026-06-01 08:00:10"))
+	require.NoError(t, err)
+	require.True(t, due)
+	require.Equal(t, date("2026-06-01 08:00:00"), runAt)
+
+	// already evaluated by the previous check
+	daily.LastChecked = date("2026-06-01 08:00:10")
+	_, due, err = s.plan(daily, date("2026-06-01 08:00:40"))
+	require.NoError(t, err)
+	require.False(t, due)
+
+	// 08:00 in Berlin is 06:00 UTC during summer time
+	berlin := models.Schedule{Cron: "0 8 * * *", Timezone: "Europe/Berlin", LastChecked: date("2026-06-01 07:59:40")}
+	_, due, err = s.plan(berlin, date("2026-06-01 08:00:10"))
+	require.NoError(t, err)
+	require.False(t, due)
+	berlin.LastChecked = date("2026-06-01 05:59:40")
+	s.started = date("2026-06-01 05:00:00")
+	runAt, due, err = s.plan(berlin, date("2026-06-01 06:00:10"))
+	require.NoError(t, err)
+	require.True(t, due)
+	require.Equal(t, date("2026-06-01 06:00:00"), runAt)
+
+	_, _, err = s.plan(models.Schedule{Cron: "not a cron"}, date("2026-06-01 06:00:10"))
+	require.Error(t, err)
+	_, _, err = s.plan(models.Schedule{Cron: "* * * * *", Timezone: "Mars/Olympus"}, date("2026-06-01 06:00:10"))
+	require.Error(t, err)
+}
+
+func TestSchedulerCatchUp(t *testing.T) {
+	// the launcher was down from 07:00 until 10:00
+	s := &Scheduler{catchUpWindow: 24 * time.Hour, started: date("2026-06-01 10:00:00")}
+	now := date("2026-06-01 10:00:05")
+	missed := models.Schedule{Cron: "0 8,9 * * *", LastChecked: date("2026-06-01 07:00:00")}
+
+	missed.CatchUp = models.CatchUpSkip
+	_, due, err := s.plan(missed, now)
+	require.NoError(t, err)
+	require.False(t, due)
+
+	// only the latest missed run is published
+	missed.CatchUp = models.CatchUpOnce
+	runAt, due, err := s.plan(missed, now)
+	require.NoError(t, err)
+	require.True(t, due)
+	require.Equal(t, date("2026-06-01 09:00:00"), runAt)
+
+	// misses older than the catch-up window are dropped
+	s.catchUpWindow = 30 * time.Minute
+	_, due, err = s.plan(missed, now)
+	require.NoError(t, err)
+	require.False(t, due)
+
+	// runs delayed by other tasks after the launcher started are on time
+	s.started = date("2026-06-01 07:30:00")
+	s.catchUpWindow = 24 * time.Hour
+	missed.CatchUp = models.CatchUpSkip
+	runAt, due, err = s.plan(missed, now)
+	require.NoError(t, err)
+	require.True(t, due)
+	require.Equal(t, date("2026-06-01 09:00:00"), runAt)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	lastChecked := time.Now().Add(-2 * time.Minute)
+	repo := &fakeScheduleRepository{
+		published: map[string]time.Time{},
+		schedules: []models.Schedule{
+			{ID: "restart", Cron: "* * * * *", Action: models.ActionRestart, ServiceStatus: "running", LastChecked: lastChecked},
+			{ID: "start-running", Cron: "* * * * *", Action: models.ActionStart, ServiceStatus: "running", LastChecked: lastChecked},
+			{ID: "stop-stopped", Cron: "* * * * *", Action: models.ActionStop, ServiceStatus: "stopped", LastChecked: lastChecked},
+			{ID: "stop-sleeping", Cron: "* * * * *", Action: models.ActionStop, ServiceStatus: "sleeping", LastChecked: lastChecked},
+			{ID: "restart-sleeping", Cron: "* * * * *", Action: models.ActionRestart, ServiceStatus: "sleeping", LastChecked: lastChecked},
+			{ID: "trashed", Cron: "* * * * *", Action: models.ActionStart, ServiceInTrash: true, LastChecked: lastChecked},
+			{ID: "invalid", Cron: "61 * * * *", Action: models.ActionStart, LastChecked: lastChecked},
+		},
+	}
+	s := &Scheduler{repo: repo, catchUpWindow: time.Hour, started: lastChecked.Add(-time.Hour)}
+
+	require.NoError(t, s.Run(context.Background()))
+	require.Len(t, repo.published, 2)
+	require.Contains(t, repo.published, "restart")
+	require.Contains(t, repo.published, "stop-sleeping")
+	require.ElementsMatch(t, []string{"restart", "start-running", "stop-stopped", "stop-sleeping", "restart-sleeping", "trashed"}, repo.checked)
+}
+
+func TestApplies(t *testing.T) {
+	require.True(t, applies(models.ActionStart, "stopped"))
+	require.False(t, applies(models.ActionStart, "running"))
+	require.False(t, applies(models.ActionStart, "starting"))
+
+	require.True(t, applies(models.ActionStop, "starting"))
+	require.True(t, applies(models.ActionStop, "sleeping"))
+	require.False(t, applies(models.ActionStop, "failure"))
+
+	require.True(t, applies(models.ActionRestart, "running"))
+	require.False(t, applies(models.ActionRestart, "sleeping"))
+}
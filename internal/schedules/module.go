@@ -0,0 +1,19 @@
+package schedules
+
+import (
+	"pb_launcher/internal/schedules/domain"
+	"pb_launcher/internal/schedules/domain/repositories"
+	"pb_launcher/internal/schedules/repos"
+
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("schedules",
+	fx.Provide(
+		fx.Annotate(
+			repos.NewScheduleRepository,
+			fx.As(new(repositories.ScheduleRepository)),
+		),
+	),
+	fx.Provide(domain.NewScheduler),
+)
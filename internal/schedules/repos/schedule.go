@@ -0,0 +1,133 @@
+package repos
+
+import (
+	"context"
+	"pb_launcher/collections"
+	"pb_launcher/internal/schedules/domain/models"
+	"pb_launcher/internal/schedules/domain/repositories"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type ScheduleRepository struct {
+	app *pocketbase.PocketBase
+}
+
+var _ repositories.ScheduleRepository = (*ScheduleRepository)(nil)
+
+func NewScheduleRepository(app *pocketbase.PocketBase) *ScheduleRepository {
+	return &ScheduleRepository{app: app}
+}
+
+type scheduleRow struct {
+	ID            string `db:"id"`
+	Service       string `db:"service"`
+	Cron          string `db:"cron"`
+	Action        string `db:"action"`
+	Timezone      string `db:"timezone"`
+	CatchUp       string `db:"catch_up"`
+	LastChecked   string `db:"last_checked"`
+	Created       string `db:"created"`
+	ServiceStatus string `db:"service_status"`
+	Deleted       string `db:"deleted"`
+}
+
+func parseDate(raw string) time.Time {
+	date, err := types.ParseDateTime(raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return date.Time()
+}
+
+// EnabledSchedules implements repositories.ScheduleRepository.
+func (r *ScheduleRepository) EnabledSchedules(ctx context.Context) ([]models.Schedule, error) {
+	var rows []scheduleRow
+	err := r.app.DB().NewQuery(`
+		select
+			sc.id,
+			sc.service,
+			sc.cron,
+			sc.action,
+			sc.timezone,
+			sc.catch_up,
+			sc.last_checked,
+			sc.created,
+			s.status as service_status,
+			coalesce(s.deleted, '') as deleted
+		from service_schedules sc
+		inner join services s on s.id = sc.service
+		where sc.enabled = true`).
+		WithContext(ctx).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]models.Schedule, 0, len(rows))
+	for _, row := range rows {
+		schedules = append(schedules, models.Schedule{
+			ID:             row.ID,
+			ServiceID:      row.Service,
+			Cron:           row.Cron,
+			Action:         models.ScheduleAction(row.Action),
+			Timezone:       row.Timezone,
+			CatchUp:        models.CatchUpPolicy(row.CatchUp),
+			LastChecked:    parseDate(row.LastChecked),
+			Created:        parseDate(row.Created),
+			ServiceStatus:  row.ServiceStatus,
+			ServiceInTrash: row.Deleted != "",
+		})
+	}
+	return schedules, nil
+}
+
+// PublishComand implements repositories.ScheduleRepository.
+func (r *ScheduleRepository) PublishComand(ctx context.Context, schedule models.Schedule, scheduledAt time.Time) error {
+	return r.app.RunInTransaction(func(txApp core.App) error {
+		comands, err := txApp.FindCachedCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		comand := core.NewRecord(comands)
+		comand.Set("service", schedule.ServiceID)
+		comand.Set("action", string(schedule.Action))
+		comand.Set("status", "pending")
+		comand.Set("error_message", "")
+		comand.Set("executed", nil)
+		comand.Set("cause", "schedule")
+		if err := txApp.Save(comand); err != nil {
+			return err
+		}
+
+		record, err := txApp.FindRecordById(collections.ServiceSchedules, schedule.ID)
+		if err != nil {
+			return err
+		}
+		record.Set("last_run", scheduledAt)
+		record.Set("last_comand", comand.Id)
+		return txApp.Save(record)
+	})
+}
+
+// MarkChecked implements repositories.ScheduleRepository. It runs on every
+// tick, so it updates the rows directly instead of saving each record.
+func (r *ScheduleRepository) MarkChecked(ctx context.Context, ids []string, at time.Time) error {
+	checked, err := types.ParseDateTime(at)
+	if err != nil {
+		return err
+	}
+	values := make([]any, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id)
+	}
+	_, err = r.app.DB().
+		Update(collections.ServiceSchedules, dbx.Params{"last_checked": checked.String()}, dbx.In("id", values...)).
+		WithContext(ctx).
+		Execute()
+	return err
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// TrashedService is a soft deleted service waiting to be purged.
+type TrashedService struct {
+	ID      string
+	Name    string
+	Deleted time.Time
+}
+
+// PurgeAfter is when the service stops being restorable.
+func (t TrashedService) PurgeAfter(retention time.Duration) time.Time {
+	return t.Deleted.Add(retention)
+}
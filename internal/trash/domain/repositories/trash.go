@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"pb_launcher/internal/trash/domain/models"
+	"time"
+)
+
+type TrashRepository interface {
+	// TrashedServices returns the deleted services, oldest first.
+	TrashedServices(ctx context.Context) ([]models.TrashedService, error)
+	// ExpiredServices returns the services deleted before deletedBefore.
+	ExpiredServices(ctx context.Context, deletedBefore time.Time) ([]models.TrashedService, error)
+	// PurgeService removes the commands, domains and record of a service;
+	// pending certificate requests go with the domains.
+	PurgeService(ctx context.Context, serviceID string) error
+}
@@ -0,0 +1,18 @@
+package services
+
+import "context"
+
+// ServiceStopper makes sure no process of the service is left running.
+type ServiceStopper interface {
+	EnsureStopped(ctx context.Context, serviceID string) error
+}
+
+// DataArchiver keeps a last archive of the service data before the purge.
+type DataArchiver interface {
+	ArchiveServiceData(ctx context.Context, serviceID string) (string, error)
+}
+
+// LogPurger removes the stored process logs of a service.
+type LogPurger interface {
+	DeleteLogsByService(serviceID string) error
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"pb_launcher/configs"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+	"pb_launcher/internal/trash/domain/models"
+	"pb_launcher/internal/trash/domain/repositories"
+	"pb_launcher/internal/trash/domain/services"
+	"time"
+)
+
+// TrashManager purges the services that stayed deleted longer than the
+// trash retention.
+type TrashManager struct {
+	repo      repositories.TrashRepository
+	stopper   services.ServiceStopper
+	archiver  services.DataArchiver
+	logs      services.LogPurger
+	dataDir   string
+	retention time.Duration
+	archive   bool
+}
+
+func NewTrashManager(
+	repo repositories.TrashRepository,
+	stopper services.ServiceStopper,
+	archiver services.DataArchiver,
+	logs services.LogPurger,
+	c configs.Config,
+) *TrashManager {
+	return &TrashManager{
+		repo:      repo,
+		stopper:   stopper,
+		archiver:  archiver,
+		logs:      logs,
+		dataDir:   c.GetDataDir(),
+		retention: c.GetTrashRetention(),
+		archive:   c.IsTrashArchiveEnabled(),
+	}
+}
+
+func (tm *TrashManager) Retention() time.Duration { return tm.retention }
+
+// Purge runs on the SequentialExecutor, which owns the launcher processes.
+func (tm *TrashManager) Purge(ctx context.Context) error {
+	expired, err := tm.repo.ExpiredServices(ctx, time.Now().Add(-tm.retention))
+	if err != nil {
+		slog.Error("failed to get expired trashed services", "error", err)
+		return err
+	}
+	for _, service := range expired {
+		if err := tm.purgeService(ctx, service); err != nil {
+			slog.Error("failed to purge service", "serviceID", service.ID, "error", err)
+			continue
+		}
+		slog.Info("purged deleted service", "serviceID", service.ID, "name", service.Name)
+	}
+	return nil
+}
+
+// purgeService removes the data before the record, so a failure leaves the
+// service in the trash and the purge is retried on the next run.
+func (tm *TrashManager) purgeService(ctx context.Context, service models.TrashedService) error {
+	if err := tm.stopper.EnsureStopped(ctx, service.ID); err != nil {
+		return fmt.Errorf("failed to stop service: %w", err)
+	}
+	if tm.archive {
+		key, err := tm.archiver.ArchiveServiceData(ctx, service.ID)
+		if err != nil {
+			return fmt.Errorf("failed to archive service data: %w", err)
+		}
+		if key != "" {
+			slog.Info("archived deleted service data", "serviceID", service.ID, "key", key)
+		}
+	}
+
+	baseDir := launcherdomain.NewLaunchArgs(tm.dataDir, service.ID, "").BaseDir
+	if err := os.RemoveAll(baseDir); err != nil {
+		return fmt.Errorf("failed to remove data directory: %w", err)
+	}
+	if err := launcherdomain.RemoveServiceState(tm.dataDir, service.ID); err != nil {
+		slog.Error("failed to remove service launcher state", "serviceID", service.ID, "error", err)
+	}
+	if err := tm.logs.DeleteLogsByService(service.ID); err != nil {
+		slog.Error("failed to delete service logs", "serviceID", service.ID, "error", err)
+	}
+	return tm.repo.PurgeService(ctx, service.ID)
+}
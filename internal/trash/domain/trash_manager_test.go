@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"pb_launcher/internal/trash/domain/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTrashRepository struct {
+	trashed []models.TrashedService
+	purged  []string
+}
+
+func (f *fakeTrashRepository) TrashedServices(ctx context.Context) ([]models.TrashedService, error) {
+	return f.trashed, nil
+}
+
+func (f *fakeTrashRepository) ExpiredServices(ctx context.Context, before time.Time) ([]models.TrashedService, error) {
+	var expired []models.TrashedService
+	for _, s := range f.trashed {
+		if s.Deleted.Before(before) {
+			expired = append(expired, s)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeTrashRepository) PurgeService(ctx context.Context, serviceID string) error {
+	f.purged = append(f.purged, serviceID)
+	return nil
+}
+
+type fakeTrashDeps struct {
+	stopErr  error
+	archived []string
+	logs     []string
+}
+
+func (f *fakeTrashDeps) EnsureStopped(ctx context.Context, serviceID string) error { return f.stopErr }
+
+func (f *fakeTrashDeps) ArchiveServiceData(ctx context.Context, serviceID string) (string, error) {
+	f.archived = append(f.archived, serviceID)
+	return "trash/" + serviceID + ".zip", nil
+}
+
+func (f *fakeTrashDeps) DeleteLogsByService(serviceID string) error {
+	f.logs = append(f.logs, serviceID)
+	return nil
+}
+
+func newTestTrashManager(t *testing.T, deps *fakeTrashDeps, archive bool) (*TrashManager, *fakeTrashRepository) {
+	repo := &fakeTrashRepository{trashed: []models.TrashedService{
+		{ID: "old", Deleted: time.Now().Add(-48 * time.Hour)},
+		{ID: "recent", Deleted: time.Now().Add(-time.Hour)},
+	}}
+	dataDir := t.TempDir()
+	for _, s := range repo.trashed {
+		require.NoError(t, os.MkdirAll(filepath.Join(dataDir, s.ID, "pb_data"), 0o755))
+	}
+	return &TrashManager{
+		repo:      repo,
+		stopper:   deps,
+		archiver:  deps,
+		logs:      deps,
+		dataDir:   dataDir,
+		retention: 24 * time.Hour,
+		archive:   archive,
+	}, repo
+}
+
+func TestTrashManager_PurgesExpiredServices(t *testing.T) {
+	deps := &fakeTrashDeps{}
+	tm, repo := newTestTrashManager(t, deps, true)
+
+	require.NoError(t, tm.Purge(context.Background()))
+
+	require.Equal(t, []string{"old"}, repo.purged)
+	require.Equal(t, []string{"old"}, deps.archived)
+	require.Equal(t, []string{"old"}, deps.logs)
+	require.NoDirExists(t, filepath.Join(tm.dataDir, "old"))
+	require.DirExists(t, filepath.Join(tm.dataDir, "recent"))
+}
+
+func TestTrashManager_KeepsServiceWhenStopFails(t *testing.T) {
+	deps := &fakeTrashDeps{stopErr: errors.New("boom")}
+	tm, repo := newTestTrashManager(t, deps, false)
+
+	require.NoError(t, tm.Purge(context.Background()))
+
+	require.Empty(t, repo.purged)
+	require.DirExists(t, filepath.Join(tm.dataDir, "old"))
+}
@@ -0,0 +1,28 @@
+package trash
+
+import (
+	"pb_launcher/helpers/logstore"
+	backupsdomain "pb_launcher/internal/backups/domain"
+	launcherdomain "pb_launcher/internal/launcher/domain"
+	"pb_launcher/internal/trash/domain"
+	"pb_launcher/internal/trash/domain/repositories"
+	"pb_launcher/internal/trash/domain/services"
+	"pb_launcher/internal/trash/repos"
+
+	"go.uber.org/fx"
+)
+
+var Module = fx.Module("trash",
+	fx.Provide(
+		fx.Annotate(
+			repos.NewTrashRepository,
+			fx.As(new(repositories.TrashRepository)),
+		),
+	),
+	fx.Provide(
+		func(lm *launcherdomain.LauncherManager) services.ServiceStopper { return lm },
+		func(bm *backupsdomain.BackupManager) services.DataArchiver { return bm },
+		func(ls *logstore.ServiceLogDB) services.LogPurger { return ls },
+	),
+	fx.Provide(domain.NewTrashManager),
+)
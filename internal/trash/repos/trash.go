@@ -0,0 +1,84 @@
+package repos
+
+import (
+	"context"
+	"pb_launcher/collections"
+	"pb_launcher/internal/trash/domain/models"
+	"pb_launcher/internal/trash/domain/repositories"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type TrashRepository struct {
+	app *pocketbase.PocketBase
+}
+
+var _ repositories.TrashRepository = (*TrashRepository)(nil)
+
+func NewTrashRepository(app *pocketbase.PocketBase) *TrashRepository {
+	return &TrashRepository{app: app}
+}
+
+func (t *TrashRepository) findTrashed(exprs ...dbx.Expression) ([]models.TrashedService, error) {
+	var records []*core.Record
+	query := t.app.RecordQuery(collections.Services).
+		AndWhere(dbx.NewExp("deleted IS NOT NULL AND deleted != ''")).
+		OrderBy("deleted")
+	for _, expr := range exprs {
+		query = query.AndWhere(expr)
+	}
+	if err := query.All(&records); err != nil {
+		return nil, err
+	}
+	services := make([]models.TrashedService, 0, len(records))
+	for _, r := range records {
+		services = append(services, models.TrashedService{
+			ID:      r.Id,
+			Name:    r.GetString("name"),
+			Deleted: r.GetDateTime("deleted").Time(),
+		})
+	}
+	return services, nil
+}
+
+// TrashedServices implements repositories.TrashRepository.
+func (t *TrashRepository) TrashedServices(ctx context.Context) ([]models.TrashedService, error) {
+	return t.findTrashed()
+}
+
+// ExpiredServices implements repositories.TrashRepository.
+func (t *TrashRepository) ExpiredServices(ctx context.Context, deletedBefore time.Time) ([]models.TrashedService, error) {
+	before, err := types.ParseDateTime(deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	return t.findTrashed(dbx.NewExp("deleted < {:before}", dbx.Params{"before": before.String()}))
+}
+
+// PurgeService implements repositories.TrashRepository. Records are deleted
+// one by one so their hooks run: domain deletes invalidate the proxy cache
+// and drop pending certificate requests, backup deletes remove archives.
+func (t *TrashRepository) PurgeService(ctx context.Context, serviceID string) error {
+	return t.app.RunInTransaction(func(txApp core.App) error {
+		for _, collection := range []string{collections.ServicesComands, collections.ServicesDomains} {
+			records, err := txApp.FindAllRecords(collection, dbx.HashExp{"service": serviceID})
+			if err != nil {
+				return err
+			}
+			for _, record := range records {
+				if err := txApp.Delete(record); err != nil {
+					return err
+				}
+			}
+		}
+		service, err := txApp.FindRecordById(collections.Services, serviceID)
+		if err != nil {
+			return err
+		}
+		return txApp.Delete(service)
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/sandbox"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+// runSandbox handles the re-execution of the launcher binary that starts a
+// service in namespace isolation, before any PocketBase setup.
+func runSandbox() {
+	if len(os.Args) < 2 || os.Args[1] != sandbox.Command {
+		return
+	}
+	if err := sandbox.Main(os.Args[2:]); err != nil {
+		slog.Error("failed to start sandboxed service", "error", err)
+	}
+	os.Exit(126) // Main only returns when the service could not be executed
+}
+
+// SecureLauncherDirs closes the launcher database, certificates, ACME
+// accounts, master key and backups to the service users. Downloaded
+// binaries stay readable, the services execute them.
+func SecureLauncherDirs(pb *pocketbase.PocketBase, cfg configs.Config) error {
+	if cfg.GetIsolationMode() == "" {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return errors.New("isolation requires running the launcher as root")
+	}
+	modes := map[string]os.FileMode{
+		pb.DataDir():             0o700,
+		cfg.GetCertificatesDir(): 0o700,
+		cfg.GetAccountsDir():     0o700,
+		cfg.GetBackupDir():       0o700,
+	}
+	// only the key itself, its directory may be shared
+	if keyFile := cfg.GetMasterKeyFile(); keyFile != "" {
+		modes[keyFile] = 0o600
+	}
+	for path, mode := range modes {
+		if err := os.Chmod(path, mode); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,17 +7,25 @@ import (
 	"pb_launcher/helpers/serialexecutor"
 	launcher "pb_launcher/internal/launcher/domain"
 	"sync/atomic"
+
+	"go.uber.org/fx"
 )
 
 func RegisterLauncherRunner(
+	lc fx.Lifecycle,
 	executor *serialexecutor.SequentialExecutor,
 	launcherManager *launcher.LauncherManager,
+	signal *launcher.CommandSignal,
 	config configs.Config) error {
 
 	var recoveryDone atomic.Bool
 
+	// queued commands wake the task, the interval only catches what the
+	// signal missed, such as delayed starts becoming due
 	launcherRunnerTask := serialexecutor.NewTask(
 		func(ctx context.Context) {
+			// the recovery starts the services in the background, the
+			// commands queued meanwhile are not held back by it
 			if !recoveryDone.Load() {
 				if err := launcherManager.RecoveryLastState(ctx); err != nil {
 					slog.Error("recovery process failed", "error", err, "task", "launcherRunner")
@@ -33,5 +41,17 @@ func RegisterLauncherRunner(
 		9999,
 	)
 
-	return executor.Add(launcherRunnerTask)
+	// appended before the executor hook, so it runs once no task can touch
+	// the process list anymore
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			if err := launcherManager.Dispose(); err != nil {
+				slog.Error("failed to dispose launcher manager", "error", err)
+				return err
+			}
+			return nil
+		},
+	})
+
+	return executor.Add(launcherRunnerTask.WithName("launcherRunner").WithWakeup(signal.C()))
 }
@@ -7,17 +7,22 @@ import (
 	"path"
 	"pb_launcher/configs"
 	"pb_launcher/helpers/logstore"
+	"pb_launcher/helpers/promexport"
 	"pb_launcher/helpers/serialexecutor"
 	"pb_launcher/helpers/unzip"
 	"pb_launcher/internal"
 	"runtime"
 	"strings"
 
+	"pb_launcher/internal/backups"
 	"pb_launcher/internal/certificates"
 	"pb_launcher/internal/certmanager"
 	"pb_launcher/internal/download"
 	"pb_launcher/internal/launcher"
+	"pb_launcher/internal/metrics"
 	"pb_launcher/internal/proxy"
+	"pb_launcher/internal/schedules"
+	"pb_launcher/internal/trash"
 	_ "pb_launcher/migrations"
 
 	_ "embed"
@@ -51,6 +56,7 @@ var skipCommands = map[string]bool{
 }
 
 func main() {
+	runSandbox()
 
 	args := strings.Join(os.Args[1:], "")
 	skipInit := skipCommands[args]
@@ -77,14 +83,21 @@ func createRootCommand(app core.App) *cobra.Command {
 				fx.Provide(configs.NewPBServeConfig),
 				fx.Provide(unzip.NewUnzip),
 				fx.Provide(logstore.NewServiceLogDB),
+				fx.Provide(NewMasterCipher),
 				fx.Provide(serialexecutor.NewSequentialExecutor),
+				fx.Provide(promexport.NewRegistry),
 				fx.Supply(app),
 				download.Module,
 				launcher.Module,
+				backups.Module,
+				trash.Module,
+				schedules.Module,
+				metrics.Module,
 				proxy.Module,
 				certmanager.Module,
 				internal.Module, // hooks
 				fx.Invoke(
+					SecureLauncherDirs,
 					StartApiServer,
 					ServeEmbeddedUI,
 					// Tasks
@@ -94,6 +107,13 @@ func createRootCommand(app core.App) *cobra.Command {
 
 					RegisterBinaryReleaseSync,
 					RegisterLauncherRunner,
+					RegisterHealthProbe,
+					RegisterIdleSleeper,
+					RegisterBackupRunner,
+					RegisterTrashPurge,
+					RegisterServiceScheduler,
+					RegisterMetricsCollector,
+					StartPrometheusExporter,
 					RunSequentialExecutor, // Start Stask Runner
 				),
 			).Run()
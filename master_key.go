@@ -0,0 +1,21 @@
+package main
+
+import (
+	"path/filepath"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/secrets"
+
+	"github.com/pocketbase/pocketbase"
+)
+
+func NewMasterCipher(app *pocketbase.PocketBase, c configs.Config) (*secrets.Cipher, error) {
+	keyFile := c.GetMasterKeyFile()
+	if keyFile == "" {
+		keyFile = filepath.Join(app.DataDir(), "master.key")
+	}
+	key, err := secrets.LoadOrCreateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.NewCipher(key)
+}
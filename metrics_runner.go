@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/serialexecutor"
+	metrics "pb_launcher/internal/metrics/domain"
+)
+
+func RegisterMetricsCollector(
+	executor *serialexecutor.SequentialExecutor,
+	collector *metrics.Collector,
+	config configs.Config) error {
+
+	metricsTask := serialexecutor.NewTask(
+		func(ctx context.Context) {
+			if err := collector.Collect(ctx); err != nil {
+				slog.Error("metrics collection failed", "error", err, "task", "metricsCollector")
+			}
+		},
+		config.GetMetricsInterval(),
+		8500,
+	)
+
+	return executor.Add(metricsTask.WithName("metricsCollector"))
+}
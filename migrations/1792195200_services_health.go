@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.Add(
+			&core.SelectField{
+				Name:      "health_status",
+				System:    true,
+				MaxSelect: 1,
+				Values:    []string{"unknown", "healthy", "unhealthy"},
+			},
+			&core.NumberField{
+				Name:    "health_failures",
+				System:  true,
+				OnlyInt: true,
+			},
+			&core.DateField{
+				Name:   "last_health_check",
+				System: true,
+			},
+		)
+		return app.Save(services)
+	}, func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.RemoveByName("health_status")
+		services.Fields.RemoveByName("health_failures")
+		services.Fields.RemoveByName("last_health_check")
+		return app.Save(services)
+	})
+}
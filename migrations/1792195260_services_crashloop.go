@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		if status, ok := services.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"idle", "running", "stopped", "failure", "crashloop"}
+		}
+		services.Fields.Add(
+			&core.NumberField{
+				Name:    "restart_attempts",
+				System:  true,
+				OnlyInt: true,
+			},
+			&core.DateField{
+				Name:   "restart_window_start",
+				System: true,
+			},
+		)
+		if err := app.Save(services); err != nil {
+			return err
+		}
+
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if action, ok := comands.Fields.GetByName("action").(*core.SelectField); ok {
+			action.Values = []string{"stop", "start", "restart", "reset"}
+		}
+		comands.Fields.Add(&core.DateField{
+			Name:   "not_before",
+			System: true,
+		})
+		return app.Save(comands)
+	}, func(app core.App) error {
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if action, ok := comands.Fields.GetByName("action").(*core.SelectField); ok {
+			action.Values = []string{"stop", "start", "restart"}
+		}
+		comands.Fields.RemoveByName("not_before")
+		if err := app.Save(comands); err != nil {
+			return err
+		}
+
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		// parked services stay down until started again
+		if _, err := app.DB().NewQuery(
+			"UPDATE " + collections.Services + " SET status = 'failure' WHERE status = 'crashloop'",
+		).Execute(); err != nil {
+			return err
+		}
+		if status, ok := services.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"idle", "running", "stopped", "failure"}
+		}
+		services.Fields.RemoveByName("restart_attempts")
+		services.Fields.RemoveByName("restart_window_start")
+		return app.Save(services)
+	})
+}
@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.Add(&core.BoolField{
+			Name:   "restart_required",
+			System: true,
+		})
+		if err := app.Save(services); err != nil {
+			return err
+		}
+
+		serviceEnv := core.NewBaseCollection(collections.ServiceEnv)
+		serviceEnv.Fields.Add(
+			&core.RelationField{
+				Name:          "service",
+				CollectionId:  services.Id,
+				System:        true,
+				Required:      true,
+				CascadeDelete: true,
+				MinSelect:     1,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name:        "key",
+				System:      true,
+				Required:    true,
+				Presentable: true,
+				Max:         255,
+				Pattern:     `^[A-Za-z_][A-Za-z0-9_]*$`,
+			},
+			&core.TextField{
+				Name:   "value",
+				System: true,
+			},
+			&core.BoolField{
+				Name:   "secret",
+				System: true,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				System:   true,
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				System:   true,
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		serviceEnv.Indexes = append(serviceEnv.Indexes,
+			`CREATE UNIQUE INDEX idx_service_env_service_key ON service_env(service, key)`,
+		)
+
+		serviceEnv.ListRule = utils.StrPointer(`@request.auth.id != ""`)
+		serviceEnv.ViewRule = utils.StrPointer(`@request.auth.id != ""`)
+		serviceEnv.CreateRule = utils.StrPointer(`@request.auth.id != ""`)
+		serviceEnv.UpdateRule = utils.StrPointer(`@request.auth.id != ""`)
+		serviceEnv.DeleteRule = utils.StrPointer(`@request.auth.id != ""`)
+
+		return app.Save(serviceEnv)
+	}, func(app core.App) error {
+		serviceEnv, err := app.FindCollectionByNameOrId(collections.ServiceEnv)
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(serviceEnv); err != nil {
+			return err
+		}
+
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.RemoveByName("restart_required")
+		return app.Save(services)
+	})
+}
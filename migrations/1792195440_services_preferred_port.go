@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.Add(&core.NumberField{
+			Name:    "preferred_port",
+			System:  true,
+			OnlyInt: true,
+			Min:     utils.Ptr[float64](1),
+			Max:     utils.Ptr[float64](65535),
+		})
+		return app.Save(services)
+	}, func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.RemoveByName("preferred_port")
+		return app.Save(services)
+	})
+}
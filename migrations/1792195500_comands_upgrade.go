@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		releases, err := app.FindCollectionByNameOrId(collections.Releases)
+		if err != nil {
+			return err
+		}
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if action, ok := comands.Fields.GetByName("action").(*core.SelectField); ok {
+			action.Values = []string{"stop", "start", "restart", "reset", "upgrade"}
+		}
+		comands.Fields.Add(&core.RelationField{
+			Name:         "release", // target release of an upgrade
+			CollectionId: releases.Id,
+			System:       true,
+			MaxSelect:    1,
+		})
+		return app.Save(comands)
+	}, func(app core.App) error {
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if action, ok := comands.Fields.GetByName("action").(*core.SelectField); ok {
+			action.Values = []string{"stop", "start", "restart", "reset"}
+		}
+		comands.Fields.RemoveByName("release")
+		return app.Save(comands)
+	})
+}
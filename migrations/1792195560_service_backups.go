@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		retentionMin := 0.0
+		services.Fields.Add(
+			&core.TextField{
+				Name:   "backup_schedule", // cron expression, empty disables scheduled backups
+				System: true,
+			},
+			&core.NumberField{
+				Name:    "backup_retention", // 0 falls back to the launcher default
+				System:  true,
+				OnlyInt: true,
+				Min:     &retentionMin,
+			},
+		)
+		if err := app.Save(services); err != nil {
+			return err
+		}
+
+		backups := core.NewBaseCollection(collections.ServiceBackups)
+		backups.Fields.Add(
+			&core.RelationField{
+				Name:          "service",
+				CollectionId:  services.Id,
+				System:        true,
+				Required:      true,
+				CascadeDelete: true,
+				MinSelect:     1,
+				MaxSelect:     1,
+			},
+			&core.SelectField{
+				Name:      "status",
+				System:    true,
+				Required:  true,
+				MaxSelect: 1,
+				Values:    []string{"pending", "running", "success", "error"},
+			},
+			&core.SelectField{
+				Name:      "trigger",
+				System:    true,
+				Required:  true,
+				MaxSelect: 1,
+				Values:    []string{"manual", "scheduled"},
+			},
+			&core.TextField{
+				Name:   "key", // archive location inside the backup storage
+				System: true,
+			},
+			&core.NumberField{
+				Name:    "size",
+				System:  true,
+				OnlyInt: true,
+			},
+			&core.TextField{
+				Name:   "error_message",
+				System: true,
+			},
+			&core.SelectField{
+				Name:      "restore_status",
+				System:    true,
+				MaxSelect: 1,
+				Values:    []string{"pending", "running", "success", "error"},
+			},
+			&core.TextField{
+				Name:   "restore_error",
+				System: true,
+			},
+			&core.DateField{
+				Name:   "completed",
+				System: true,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				System:   true,
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				System:   true,
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		backups.Indexes = append(backups.Indexes,
+			`CREATE INDEX idx_service_backups_service ON service_backups(service, created)`,
+		)
+
+		backups.ListRule = utils.StrPointer(`@request.auth.id != ""`)
+		backups.ViewRule = utils.StrPointer(`@request.auth.id != ""`)
+		backups.CreateRule = utils.StrPointer(`@request.auth.id != ""`)
+		backups.DeleteRule = utils.StrPointer(`@request.auth.id != ""`)
+
+		return app.Save(backups)
+	}, func(app core.App) error {
+		backups, err := app.FindCollectionByNameOrId(collections.ServiceBackups)
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(backups); err != nil {
+			return err
+		}
+
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.RemoveByName("backup_schedule")
+		services.Fields.RemoveByName("backup_retention")
+		return app.Save(services)
+	})
+}
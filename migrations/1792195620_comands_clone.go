@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if action, ok := comands.Fields.GetByName("action").(*core.SelectField); ok {
+			action.Values = []string{"stop", "start", "restart", "reset", "upgrade", "clone"}
+		}
+		comands.Fields.Add(
+			&core.RelationField{
+				Name:         "source", // service copied by a clone
+				CollectionId: services.Id,
+				System:       true,
+				MaxSelect:    1,
+			},
+			&core.JSONField{
+				Name:    "options", // clone options
+				System:  true,
+				MaxSize: 1024,
+			},
+		)
+		return app.Save(comands)
+	}, func(app core.App) error {
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if action, ok := comands.Fields.GetByName("action").(*core.SelectField); ok {
+			action.Values = []string{"stop", "start", "restart", "reset", "upgrade"}
+		}
+		comands.Fields.RemoveByName("source")
+		comands.Fields.RemoveByName("options")
+		return app.Save(comands)
+	})
+}
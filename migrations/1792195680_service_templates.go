@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		releases, err := app.FindCollectionByNameOrId(collections.Releases)
+		if err != nil {
+			return err
+		}
+
+		templates := core.NewBaseCollection(collections.ServiceTemplates)
+		templates.Fields.Add(
+			&core.TextField{
+				Name:     "name",
+				System:   true,
+				Required: true,
+				Max:      100,
+			},
+			&core.TextField{
+				Name:   "description",
+				System: true,
+			},
+			&core.FileField{
+				// zip with pb_migrations/, pb_hooks/, pb_public/ and an optional seed.json
+				Name:      "archive",
+				System:    true,
+				Required:  true,
+				MaxSelect: 1,
+				MaxSize:   100 << 20,
+				Protected: true, // hooks may carry credentials
+				MimeTypes: []string{"application/zip", "application/x-zip-compressed"},
+			},
+			&core.RelationField{
+				Name:         "release", // default release of new services
+				CollectionId: releases.Id,
+				System:       true,
+				MaxSelect:    1,
+			},
+			&core.SelectField{
+				Name:      "restart_policy", // default restart policy of new services
+				System:    true,
+				MaxSelect: 1,
+				Values:    []string{"no", "on-failure"},
+			},
+			&core.AutodateField{
+				Name:     "created",
+				System:   true,
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				System:   true,
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		templates.Indexes = append(templates.Indexes,
+			`CREATE UNIQUE INDEX idx_service_templates_name ON service_templates(name)`,
+		)
+
+		templates.ListRule = utils.StrPointer(`@request.auth.id != ""`)
+		templates.ViewRule = utils.StrPointer(`@request.auth.id != ""`)
+		templates.CreateRule = utils.StrPointer(`@request.auth.id != ""`)
+		templates.UpdateRule = utils.StrPointer(`@request.auth.id != ""`)
+		templates.DeleteRule = utils.StrPointer(`@request.auth.id != ""`)
+
+		if err := app.Save(templates); err != nil {
+			return err
+		}
+
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.Add(&core.RelationField{
+			Name:         "template", // unpacked into the data directory before the first start
+			CollectionId: templates.Id,
+			System:       true,
+			MaxSelect:    1,
+		})
+		return app.Save(services)
+	}, func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.RemoveByName("template")
+		if err := app.Save(services); err != nil {
+			return err
+		}
+
+		templates, err := app.FindCollectionByNameOrId(collections.ServiceTemplates)
+		if err != nil {
+			return err
+		}
+		return app.Delete(templates)
+	})
+}
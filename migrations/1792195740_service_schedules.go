@@ -0,0 +1,104 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+
+		schedules := core.NewBaseCollection(collections.ServiceSchedules)
+		schedules.Fields.Add(
+			&core.RelationField{
+				Name:          "service",
+				CollectionId:  services.Id,
+				System:        true,
+				Required:      true,
+				CascadeDelete: true,
+				MinSelect:     1,
+				MaxSelect:     1,
+			},
+			&core.TextField{
+				Name:     "cron", // 5 field cron expression
+				System:   true,
+				Required: true,
+			},
+			&core.SelectField{
+				Name:      "action",
+				System:    true,
+				Required:  true,
+				MaxSelect: 1,
+				Values:    []string{"start", "stop", "restart"},
+			},
+			&core.TextField{
+				Name:   "timezone", // IANA name, empty is UTC
+				System: true,
+			},
+			&core.BoolField{
+				Name:   "enabled",
+				System: true,
+			},
+			&core.SelectField{
+				// runs missed while the launcher was down: skip them or run the latest one
+				Name:      "catch_up",
+				System:    true,
+				MaxSelect: 1,
+				Values:    []string{"skip", "once"},
+			},
+			&core.DateField{
+				Name:   "last_checked", // schedules are evaluated up to this time
+				System: true,
+			},
+			&core.DateField{
+				Name:   "last_run", // scheduled time of the last published command
+				System: true,
+			},
+			&core.RelationField{
+				Name:         "last_comand",
+				CollectionId: comands.Id,
+				System:       true,
+				MaxSelect:    1,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				System:   true,
+				OnCreate: true,
+			},
+			&core.AutodateField{
+				Name:     "updated",
+				System:   true,
+				OnCreate: true,
+				OnUpdate: true,
+			},
+		)
+		schedules.Indexes = append(schedules.Indexes,
+			`CREATE INDEX idx_service_schedules_service ON service_schedules(service)`,
+		)
+
+		schedules.ListRule = utils.StrPointer(`@request.auth.id != ""`)
+		schedules.ViewRule = utils.StrPointer(`@request.auth.id != ""`)
+		schedules.CreateRule = utils.StrPointer(`@request.auth.id != ""`)
+		schedules.UpdateRule = utils.StrPointer(`@request.auth.id != ""`)
+		schedules.DeleteRule = utils.StrPointer(`@request.auth.id != ""`)
+
+		return app.Save(schedules)
+	}, func(app core.App) error {
+		schedules, err := app.FindCollectionByNameOrId(collections.ServiceSchedules)
+		if err != nil {
+			return err
+		}
+		return app.Delete(schedules)
+	})
+}
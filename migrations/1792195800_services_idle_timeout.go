@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		if status, ok := services.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"idle", "running", "stopped", "failure", "crashloop", "sleeping"}
+		}
+		services.Fields.Add(&core.NumberField{
+			Name:    "idle_timeout", // seconds without proxied requests before sleeping, 0 disables
+			System:  true,
+			OnlyInt: true,
+			Min:     utils.Ptr[float64](0),
+		})
+		return app.Save(services)
+	}, func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		if _, err := app.DB().NewQuery(
+			"UPDATE " + collections.Services + " SET status = 'stopped' WHERE status = 'sleeping'",
+		).Execute(); err != nil {
+			return err
+		}
+		if status, ok := services.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"idle", "running", "stopped", "failure", "crashloop"}
+		}
+		services.Fields.RemoveByName("idle_timeout")
+		return app.Save(services)
+	})
+}
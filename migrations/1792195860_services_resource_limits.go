@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		// 0 leaves the resource unlimited
+		services.Fields.Add(
+			&core.NumberField{
+				Name:    "memory_limit_mb",
+				System:  true,
+				OnlyInt: true,
+				Min:     utils.Ptr[float64](0),
+			},
+			&core.NumberField{
+				Name:   "cpu_limit", // cores, 0.5 is half of one core
+				System: true,
+				Min:    utils.Ptr[float64](0),
+			},
+			&core.NumberField{
+				Name:    "pids_limit",
+				System:  true,
+				OnlyInt: true,
+				Min:     utils.Ptr[float64](0),
+			},
+			&core.NumberField{
+				Name:    "nofile_limit",
+				System:  true,
+				OnlyInt: true,
+				Min:     utils.Ptr[float64](0),
+			},
+			&core.SelectField{
+				Name:      "failure_reason", // set with the failure status
+				System:    true,
+				MaxSelect: 1,
+				Values:    []string{"start_failed", "exited", "oom_killed"},
+			},
+		)
+		return app.Save(services)
+	}, func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"memory_limit_mb", "cpu_limit", "pids_limit", "nofile_limit", "failure_reason"} {
+			services.Fields.RemoveByName(name)
+		}
+		return app.Save(services)
+	})
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.Add(&core.NumberField{
+			Name:    "uid", // Unix user of the service processes when isolation is enabled
+			System:  true,
+			OnlyInt: true,
+			Min:     utils.Ptr[float64](0),
+		})
+		return app.Save(services)
+	}, func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		services.Fields.RemoveByName("uid")
+		return app.Save(services)
+	})
+}
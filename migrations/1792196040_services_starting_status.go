@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		if status, ok := services.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"idle", "running", "stopped", "failure", "crashloop", "sleeping", "starting"}
+		}
+		return app.Save(services)
+	}, func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		// recovered as running services on the next boot
+		if _, err := app.DB().NewQuery(
+			"UPDATE " + collections.Services + " SET status = 'running' WHERE status = 'starting'",
+		).Execute(); err != nil {
+			return err
+		}
+		if status, ok := services.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"idle", "running", "stopped", "failure", "crashloop", "sleeping"}
+		}
+		return app.Save(services)
+	})
+}
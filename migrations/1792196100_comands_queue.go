@@ -0,0 +1,129 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// modules register their own actions, so the action is free text
+		// checked against the command registry by the hooks
+		if err := replaceComandAction(app, &core.TextField{
+			Name:     "action",
+			System:   true,
+			Required: true,
+			Max:      64,
+		}); err != nil {
+			return err
+		}
+
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if status, ok := comands.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"pending", "running", "success", "error", "cancelled"}
+		}
+		comands.Fields.Add(
+			&core.NumberField{
+				Name:    "timeout", // seconds, 0 means no limit
+				System:  true,
+				OnlyInt: true,
+				Min:     utils.Ptr[float64](0),
+				Max:     utils.Ptr[float64](86400),
+			},
+			&core.NumberField{
+				Name:    "max_retries",
+				System:  true,
+				OnlyInt: true,
+				Min:     utils.Ptr[float64](0),
+				Max:     utils.Ptr[float64](10),
+			},
+			&core.NumberField{
+				Name:    "attempts",
+				System:  true,
+				OnlyInt: true,
+				Min:     utils.Ptr[float64](0),
+			},
+			&core.JSONField{
+				Name:    "result",
+				System:  true,
+				MaxSize: 16384,
+			},
+		)
+		// only the cancellation of pending commands, enforced by the hooks
+		comands.UpdateRule = utils.StrPointer(`@request.auth.id != ""`)
+		return app.Save(comands)
+	}, func(app core.App) error {
+		builtin := []string{"stop", "start", "restart", "reset", "upgrade", "clone"}
+		if _, err := app.DB().NewQuery(
+			"DELETE FROM " + collections.ServicesComands +
+				" WHERE action NOT IN ('stop', 'start', 'restart', 'reset', 'upgrade', 'clone')",
+		).Execute(); err != nil {
+			return err
+		}
+		if _, err := app.DB().NewQuery(
+			"UPDATE " + collections.ServicesComands + " SET status = CASE status" +
+				" WHEN 'running' THEN 'pending' WHEN 'cancelled' THEN 'error' ELSE status END",
+		).Execute(); err != nil {
+			return err
+		}
+
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		if status, ok := comands.Fields.GetByName("status").(*core.SelectField); ok {
+			status.Values = []string{"pending", "success", "error"}
+		}
+		comands.Fields.RemoveByName("timeout")
+		comands.Fields.RemoveByName("max_retries")
+		comands.Fields.RemoveByName("attempts")
+		comands.Fields.RemoveByName("result")
+		comands.UpdateRule = nil
+		if err := app.Save(comands); err != nil {
+			return err
+		}
+
+		return replaceComandAction(app, &core.SelectField{
+			Name:      "action",
+			Values:    builtin,
+			System:    true,
+			Required:  true,
+			MaxSelect: 1,
+		})
+	})
+}
+
+// replaceComandAction swaps the action field of the comands collection for
+// field, which may be of another type, keeping the stored actions. System
+// fields can't be renamed or removed, so the old field is released first.
+func replaceComandAction(app core.App, field core.Field) error {
+	comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+	if err != nil {
+		return err
+	}
+	old := comands.Fields.GetByName("action")
+	old.SetSystem(false)
+	if err := app.Save(comands); err != nil {
+		return err
+	}
+
+	old.SetName("legacy_action")
+	comands.Fields.Add(field)
+	if err := app.Save(comands); err != nil {
+		return err
+	}
+	if _, err := app.DB().NewQuery(
+		"UPDATE " + collections.ServicesComands + " SET action = legacy_action",
+	).Execute(); err != nil {
+		return err
+	}
+
+	comands.Fields.RemoveByName("legacy_action")
+	return app.Save(comands)
+}
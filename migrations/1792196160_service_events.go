@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"pb_launcher/collections"
+	"pb_launcher/utils"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+var serviceEventCauses = []string{"user", "schedule", "crash", "health_check", "idle", "wake", "system"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		services, err := app.FindCollectionByNameOrId(collections.Services)
+		if err != nil {
+			return err
+		}
+		statuses := []string{"idle", "running", "stopped", "failure", "crashloop", "sleeping", "starting"}
+
+		events := core.NewBaseCollection(collections.ServiceEvents)
+		events.Fields.Add(
+			&core.RelationField{
+				Name:          "service",
+				CollectionId:  services.Id,
+				System:        true,
+				Required:      true,
+				CascadeDelete: true,
+				MinSelect:     1,
+				MaxSelect:     1,
+			},
+			&core.SelectField{
+				Name:      "from",
+				System:    true,
+				MaxSelect: 1,
+				Values:    statuses,
+			},
+			&core.SelectField{
+				Name:      "to",
+				System:    true,
+				Required:  true,
+				MaxSelect: 1,
+				Values:    statuses,
+			},
+			&core.SelectField{
+				Name:      "cause",
+				System:    true,
+				Required:  true,
+				MaxSelect: 1,
+				Values:    serviceEventCauses,
+			},
+			&core.TextField{
+				Name:   "error_message",
+				System: true,
+			},
+			&core.AutodateField{
+				Name:     "created",
+				System:   true,
+				OnCreate: true,
+			},
+		)
+		events.Indexes = append(events.Indexes,
+			`CREATE INDEX idx_service_events_service_created ON service_events(service, created)`,
+		)
+		// written by the launcher only
+		events.ListRule = utils.StrPointer(`@request.auth.id != ""`)
+		events.ViewRule = utils.StrPointer(`@request.auth.id != ""`)
+		if err := app.Save(events); err != nil {
+			return err
+		}
+
+		// the cause of a command carries over to the status changes it makes
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		comands.Fields.Add(&core.SelectField{
+			Name:      "cause",
+			System:    true,
+			MaxSelect: 1,
+			Values:    serviceEventCauses,
+		})
+		return app.Save(comands)
+	}, func(app core.App) error {
+		comands, err := app.FindCollectionByNameOrId(collections.ServicesComands)
+		if err != nil {
+			return err
+		}
+		comands.Fields.RemoveByName("cause")
+		if err := app.Save(comands); err != nil {
+			return err
+		}
+
+		events, err := app.FindCollectionByNameOrId(collections.ServiceEvents)
+		if err != nil {
+			return err
+		}
+		return app.Delete(events)
+	})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/promexport"
+	"pb_launcher/helpers/serialexecutor"
+	"pb_launcher/internal/certificates/tlscommon"
+	certmanager "pb_launcher/internal/certmanager/domain"
+	"pb_launcher/utils/domainutil"
+	"time"
+
+	"go.uber.org/fx"
+)
+
+// tasks range from a few milliseconds (command polling) to minutes
+// (release downloads, backups)
+var taskDurationBuckets = []float64{.001, .01, .1, .5, 1, 5, 10, 30, 60, 300}
+
+// StartPrometheusExporter serves the launcher metrics on their own listener
+// when prometheus.listen_address is set. It must run before the executor
+// starts so task durations are observed.
+func StartPrometheusExporter(
+	lc fx.Lifecycle,
+	registry *promexport.Registry,
+	executor *serialexecutor.SequentialExecutor,
+	store tlscommon.Store,
+	planner *certmanager.CertRequestPlannerUsecase,
+	cfg configs.Config,
+) error {
+	addr := cfg.GetPrometheusListenAddress()
+	if addr == "" {
+		return nil
+	}
+
+	taskDuration := registry.NewHistogramVec("pb_launcher_task_duration_seconds",
+		"Duration of each run of the launcher background tasks.", taskDurationBuckets, "task")
+	if err := executor.SetObserver(func(task string, duration time.Duration) {
+		taskDuration.Observe(duration.Seconds(), task)
+	}); err != nil {
+		return err
+	}
+
+	if cfg.IsHttpsEnabled() {
+		registerCertificateTTL(registry, store, planner, cfg)
+	}
+
+	token := cfg.GetPrometheusBearerToken()
+	if token == "" {
+		slog.Warn("prometheus exporter has no bearer token, anyone reaching it can read the metrics", "address", addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", registry.Handler(token))
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				slog.Info("Starting prometheus exporter", "address", addr)
+				if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("prometheus exporter error", "error", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	})
+	return nil
+}
+
+// registerCertificateTTL reports the remaining lifetime of the wildcard
+// certificate and of every custom domain with https enabled. Expired
+// certificates report zero or less; missing ones are left out.
+func registerCertificateTTL(
+	registry *promexport.Registry,
+	store tlscommon.Store,
+	planner *certmanager.CertRequestPlannerUsecase,
+	cfg configs.Config,
+) {
+	wildcardDomain := domainutil.ToWildcardDomain(cfg.GetDomain())
+	registry.NewGaugeFunc("pb_launcher_certificate_ttl_seconds",
+		"Seconds until the certificate of each domain expires.",
+		[]string{"domain"},
+		func(ctx context.Context, set func(float64, ...string)) error {
+			domains, err := planner.Domains(ctx)
+			if err != nil {
+				return err
+			}
+			for _, domain := range append([]string{wildcardDomain}, domains...) {
+				cert, err := store.Resolve(domain)
+				switch {
+				case err == nil:
+					set(cert.GetTTL().Seconds(), domain)
+				case errors.Is(err, tlscommon.ErrCertificateExpired):
+					set(0, domain)
+				case !errors.Is(err, tlscommon.ErrCertificateNotFound):
+					slog.Warn("failed to resolve certificate", "domain", domain, "error", err)
+				}
+			}
+			return nil
+		})
+}
@@ -22,5 +22,5 @@ func RegisterBinaryReleaseSync(
 		config.GetReleaseSyncInterval(),
 		99999,
 	)
-	return executor.Add(releaseSyncTask)
+	return executor.Add(releaseSyncTask.WithName("releaseSync"))
 }
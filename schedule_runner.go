@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/serialexecutor"
+	schedules "pb_launcher/internal/schedules/domain"
+)
+
+func RegisterServiceScheduler(
+	executor *serialexecutor.SequentialExecutor,
+	scheduler *schedules.Scheduler,
+	config configs.Config) error {
+
+	schedulerTask := serialexecutor.NewTask(
+		func(ctx context.Context) {
+			if err := scheduler.Run(ctx); err != nil {
+				slog.Error("service scheduler failed", "error", err, "task", "serviceScheduler")
+			}
+		},
+		config.GetScheduleCheckInterval(),
+		9500,
+	)
+
+	return executor.Add(schedulerTask.WithName("serviceScheduler"))
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"pb_launcher/configs"
+	"pb_launcher/helpers/serialexecutor"
+	trash "pb_launcher/internal/trash/domain"
+)
+
+func RegisterTrashPurge(
+	executor *serialexecutor.SequentialExecutor,
+	trashManager *trash.TrashManager,
+	config configs.Config) error {
+
+	purgeTask := serialexecutor.NewTask(
+		func(ctx context.Context) {
+			if err := trashManager.Purge(ctx); err != nil {
+				slog.Error("trash purge failed", "error", err, "task", "trashPurge")
+			}
+		},
+		config.GetTrashPurgeInterval(),
+		8000,
+	)
+
+	return executor.Add(purgeTask.WithName("trashPurge"))
+}
@@ -14,7 +14,7 @@ import { useNavigate } from "react-router-dom";
 import { useProxyConfigs } from "../../hooks/useProxyConfigs";
 
 const STATUS_FILTER_KEY = "pb-dashboard-status-filter";
-type TStatus = "all" | "running" | "stopped";
+type TStatus = "all" | "running" | "stopped" | "sleeping";
 export const ServicesPage = () => {
   const navigate = useNavigate();
   const { openModal } = useModal();
@@ -26,6 +26,13 @@ export const ServicesPage = () => {
     queryFn: serviceService.fetchAllServices,
     refetchInterval: 3000,
   });
+  const recoveryQuery = useQuery({
+    queryKey: ["services-recovery"],
+    queryFn: serviceService.getRecoveryProgress,
+    // polls until the recovery at launcher boot finished
+    refetchInterval: ({ state }) => (state.data?.finished ? false : 2000),
+  });
+  const recovery = recoveryQuery.data;
 
   const [query, setQuery] = useState("");
   const [statusFilter, setStatusFilter] = useLocalStorage<{ value: TStatus }>(
@@ -48,6 +55,8 @@ export const ServicesPage = () => {
             return s.status === "running";
           case "stopped":
             return s.status === "stopped";
+          case "sleeping":
+            return s.status === "sleeping";
         }
       });
   }, [servicesQuery.data, query, statusFilter]);
@@ -115,6 +124,13 @@ export const ServicesPage = () => {
 
   return (
     <div className="space-y-6">
+      {recovery?.running && (
+        <div className="alert alert-info text-sm">
+          Recovering services {recovery.done}/{recovery.total}
+          {recovery.failures.length > 0 &&
+            ` (${recovery.failures.length} failed)`}
+        </div>
+      )}
       <div className="flex flex-col sm:flex-row sm:items-center justify-between gap-4">
         <div className="flex gap-2 w-full sm:max-w-md">
           <input
@@ -147,6 +163,7 @@ export const ServicesPage = () => {
             <option value="all">All</option>
             <option value="running">Running</option>
             <option value="stopped">Stopped</option>
+            <option value="sleeping">Sleeping</option>
           </select>
           <button
             className="btn btn-sm btn-primary gap-2 w-full sm:w-auto"
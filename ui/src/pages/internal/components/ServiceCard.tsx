@@ -115,12 +115,14 @@ export const ServiceCard: FC<Props> = ({
                         <button
                           disabled={
                             service.status === "running" ||
-                            service.status === "pending"
+                            service.status === "pending" ||
+                            service.status === "starting"
                           }
                           className={classNames(
                             "flex items-center gap-2 w-full px-2 py-1 rounded-md text-left",
                             service.status === "running" ||
-                              service.status === "pending"
+                              service.status === "pending" ||
+                              service.status === "starting"
                               ? "text-base-content/60 cursor-not-allowed"
                               : "text-success hover:bg-success/10 hover:text-success",
                           )}
@@ -185,13 +187,21 @@ export const ServiceCard: FC<Props> = ({
               className={classNames("badge badge-sm", {
                 "badge-success": service.status === "running",
                 "badge-warning":
-                  service.status === "pending" || service.status === "idle",
-                "badge-error": service.status === "failure",
+                  service.status === "pending" ||
+                  service.status === "idle" ||
+                  service.status === "starting",
+                "badge-error":
+                  service.status === "failure" ||
+                  service.status === "crashloop",
+                "badge-info": service.status === "sleeping",
                 "badge-neutral": ![
                   "running",
                   "pending",
                   "idle",
+                  "starting",
                   "failure",
+                  "crashloop",
+                  "sleeping",
                 ].includes(service.status),
               })}
             >
@@ -44,11 +44,21 @@ export const GeneralSection: FC<Props> = ({ service_id }) => {
         className={classNames("badge badge-sm absolute -top-2 right-2", {
           "badge-success": service.status === "running",
           "badge-warning":
-            service.status === "pending" || service.status === "idle",
-          "badge-error": service.status === "failure",
-          "badge-neutral": !["running", "pending", "idle", "failure"].includes(
-            service.status,
-          ),
+            service.status === "pending" ||
+            service.status === "idle" ||
+            service.status === "starting",
+          "badge-error":
+            service.status === "failure" || service.status === "crashloop",
+          "badge-info": service.status === "sleeping",
+          "badge-neutral": ![
+            "running",
+            "pending",
+            "idle",
+            "starting",
+            "failure",
+            "crashloop",
+            "sleeping",
+          ].includes(service.status),
         })}
       >
         {status}
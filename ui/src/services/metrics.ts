@@ -0,0 +1,45 @@
+import { joinUrls } from "../utils/url";
+import { HttpError } from "./client/errors";
+import { pb } from "./client/pb";
+
+export interface ServiceMetricsSample {
+  service_id: string;
+  timestamp: string; // ISO 8601 format
+  pid: number;
+  cpu_percent: number; // of one core, since the previous sample
+  cpu_seconds: number;
+  rss_bytes: number;
+  open_fds: number;
+  disk_bytes: number; // size of the service directory
+}
+
+export interface ServiceMetricsDto {
+  current: ServiceMetricsSample | null; // null when the service is not running
+  history: ServiceMetricsSample[];
+}
+
+export const metricsService = {
+  // since is a Go duration such as "1h" or "30m"
+  fetchServiceMetrics: async (
+    service_id: string,
+    since = "1h",
+    signal?: AbortSignal,
+  ): Promise<ServiceMetricsDto> => {
+    const url =
+      joinUrls(pb.baseURL, `/x-api/service/${service_id}/metrics`) +
+      `?${new URLSearchParams({ since })}`;
+    const response = await fetch(url, {
+      signal,
+      headers: { Authorization: pb.authStore.token },
+    });
+    const json = await response.json();
+    if (!response.ok) {
+      throw new HttpError(
+        response.status,
+        json?.message || "Unexpected error",
+        json,
+      );
+    }
+    return json as ServiceMetricsDto;
+  },
+};
@@ -0,0 +1,40 @@
+import { pb } from "./client/pb";
+
+export const SCHEDULES_COLLECTION = "service_schedules";
+
+export interface ServiceScheduleDto {
+  id: string;
+  service: string;
+  cron: string; // 5 field cron expression
+  action: "start" | "stop" | "restart";
+  timezone: string; // IANA name, empty is UTC
+  enabled: boolean;
+  catch_up: "skip" | "once"; // runs missed while the launcher was down
+  last_run: string;
+}
+
+type ScheduleInput = Omit<ServiceScheduleDto, "id" | "last_run">;
+
+export const scheduleService = {
+  fetchByService: async (service_id: string) => {
+    const schedules = pb.collection(SCHEDULES_COLLECTION);
+    return schedules.getFullList<ServiceScheduleDto>({
+      filter: pb.filter("service = {:service_id}", { service_id }),
+      fields: "id,service,cron,action,timezone,enabled,catch_up,last_run",
+      sort: "created",
+    });
+  },
+  create: async (data: ScheduleInput) => {
+    return pb
+      .collection(SCHEDULES_COLLECTION)
+      .create<ServiceScheduleDto>(data);
+  },
+  update: async (id: string, data: Partial<Omit<ScheduleInput, "service">>) => {
+    return pb
+      .collection(SCHEDULES_COLLECTION)
+      .update<ServiceScheduleDto>(id, data);
+  },
+  delete: async (id: string) => {
+    await pb.collection(SCHEDULES_COLLECTION).delete(id);
+  },
+};
@@ -4,10 +4,82 @@ import { pb } from "./client/pb";
 import { COMANDS_COLLECTION } from "./release";
 import { domainsService, type DomainDto } from "./services_domain";
 
+export type ServiceCommandAction =
+  | "stop"
+  | "start"
+  | "restart"
+  | "reset"
+  | "upgrade"
+  | "reset-superuser"
+  | "vacuum"
+  | "backup";
+
+export interface ServiceCommandDto {
+  id: string;
+  service: string;
+  action: ServiceCommandAction | "clone";
+  status: "pending" | "running" | "success" | "error" | "cancelled";
+  error_message: string;
+  timeout: number;
+  max_retries: number;
+  attempts: number;
+  not_before: string;
+  executed: string;
+  result: unknown; // set by the action, e.g. the sealed password of reset-superuser
+  created: string;
+}
+
+export type ServiceFileArea = "hooks" | "public" | "migrations";
+
+export interface ServiceFileEntry {
+  path: string; // relative to the area, slash separated
+  dir: boolean;
+  size: number;
+  modified: string;
+}
+
+const filesUrl = (
+  service_id: string,
+  area: ServiceFileArea,
+  path = "",
+  restart?: boolean,
+) => {
+  const encoded = path.split("/").filter(Boolean).map(encodeURIComponent);
+  // directories are addressed with a trailing slash
+  const url =
+    joinUrls(pb.baseURL, `/x-api/service/${service_id}/files/${area}`, ...encoded) +
+    (encoded.length ? "" : "/");
+  return restart ? `${url}?restart=true` : url;
+};
+
+const filesRequest = async <T>(url: string, init: RequestInit = {}) => {
+  const response = await fetch(url, {
+    ...init,
+    headers: { Authorization: pb.authStore.token, ...init.headers },
+  });
+  const json = await response.json();
+  if (!response.ok) {
+    throw new HttpError(
+      response.status,
+      json?.message || "Unexpected error",
+      json,
+    );
+  }
+  return json as T;
+};
+
 interface _Service {
   id: string;
   name: string;
-  status: "idle" | "pending" | "running" | "stopped" | "failure";
+  status:
+    | "idle"
+    | "pending"
+    | "starting" // spawned, waiting for /api/health
+    | "running"
+    | "stopped"
+    | "failure"
+    | "crashloop"
+    | "sleeping"; // stopped after idle_timeout, started by the next request
 
   _pb_install: string;
   boot_user_email: string;
@@ -16,6 +88,15 @@ interface _Service {
 
   restart_policy: string;
   error_message: string;
+  failure_reason?: "" | "start_failed" | "exited" | "oom_killed";
+
+  // 0 leaves the resource unlimited
+  memory_limit_mb?: number;
+  cpu_limit?: number; // cores
+  pids_limit?: number;
+  nofile_limit?: number;
+
+  boot_priority?: number; // higher values are recovered first at launcher boot
 
   created: string;
 
@@ -52,14 +133,16 @@ export type ServiceDto = Omit<_Service, "expand"> & { domains?: DomainDto[] };
 export const serviceService = {
   createServiceInstance: async (data: {
     name: string;
-    release: string;
+    release: string; // may be empty with a template, which provides it
     restart_policy: string;
+    template?: string;
   }) => {
     const services = pb.collection(SERVICES_COLLECTION);
     await services.create({
       name: data.name,
       release: data.release,
       restart_policy: data.restart_policy,
+      template: data.template,
     });
   },
   updateServiceInstance: async (data: {
@@ -174,11 +257,197 @@ export const serviceService = {
 
   executeServiceCommand: async (data: {
     service_id: string;
-    action: "stop" | "start" | "restart";
+    action: ServiceCommandAction;
+    release?: string; // target release of an upgrade
+    timeout?: number; // seconds, 0 means no limit
+    max_retries?: number;
   }) => {
     const comands = pb.collection(COMANDS_COLLECTION);
-    await comands.create({ service: data.service_id, action: data.action });
+    // an identical pending command is returned instead of a new one
+    return await comands.create<ServiceCommandDto>({
+      service: data.service_id,
+      action: data.action,
+      release: data.release,
+      timeout: data.timeout,
+      max_retries: data.max_retries,
+    });
+  },
+  cancelServiceCommand: async (id: string) => {
+    const comands = pb.collection(COMANDS_COLLECTION);
+    await comands.update(id, { status: "cancelled" });
+  },
+  // the password of a reset-superuser command can only be read once
+  readResetSuperuserPassword: async (comand_id: string) => {
+    const url = joinUrls(
+      pb.baseURL,
+      `/x-api/comands/${comand_id}/superuser-password`,
+    );
+    const response = await fetch(url, {
+      method: "POST",
+      headers: { Authorization: pb.authStore.token },
+    });
+    const json = await response.json();
+    if (!response.ok) {
+      throw new HttpError(
+        response.status,
+        json?.message || "Unexpected error",
+        json,
+      );
+    }
+    return json as { email: string; password: string };
+  },
+  cloneService: async (
+    service_id: string,
+    data: {
+      name?: string;
+      release?: string; // defaults to the release of the source
+      drop_auth_records?: boolean;
+      rotate_superuser?: boolean;
+    },
+  ) => {
+    const url = joinUrls(pb.baseURL, `/x-api/service/${service_id}/clone`);
+    const response = await fetch(url, {
+      method: "POST",
+      headers: {
+        Authorization: pb.authStore.token,
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify(data),
+    });
+    const json = await response.json();
+    if (!response.ok) {
+      throw new HttpError(
+        response.status,
+        json?.message || "Unexpected error",
+        json,
+      );
+    }
+    return json as { service: string; comand: string };
   },
+  listTrash: async () => {
+    const url = joinUrls(pb.baseURL, `/x-api/services/trash`);
+    const response = await fetch(url, {
+      headers: { Authorization: pb.authStore.token },
+    });
+    const json = await response.json();
+    if (!response.ok) {
+      throw new HttpError(
+        response.status,
+        json?.message || "Unexpected error",
+        json,
+      );
+    }
+    return json as {
+      id: string;
+      name: string;
+      deleted: string;
+      purge_after: string;
+    }[];
+  },
+  getRecoveryProgress: async () => {
+    const url = joinUrls(pb.baseURL, `/x-api/services/recovery`);
+    const response = await fetch(url, {
+      headers: { Authorization: pb.authStore.token },
+    });
+    const json = await response.json();
+    if (!response.ok) {
+      throw new HttpError(
+        response.status,
+        json?.message || "Unexpected error",
+        json,
+      );
+    }
+    return json as {
+      running: boolean;
+      total: number;
+      done: number;
+      failures: { service_id: string; error: string }[];
+      started: string | null;
+      finished: string | null;
+    };
+  },
+  restoreService: async (service_id: string) => {
+    const url = joinUrls(pb.baseURL, `/x-api/service/${service_id}/restore`);
+    const response = await fetch(url, {
+      method: "POST",
+      headers: { Authorization: pb.authStore.token },
+    });
+    const json = await response.json();
+    if (!response.ok) {
+      throw new HttpError(
+        response.status,
+        json?.message || "Unexpected error",
+        json,
+      );
+    }
+    return json as { id: string; status: string };
+  },
+  listFiles: (service_id: string, area: ServiceFileArea, dir = "") =>
+    filesRequest<ServiceFileEntry[]>(filesUrl(service_id, area, dir)),
+  readFile: async (service_id: string, area: ServiceFileArea, path: string) => {
+    const response = await fetch(filesUrl(service_id, area, path), {
+      headers: { Authorization: pb.authStore.token },
+    });
+    if (!response.ok) {
+      const json = await response.json().catch(() => null);
+      throw new HttpError(
+        response.status,
+        json?.message || "Unexpected error",
+        json,
+      );
+    }
+    return response.blob();
+  },
+  uploadFile: (
+    service_id: string,
+    area: ServiceFileArea,
+    path: string,
+    content: Blob | string,
+    restart = false,
+  ) =>
+    filesRequest<{ file: ServiceFileEntry; restart: boolean }>(
+      filesUrl(service_id, area, path, restart),
+      { method: "PUT", body: content },
+    ),
+  // extracts a zip archive into dir, keeping the archive layout
+  extractFiles: (
+    service_id: string,
+    area: ServiceFileArea,
+    archive: Blob,
+    dir = "",
+    restart = false,
+  ) => {
+    const query = new URLSearchParams();
+    if (dir) query.set("dir", dir);
+    if (restart) query.set("restart", "true");
+    const url = `${filesUrl(service_id, area, "extract")}?${query}`;
+    return filesRequest<{ files: string[]; restart: boolean }>(url, {
+      method: "POST",
+      body: archive,
+    });
+  },
+  renameFile: (
+    service_id: string,
+    area: ServiceFileArea,
+    from: string,
+    to: string,
+    restart = false,
+  ) =>
+    filesRequest<{ restart: boolean }>(filesUrl(service_id, area, "rename"), {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ from, to, restart }),
+    }),
+  deleteFile: (
+    service_id: string,
+    area: ServiceFileArea,
+    path: string,
+    restart = false,
+  ) =>
+    filesRequest<{ restart: boolean }>(
+      filesUrl(service_id, area, path, restart),
+      { method: "DELETE" },
+    ),
   upsertSuperuser: async (service_id: string) => {
     const url = joinUrls(pb.baseURL, `/x-api/upsert_superuser/${service_id}`);
     const response = await fetch(url, {
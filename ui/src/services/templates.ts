@@ -0,0 +1,35 @@
+import { pb } from "./client/pb";
+
+export const TEMPLATES_COLLECTION = "service_templates";
+
+export interface ServiceTemplateDto {
+  id: string;
+  name: string;
+  description: string;
+  release: string;
+  restart_policy: string;
+}
+
+export const templateService = {
+  fetchAll: async () => {
+    const templates = pb.collection(TEMPLATES_COLLECTION);
+    return templates.getFullList<ServiceTemplateDto>({
+      fields: "id,name,description,release,restart_policy",
+      sort: "name",
+    });
+  },
+  // archive: zip with pb_migrations/, pb_hooks/, pb_public/ and an optional seed.json
+  create: async (data: {
+    name: string;
+    description?: string;
+    archive: File;
+    release?: string;
+    restart_policy?: string;
+  }) => {
+    const templates = pb.collection(TEMPLATES_COLLECTION);
+    return templates.create<ServiceTemplateDto>(data);
+  },
+  delete: async (id: string) => {
+    await pb.collection(TEMPLATES_COLLECTION).delete(id);
+  },
+};
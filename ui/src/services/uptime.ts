@@ -0,0 +1,51 @@
+import { joinUrls } from "../utils/url";
+import { HttpError } from "./client/errors";
+import { pb } from "./client/pb";
+
+export interface ServiceUptimeDto {
+  service_id: string;
+  from: string; // ISO 8601 format, clipped to the creation of the service
+  to: string; // ISO 8601 format, clipped to now
+  observed_seconds: number;
+  uptime_seconds: number;
+  uptime_percent: number | null; // null when nothing was observed
+  mtbf_seconds: number | null; // null without failures
+  failures: number;
+  restarts: number;
+}
+
+// from and to default to the last 7 days
+const uptimeQuery = (from?: Date, to?: Date) => {
+  const params = new URLSearchParams();
+  if (from) params.set("from", from.toISOString());
+  if (to) params.set("to", to.toISOString());
+  const query = params.toString();
+  return query ? `?${query}` : "";
+};
+
+const fetchUptime = async <T>(path: string, from?: Date, to?: Date) => {
+  const url = joinUrls(pb.baseURL, path) + uptimeQuery(from, to);
+  const response = await fetch(url, {
+    headers: { Authorization: pb.authStore.token },
+  });
+  const json = await response.json();
+  if (!response.ok) {
+    throw new HttpError(
+      response.status,
+      json?.message || "Unexpected error",
+      json,
+    );
+  }
+  return json as T;
+};
+
+export const uptimeService = {
+  fetchServiceUptime: (service_id: string, from?: Date, to?: Date) =>
+    fetchUptime<ServiceUptimeDto>(
+      `/x-api/service/uptime/${service_id}`,
+      from,
+      to,
+    ),
+  fetchServicesUptime: (from?: Date, to?: Date) =>
+    fetchUptime<ServiceUptimeDto[]>(`/x-api/services/uptime`, from, to),
+};
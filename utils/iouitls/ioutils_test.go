@@ -2,6 +2,8 @@ package iouitls_test
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 	"pb_launcher/utils/iouitls"
 	"testing"
 )
@@ -28,3 +30,34 @@ func TestWriterInterceptor_Write(t *testing.T) {
 		t.Fatalf("expected intercepted to have %q, got %q", input, string(intercepted))
 	}
 }
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "storage", "files"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "data.db"), []byte("db"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "storage", "files", "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	if err := iouitls.CopyDir(src, dst); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dst, "storage", "files", "a.txt"))
+	if err != nil || string(data) != "a" {
+		t.Fatalf("nested file not copied: %q, %v", data, err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "data.db"))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("file mode not kept: %v, %v", info, err)
+	}
+
+	if err := iouitls.CopyDir(src, dst); err == nil {
+		t.Fatalf("expected error when destination exists")
+	}
+}
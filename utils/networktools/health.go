@@ -0,0 +1,43 @@
+package networktools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const HealthPath = "/api/health"
+
+var healthClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:             nil,
+		DisableKeepAlives: true,
+	},
+}
+
+// CheckHealth performs a GET request against the PocketBase health endpoint
+// of the instance listening on ip:port and fails unless it answers 200 OK
+// within the given timeout.
+func CheckHealth(ctx context.Context, ip string, port int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(ip, strconv.Itoa(port)), HealthPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected health status: %d", resp.StatusCode)
+	}
+	return nil
+}
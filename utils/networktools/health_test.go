@@ -0,0 +1,62 @@
+package networktools_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"pb_launcher/utils/networktools"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func splitTestServerAddr(t *testing.T, srv *httptest.Server) (string, int) {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to split server address: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+func TestCheckHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != networktools.HealthPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ip, port := splitTestServerAddr(t, srv)
+	if err := networktools.CheckHealth(context.Background(), ip, port, time.Second); err != nil {
+		t.Fatalf("expected healthy instance, got %v", err)
+	}
+}
+
+func TestCheckHealth_UnexpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ip, port := splitTestServerAddr(t, srv)
+	if err := networktools.CheckHealth(context.Background(), ip, port, time.Second); err == nil {
+		t.Fatal("expected error for non-200 status, got nil")
+	}
+}
+
+func TestCheckHealth_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	ip, port := splitTestServerAddr(t, srv)
+	if err := networktools.CheckHealth(context.Background(), ip, port, 50*time.Millisecond); err == nil {
+		t.Fatal("expected timeout error, got nil")
+	}
+}
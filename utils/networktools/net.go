@@ -1,10 +1,14 @@
 package networktools
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"strconv"
 )
 
+var ErrNoPortAvailable = errors.New("no available port in range")
+
 // GetAvailablePort tries to bind to a random available port on the given IP address segment.
 // Example: ipSegment = "127.0.0.2" → returns ("127.0.0.2", 49231, nil)
 func GetAvailablePort(ipAddress string) (string, int, error) {
@@ -21,3 +25,33 @@ func GetAvailablePort(ipAddress string) (string, int, error) {
 	addr := listener.Addr().(*net.TCPAddr)
 	return ipAddress, addr.Port, nil
 }
+
+// IsPortAvailable reports whether ipAddress:port can currently be bound.
+func IsPortAvailable(ipAddress string, port int) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort(ipAddress, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// GetAvailablePortInRange returns the first bindable port within [start, end]
+// on the given IP address, skipping the ports listed in exclude.
+func GetAvailablePortInRange(ipAddress string, start, end int, exclude map[int]struct{}) (string, int, error) {
+	if net.ParseIP(ipAddress) == nil {
+		return "", 0, fmt.Errorf("invalid IP address: %s", ipAddress)
+	}
+	if start < 1 || end > 65535 || start > end {
+		return "", 0, fmt.Errorf("invalid port range: %d-%d", start, end)
+	}
+	for port := start; port <= end; port++ {
+		if _, excluded := exclude[port]; excluded {
+			continue
+		}
+		if IsPortAvailable(ipAddress, port) {
+			return ipAddress, port, nil
+		}
+	}
+	return "", 0, fmt.Errorf("%w: %d-%d", ErrNoPortAvailable, start, end)
+}
@@ -1,6 +1,7 @@
 package networktools_test
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"pb_launcher/utils/networktools"
@@ -39,3 +40,62 @@ func TestGetAvailablePort_InvalidIP(t *testing.T) {
 		t.Error("Expected error for invalid IP, got nil")
 	}
 }
+
+func TestIsPortAvailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if networktools.IsPortAvailable("127.0.0.1", port) {
+		t.Errorf("expected port %d to be in use", port)
+	}
+	listener.Close()
+
+	if !networktools.IsPortAvailable("127.0.0.1", port) {
+		t.Errorf("expected port %d to be available after close", port)
+	}
+}
+
+func TestGetAvailablePortInRange(t *testing.T) {
+	_, base, err := networktools.GetAvailablePort("127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to find base port: %v", err)
+	}
+	if base > 65530 {
+		base = 40000
+	}
+
+	_, port, err := networktools.GetAvailablePortInRange("127.0.0.1", base, base+5,
+		map[int]struct{}{base: {}})
+	if err != nil {
+		t.Fatalf("expected a port, got error: %v", err)
+	}
+	if port == base {
+		t.Errorf("excluded port %d was returned", base)
+	}
+	if port < base || port > base+5 {
+		t.Errorf("port %d outside of range %d-%d", port, base, base+5)
+	}
+}
+
+func TestGetAvailablePortInRange_Exhausted(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	_, _, err = networktools.GetAvailablePortInRange("127.0.0.1", port, port, nil)
+	if !errors.Is(err, networktools.ErrNoPortAvailable) {
+		t.Fatalf("expected ErrNoPortAvailable, got %v", err)
+	}
+}
+
+func TestGetAvailablePortInRange_InvalidRange(t *testing.T) {
+	if _, _, err := networktools.GetAvailablePortInRange("127.0.0.1", 9000, 8000, nil); err == nil {
+		t.Error("expected error for inverted range, got nil")
+	}
+}